@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -34,6 +35,523 @@ type NamespaceClassSpec struct {
 	// foo is an example field of NamespaceClass. Edit namespaceclass_types.go to remove/update
 	// +optional
 	Resources []runtime.RawExtension `json:"resources,omitempty"`
+
+	// Sources add resources stored outside the class, which keeps large classes under the object
+	// size limit and lets classes share resources. Their resources are applied after the inline
+	// resources, in the order the sources are listed. Sources are read when a binding applies the
+	// class, so changing a source updates bound namespaces without a new class generation and
+	// outside of the rollout strategy.
+	// +optional
+	Sources []ResourceSource `json:"sources,omitempty"`
+
+	// Functions run over the inline and source resources, in order, before the common labels,
+	// annotations and name prefix and suffix are applied. Each one is a KRM function the operator
+	// was started with; the resources it receives still hold the $(NAMESPACE) placeholder, which
+	// is filled in for every bound namespace afterwards.
+	// +optional
+	Functions []KRMFunction `json:"functions,omitempty"`
+
+	// AllowClusterScoped permits cluster-scoped kinds (e.g. ClusterRoleBinding) in resources.
+	// Cluster-scoped objects are created once per bound namespace, so their names should
+	// include the $(NAMESPACE) placeholder to stay unique.
+	// +optional
+	AllowClusterScoped bool `json:"allowClusterScoped,omitempty"`
+
+	// AllowedTargetNamespaces lists the namespaces, other than the bound namespace, that resources
+	// may target through metadata.namespace. Entries are glob patterns and may use the
+	// $(NAMESPACE) placeholder. Resources in other namespaces are tracked by label and removed
+	// through a finalizer on the binding.
+	// +optional
+	AllowedTargetNamespaces []string `json:"allowedTargetNamespaces,omitempty"`
+
+	// CommonLabels are added to every resource. As with kustomize they are also added to the
+	// selectors and pod template labels of workloads and to Service selectors, so they must not
+	// change once workloads exist. Keys under namespaceclass.akuity.io/ are reserved for the operator.
+	// +optional
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+
+	// CommonAnnotations are added to every resource and to the pod templates of workloads
+	// +optional
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty"`
+
+	// NamePrefix is prepended to the name of every resource except Namespaces and
+	// CustomResourceDefinitions. Resources created under the old name are pruned when it changes.
+	// References between the resources of the class, such as the roleRef of a RoleBinding or the
+	// ConfigMap volumes of a pod template, follow the new names.
+	// +optional
+	NamePrefix string `json:"namePrefix,omitempty"`
+
+	// NameSuffix is appended to the name of every resource except Namespaces and
+	// CustomResourceDefinitions
+	// +optional
+	NameSuffix string `json:"nameSuffix,omitempty"`
+
+	// NamespaceMetadata sets labels and annotations on the bound Namespace object itself. Values
+	// may use the $(NAMESPACE) placeholder. They are applied with their own field manager and
+	// removed again when the class stops setting them or the namespace is unbound. Keys someone
+	// else already set to another value are left as they are. Keys under namespaceclass.akuity.io/
+	// are reserved for the operator.
+	// +optional
+	NamespaceMetadata *NamespaceMetadata `json:"namespaceMetadata,omitempty"`
+
+	// RolloutStrategy throttles how changes to the class reach namespaces that are already bound.
+	// Without it every binding applies a change as soon as it is made. Newly bound namespaces
+	// always apply the current class right away.
+	// +optional
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// RevisionHistoryLimit is how many NamespaceClassRevisions of the class are kept. Revisions
+	// that bindings are pinned to, the last-known-good revision and the current revision are
+	// always kept on top of the limit.
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// RequireApproval holds every change to the class until it is approved. Each generation gets
+	// a NamespaceClassPlan listing what would be created, updated and pruned in every bound
+	// namespace; setting the namespaceclass.akuity.io/approved-plan annotation to the plan hash
+	// lets the change roll out. Newly bound namespaces don't wait for approval.
+	// +optional
+	RequireApproval bool `json:"requireApproval,omitempty"`
+
+	// CanaryNamespaces are namespaces every class change is validated against before it rolls out.
+	// The rendered resources are applied to each of them as a server-side dry run; if the API
+	// server rejects any of them the Validated condition turns False and bound namespaces keep
+	// the previous generation. The namespaces don't have to be bound to the class.
+	// +optional
+	CanaryNamespaces []string `json:"canaryNamespaces,omitempty"`
+
+	// SafeToSwitch lets namespaces switch from this class to another one right away, even when the
+	// switch deletes resources. Otherwise such a switch waits for the namespace to be annotated
+	// with namespaceclass.akuity.io/confirm-switch set to the new class.
+	// +optional
+	SafeToSwitch bool `json:"safeToSwitch,omitempty"`
+
+	// PodSecurityPreflight decides what happens when the class tightens the Pod Security level a
+	// bound namespace enforces (through the pod-security.kubernetes.io labels of a Namespace
+	// resource) and running pods don't meet the new level. Report lists them on the binding and
+	// applies the change anyway; Block also holds the change until the pods comply.
+	// +kubebuilder:validation:Enum=Report;Block
+	// +kubebuilder:default=Report
+	// +optional
+	PodSecurityPreflight PodSecurityPreflightMode `json:"podSecurityPreflight,omitempty"`
+}
+
+// KRMFunction refers to a KRM function the operator was started with
+type KRMFunction struct {
+	// Name of the function, as allowlisted with --krm-function
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Config is passed to the function as the functionConfig of the ResourceList
+	// +optional
+	Config *runtime.RawExtension `json:"config,omitempty"`
+}
+
+// ResourceSource is one place the resources of a class are read from. Exactly one field is set.
+// +kubebuilder:validation:MinProperties=1
+// +kubebuilder:validation:MaxProperties=1
+type ResourceSource struct {
+	// ConfigMap reads resources from multi-document YAML in a ConfigMap. The operator only sees
+	// ConfigMaps labeled namespaceclass.akuity.io/source=true.
+	// +optional
+	ConfigMap *ConfigMapSource `json:"configMap,omitempty"`
+
+	// Fragment includes the resources of a NamespaceClassFragment
+	// +optional
+	Fragment *FragmentSource `json:"fragment,omitempty"`
+
+	// Git reads resources from a directory of a Git repository
+	// +optional
+	Git *GitSource `json:"git,omitempty"`
+
+	// OCI reads resources from an artifact in an OCI registry
+	// +optional
+	OCI *OCISource `json:"oci,omitempty"`
+
+	// Helm renders the resources of a Helm chart
+	// +optional
+	Helm *HelmSource `json:"helm,omitempty"`
+
+	// Kustomize builds the resources of a kustomization
+	// +optional
+	Kustomize *KustomizeSource `json:"kustomize,omitempty"`
+}
+
+// ConfigMapSource references YAML resources in a ConfigMap
+type ConfigMapSource struct {
+	// Name of the ConfigMap
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Name string `json:"name"`
+
+	// Namespace of the ConfigMap
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Namespace string `json:"namespace"`
+
+	// Key is the data key holding the resources. Without it every key is read, in key order.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// FragmentSource references a NamespaceClassFragment
+type FragmentSource struct {
+	// Name of the NamespaceClassFragment
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Name string `json:"name"`
+}
+
+// GitSource references YAML resources in a Git repository. The repository is polled and the commit
+// the ref resolves to is recorded in the class status; bindings apply the recorded commit.
+type GitSource struct {
+	// URL of the repository, e.g. https://github.com/example/classes.git or ssh://git@github.com/example/classes.git
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	URL string `json:"url"`
+
+	// Ref is the branch, tag or commit SHA to read. Defaults to the default branch of the repository.
+	// +optional
+	Ref string `json:"ref,omitempty"`
+
+	// Path is the directory holding the resources. Every .yaml, .yml and .json file below it is read,
+	// in path order. Defaults to the root of the repository.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// SecretRef names a Secret with the credentials for the repository: username and password (or a
+	// token as password) for HTTPS, or identity and known_hosts for SSH
+	// +optional
+	SecretRef *SecretReference `json:"secretRef,omitempty"`
+
+	// Interval is how often the repository is polled for new commits. A push can also trigger a
+	// fetch right away through the Git webhook endpoint.
+	// +kubebuilder:default="5m"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// OCISource references YAML resources in an OCI artifact, e.g. one pushed with oras or flux push
+// artifact. The tag is polled and the digest it resolves to is recorded in the class status;
+// bindings apply the artifact with the recorded digest, which is verified on pull.
+type OCISource struct {
+	// URL of the artifact, e.g. oci://ghcr.io/example/classes:v1 or
+	// oci://ghcr.io/example/classes@sha256:<digest>. Defaults to the latest tag.
+	// +kubebuilder:validation:Pattern=`^oci://.+`
+	// +required
+	URL string `json:"url"`
+
+	// Path is the directory inside tar layers holding the resources. Every .yaml, .yml and .json
+	// file below it is read, in path order. Layers that aren't tar archives are read as YAML.
+	// Defaults to the root of the artifact.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// SecretRef names a Secret with the registry credentials: a kubernetes.io/dockerconfigjson
+	// Secret, or username and password
+	// +optional
+	SecretRef *SecretReference `json:"secretRef,omitempty"`
+
+	// Insecure pulls the artifact over plain HTTP
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+
+	// Interval is how often the tag is checked for a new digest
+	// +kubebuilder:default="5m"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// HelmSource renders a Helm chart into resources, as helm template would. Nothing is installed as a
+// Helm release; the rendered resources are applied and pruned like inline resources. Charts from a
+// repository or an OCI registry are polled and the version or digest they resolve to is recorded in
+// the class status; bindings render the recorded chart.
+type HelmSource struct {
+	// Chart is where the chart is read from
+	// +required
+	Chart HelmChartReference `json:"chart"`
+
+	// ReleaseName is the release name the chart is rendered with. Defaults to the chart name.
+	// +optional
+	ReleaseName string `json:"releaseName,omitempty"`
+
+	// Values override the default values of the chart. The chart is rendered once for all bound
+	// namespaces, so the only namespace input is the literal placeholder $(NAMESPACE): it is the
+	// release namespace, string values may use it too, and it is replaced with the bound namespace
+	// after rendering. Templates that only pass .Release.Namespace or such values through are safe;
+	// templates that transform them (truncate, hash, parse or compare them) see the placeholder text
+	// instead of the namespace name. The labels and annotations of the bound namespace aren't
+	// available to the chart.
+	// +optional
+	Values *runtime.RawExtension `json:"values,omitempty"`
+
+	// IncludeCRDs renders the CustomResourceDefinitions in the crds directory of the chart too
+	// +optional
+	IncludeCRDs bool `json:"includeCRDs,omitempty"`
+
+	// Interval is how often repository and OCI charts are checked for a new version or digest
+	// +kubebuilder:default="5m"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// HelmChartReference is where a Helm chart is read from. Exactly one field is set.
+// +kubebuilder:validation:MinProperties=1
+// +kubebuilder:validation:MaxProperties=1
+type HelmChartReference struct {
+	// ConfigMap reads a packaged chart (.tgz) from the binaryData of a ConfigMap. The operator only
+	// sees ConfigMaps labeled namespaceclass.akuity.io/source=true.
+	// +optional
+	ConfigMap *HelmConfigMapChart `json:"configMap,omitempty"`
+
+	// Repository reads a chart from a Helm chart repository
+	// +optional
+	Repository *HelmRepositoryChart `json:"repository,omitempty"`
+
+	// OCI reads a chart from an OCI registry
+	// +optional
+	OCI *HelmOCIChart `json:"oci,omitempty"`
+}
+
+// HelmConfigMapChart references a packaged chart in a ConfigMap
+type HelmConfigMapChart struct {
+	// Name of the ConfigMap
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Name string `json:"name"`
+
+	// Namespace of the ConfigMap
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Namespace string `json:"namespace"`
+
+	// Key is the binaryData key holding the packaged chart
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Key string `json:"key"`
+}
+
+// HelmRepositoryChart references a chart in a Helm chart repository
+type HelmRepositoryChart struct {
+	// URL of the repository, the location of its index.yaml
+	// +kubebuilder:validation:Pattern=`^https?://.+`
+	// +required
+	URL string `json:"url"`
+
+	// Name of the chart in the repository index
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Name string `json:"name"`
+
+	// Version is the chart version or a semver constraint, e.g. 1.2.3 or ~1.2. Defaults to the latest
+	// stable version.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// SecretRef names a Secret with username and password for the repository. They are only sent to
+	// the host of the repository.
+	// +optional
+	SecretRef *SecretReference `json:"secretRef,omitempty"`
+}
+
+// HelmOCIChart references a chart in an OCI registry
+type HelmOCIChart struct {
+	// URL of the chart without its version, e.g. oci://ghcr.io/example/charts/monitoring
+	// +kubebuilder:validation:Pattern=`^oci://.+`
+	// +required
+	URL string `json:"url"`
+
+	// Version is the chart version, the tag of the chart in the registry
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Version string `json:"version"`
+
+	// SecretRef names a Secret with the registry credentials: a kubernetes.io/dockerconfigjson
+	// Secret, or username and password
+	// +optional
+	SecretRef *SecretReference `json:"secretRef,omitempty"`
+
+	// Insecure pulls the chart over plain HTTP
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// KustomizeSource builds a kustomization, as kustomize build would, from files held in ConfigMaps, a
+// Git repository or an OCI artifact. The build sees a local-config ConfigMap named
+// namespaceclass-namespace whose data.name is $(NAMESPACE), which replacements can copy into fields
+// of bases that can't use the placeholder themselves; the placeholder is filled in with the bound
+// namespace after the build.
+// +kubebuilder:validation:XValidation:rule="(has(self.configMaps) ? 1 : 0) + (has(self.git) ? 1 : 0) + (has(self.oci) ? 1 : 0) == 1",message="exactly one of configMaps, git and oci must be set"
+type KustomizeSource struct {
+	// ConfigMaps hold the files of the kustomization, one file per data or binaryData key. The
+	// operator only sees ConfigMaps labeled namespaceclass.akuity.io/source=true.
+	// +kubebuilder:validation:MinItems=1
+	// +optional
+	ConfigMaps []KustomizeConfigMap `json:"configMaps,omitempty"`
+
+	// Git reads the files below its path in a Git repository
+	// +optional
+	Git *GitSource `json:"git,omitempty"`
+
+	// OCI reads the files below its path in the tar layers of an OCI artifact
+	// +optional
+	OCI *OCISource `json:"oci,omitempty"`
+
+	// Path is the directory of the kustomization among the files. Defaults to the root.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Replacements are kustomize replacements applied to the output of the kustomization. Their
+	// source is usually the namespaceclass-namespace ConfigMap.
+	// +optional
+	Replacements []runtime.RawExtension `json:"replacements,omitempty"`
+}
+
+// KustomizeConfigMap places the keys of a ConfigMap as files of a kustomization
+type KustomizeConfigMap struct {
+	// Name of the ConfigMap
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Name string `json:"name"`
+
+	// Namespace of the ConfigMap
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Namespace string `json:"namespace"`
+
+	// Path is the directory the keys are placed in. Defaults to the root.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// SecretReference names a Secret in a namespace
+type SecretReference struct {
+	// Name of the Secret
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Name string `json:"name"`
+
+	// Namespace of the Secret, which must be the operator's namespace; Secrets elsewhere aren't read
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Namespace string `json:"namespace"`
+}
+
+// PodSecurityPreflightMode is what a binding does with pods that don't meet a stricter Pod Security level
+type PodSecurityPreflightMode string
+
+const (
+	// PodSecurityPreflightReport reports non-compliant pods and applies the class anyway
+	PodSecurityPreflightReport PodSecurityPreflightMode = "Report"
+
+	// PodSecurityPreflightBlock reports non-compliant pods and holds the class until they comply
+	PodSecurityPreflightBlock PodSecurityPreflightMode = "Block"
+)
+
+// NamespaceMetadata is the metadata a class sets on bound Namespace objects
+type NamespaceMetadata struct {
+	// Labels to set on the namespace, e.g. pod-security.kubernetes.io/enforce
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations to set on the namespace
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// RolloutStrategy controls the progressive rollout of class changes across bound namespaces
+type RolloutStrategy struct {
+	// MaxConcurrent is how many bindings may be updating to a new class generation at once.
+	// Value can be an absolute number or a percentage of all bindings of the class.
+	// +kubebuilder:default=1
+	// +optional
+	MaxConcurrent *intstr.IntOrString `json:"maxConcurrent,omitempty"`
+
+	// MaxUnavailable is how many bindings may be Degraded on the new generation before the
+	// rollout halts. Value can be an absolute number or a percentage of all bindings of the class.
+	// +kubebuilder:default=0
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// Batches split the rollout into ordered groups of namespaces selected by their labels, so
+	// canary namespaces can be updated first. A namespace belongs to the first batch it matches;
+	// namespaces matching no batch are updated last. A batch only starts once every earlier
+	// batch is fully updated.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Batches []RolloutBatch `json:"batches,omitempty"`
+
+	// AutoRollback reverts namespaces to the last-known-good revision of the class when too many
+	// bindings go Degraded on a new generation
+	// +optional
+	AutoRollback *AutoRollback `json:"autoRollback,omitempty"`
+}
+
+// AutoRollback configures when a rollout is aborted and rolled back
+type AutoRollback struct {
+	// FailureThreshold is how many bindings must be Degraded on the new generation to abort the
+	// rollout. Value can be an absolute number or a percentage of all bindings of the class.
+	// +kubebuilder:default=1
+	// +optional
+	FailureThreshold *intstr.IntOrString `json:"failureThreshold,omitempty"`
+}
+
+// RolloutBatch is one ordered group of namespaces in a rollout
+type RolloutBatch struct {
+	// Name identifies the batch in rollout status and in the resume annotation
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Name string `json:"name"`
+
+	// NamespaceSelector selects the namespaces in this batch by their labels
+	// +required
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+
+	// PauseAfter pauses the rollout once this batch is fully updated. Resume it by setting the
+	// namespaceclass.akuity.io/resume annotation on the class to the name of this batch.
+	// +optional
+	PauseAfter bool `json:"pauseAfter,omitempty"`
+}
+
+// RolloutStatus reports the progress of rolling out the current class generation
+type RolloutStatus struct {
+	// Generation is the class generation being rolled out
+	Generation int64 `json:"generation"`
+
+	// SourcesHash is the hash of the source content rolled out along with the generation, for
+	// classes with sources. A change of source content starts a new rollout just like a new
+	// generation.
+	// +optional
+	SourcesHash string `json:"sourcesHash,omitempty"`
+
+	// Total is the number of bindings of the class
+	Total int32 `json:"total"`
+
+	// Updated is the number of bindings that applied this generation
+	Updated int32 `json:"updated"`
+
+	// Unavailable is the number of bindings that are Degraded on this generation
+	// +optional
+	Unavailable int32 `json:"unavailable,omitempty"`
+
+	// CurrentBatch is the name of the batch being updated
+	// +optional
+	CurrentBatch string `json:"currentBatch,omitempty"`
+
+	// Paused is true while the rollout waits to be resumed after a batch
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// Aborted is true once the rollout was aborted and its namespaces rolled back
+	// +optional
+	Aborted bool `json:"aborted,omitempty"`
+
+	// RollbackRevision is the NamespaceClassRevision that aborted namespaces were rolled back to
+	// +optional
+	RollbackRevision string `json:"rollbackRevision,omitempty"`
 }
 
 // NamespaceClassStatus defines the observed state of NamespaceClass.
@@ -57,8 +575,89 @@ type NamespaceClassStatus struct {
 	// +listMapKey=type
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Rollout reports the progress of rolling out the current generation to bound namespaces
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+
+	// LastKnownGoodRevision is the NamespaceClassRevision of the latest generation that every
+	// binding applied without going Degraded
+	// +optional
+	LastKnownGoodRevision string `json:"lastKnownGoodRevision,omitempty"`
+
+	// Plan reports the NamespaceClassPlan of the current generation when the class requires approval
+	// +optional
+	Plan *PlanStatus `json:"plan,omitempty"`
+
+	// Sources reports the revision every fetched source of the class resolved to
+	// +listType=map
+	// +listMapKey=index
+	// +optional
+	Sources []SourceStatus `json:"sources,omitempty"`
+
+	// ObservedRefresh is the last value of the namespaceclass.akuity.io/refresh annotation that made
+	// the sources be fetched
+	// +optional
+	ObservedRefresh string `json:"observedRefresh,omitempty"`
 }
 
+// SourceStatus is the revision a fetched class source resolved to
+type SourceStatus struct {
+	// Index of the source in spec.sources
+	Index int32 `json:"index"`
+
+	// URL of the source when it was fetched
+	URL string `json:"url"`
+
+	// Ref of the source when it was fetched
+	// +optional
+	Ref string `json:"ref,omitempty"`
+
+	// Revision is the resolved revision: the commit SHA of a Git source, the digest of an OCI source or
+	// OCI chart, or the version of a repository chart
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// LastFetchTime is when the source was last fetched. Revision stays at the last successful
+	// fetch when a later one fails.
+	// +optional
+	LastFetchTime *metav1.Time `json:"lastFetchTime,omitempty"`
+
+	// Message describes why the last fetch failed
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PlanStatus is the approval state of the plan of the current class generation
+type PlanStatus struct {
+	// Name of the NamespaceClassPlan
+	Name string `json:"name"`
+
+	// Hash of the plan; the approval annotation must match it
+	Hash string `json:"hash"`
+
+	// Approved is true once the plan was approved
+	// +optional
+	Approved bool `json:"approved,omitempty"`
+}
+
+// Condition types reported on NamespaceClass
+const (
+	// ClassConditionRolloutProgressing is True while a class change is still being rolled out
+	ClassConditionRolloutProgressing = "RolloutProgressing"
+
+	// ClassConditionRolloutAborted is True when the rollout of the current generation was aborted
+	// and its namespaces rolled back
+	ClassConditionRolloutAborted = "RolloutAborted"
+
+	// ClassConditionValidated reports whether the current generation passed a server-side dry run
+	// in the canary namespaces
+	ClassConditionValidated = "Validated"
+
+	// ClassConditionSourcesReady reports whether every fetched source of the class could be fetched
+	ClassConditionSourcesReady = "SourcesReady"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:scope=Cluster,shortName=nc
 // +kubebuilder:subresource:status
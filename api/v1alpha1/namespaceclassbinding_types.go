@@ -33,6 +33,11 @@ type NamespaceClassBindingSpec struct {
 	// foo is an example field of NamespaceClassBinding. Edit namespaceclassbinding_types.go to remove/update
 	// +optional
 	ClassName string `json:"className"`
+
+	// Revision pins the binding to a NamespaceClassRevision of its class by name. Empty or
+	// "latest" follows the current generation of the class.
+	// +optional
+	Revision string `json:"revision,omitempty"`
 }
 
 // NamespaceClassBindingStatus defines the observed state of NamespaceClassBinding.
@@ -45,10 +50,36 @@ type NamespaceClassBindingStatus struct {
 	// +optional
 	ObservedClassGeneration int64 `json:"observedClassGeneration,omitempty"`
 
+	// ObservedRevision is the NamespaceClassRevision that was last processed
+	// +optional
+	ObservedRevision string `json:"observedRevision,omitempty"`
+
+	// ObservedSourcesHash is the content hash of the class sources that were last processed. Sources
+	// change without the class generation changing, so this tells when to apply them again.
+	// +optional
+	ObservedSourcesHash string `json:"observedSourcesHash,omitempty"`
+
 	// AppliedResources tracks which resources have been created
 	// +optional
 	AppliedResources []AppliedResource `json:"appliedResources,omitempty"`
 
+	// RenderedHash is the content hash of the whole rendered resource set that was last applied
+	// +optional
+	RenderedHash string `json:"renderedHash,omitempty"`
+
+	// FailedAttempts counts consecutive transient failures applying the class
+	// +optional
+	FailedAttempts int32 `json:"failedAttempts,omitempty"`
+
+	// NextRetryTime is when the binding is retried after a transient failure
+	// +optional
+	NextRetryTime *metav1.Time `json:"nextRetryTime,omitempty"`
+
+	// PodSecurityViolations lists the running pods that didn't meet the stricter Pod Security level
+	// of the latest class change that tightened it. It is cleared by a class change that doesn't.
+	// +optional
+	PodSecurityViolations []PodSecurityViolation `json:"podSecurityViolations,omitempty"`
+
 	// conditions represent the current state of the NamespaceClassBinding resource.
 	// Each condition has a unique type and reflects the status of a specific aspect of the resource.
 	//
@@ -72,8 +103,69 @@ type AppliedResource struct {
 	Kind string `json:"kind"`
 	// Name of the resource
 	Name string `json:"name"`
+	// Namespace of the resource when it lives outside the binding's namespace
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// ClusterScoped is true when the resource is not namespaced and is tracked by labels
+	// instead of an owner reference
+	// +optional
+	ClusterScoped bool `json:"clusterScoped,omitempty"`
+	// Phase is Applied once the resource exists, or Pending while it can't be applied yet
+	// +optional
+	Phase ResourcePhase `json:"phase,omitempty"`
+	// Reason explains why a resource is Pending or Recreating
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// RecreateStartedAt is when a Recreating resource was deleted
+	// +optional
+	RecreateStartedAt *metav1.Time `json:"recreateStartedAt,omitempty"`
+	// Hash is the content hash of the rendered object that was last applied
+	// +optional
+	Hash string `json:"hash,omitempty"`
+	// ResourceVersion is the resourceVersion of the object right after it was last applied
+	// +optional
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+// PodSecurityViolation is a pod that doesn't meet the Pod Security level a class change enforces
+type PodSecurityViolation struct {
+	// Pod is the name of the pod
+	Pod string `json:"pod"`
+	// Level is the Pod Security level and version the pod was checked against, e.g. restricted:latest
+	Level string `json:"level"`
+	// Reasons lists the checks the pod fails
+	Reasons []string `json:"reasons"`
 }
 
+// ResourcePhase describes whether a class resource has been applied to the namespace
+// +kubebuilder:validation:Enum=Applied;Pending;Recreating
+type ResourcePhase string
+
+const (
+	// ResourcePhaseApplied means the resource was applied to the cluster
+	ResourcePhaseApplied ResourcePhase = "Applied"
+
+	// ResourcePhasePending means the resource is waiting on something, such as its CRD, to be applied
+	ResourcePhasePending ResourcePhase = "Pending"
+
+	// ResourcePhaseRecreating means the resource was deleted to change an immutable field and will
+	// be applied again once the old object is gone
+	ResourcePhaseRecreating ResourcePhase = "Recreating"
+)
+
+// Condition types reported on NamespaceClassBinding
+const (
+	// BindingConditionProgressing is True while resources are still converging, e.g. being recreated
+	BindingConditionProgressing = "Progressing"
+
+	// BindingConditionDegraded is True when the binding can't reach the desired state on its own
+	BindingConditionDegraded = "Degraded"
+
+	// BindingConditionPodSecurityViolations is True when running pods don't meet the Pod Security
+	// level the class enforces
+	BindingConditionPodSecurityViolations = "PodSecurityViolations"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 
@@ -0,0 +1,61 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// NamespaceClassFragmentSpec holds resources that NamespaceClasses include through their sources
+type NamespaceClassFragmentSpec struct {
+	// Resources are included in every class that lists the fragment as a source. They are
+	// rendered like inline class resources and may use the $(NAMESPACE) placeholder.
+	// +optional
+	Resources []runtime.RawExtension `json:"resources,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=ncf
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// NamespaceClassFragment is a set of resources shared between NamespaceClasses. It keeps large
+// classes under the object size limit and lets classes reuse common resources.
+type NamespaceClassFragment struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec holds the resources of the fragment
+	// +required
+	Spec NamespaceClassFragmentSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// NamespaceClassFragmentList contains a list of NamespaceClassFragment
+type NamespaceClassFragmentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespaceClassFragment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NamespaceClassFragment{}, &NamespaceClassFragmentList{})
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NamespaceClassPlanSpec is what rolling out one NamespaceClass generation would change
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type NamespaceClassPlanSpec struct {
+	// ClassName is the name of the NamespaceClass the plan is for
+	// +required
+	ClassName string `json:"className"`
+
+	// Generation is the NamespaceClass generation the plan rolls out
+	// +required
+	Generation int64 `json:"generation"`
+
+	// Hash identifies the content of the plan. Approve the plan by setting the
+	// namespaceclass.akuity.io/approved-plan annotation on the class to it.
+	// +required
+	Hash string `json:"hash"`
+
+	// Namespaces lists the changes planned in every bound namespace
+	// +optional
+	Namespaces []NamespacePlan `json:"namespaces,omitempty"`
+
+	// SourcesHash is the hash of the source content the plan rolls out along with the generation,
+	// for classes with sources
+	// +optional
+	SourcesHash string `json:"sourcesHash,omitempty"`
+}
+
+// NamespacePlan is what rolling out a class generation would change in one bound namespace
+type NamespacePlan struct {
+	// Namespace is the bound namespace
+	Namespace string `json:"namespace"`
+
+	// Changes lists the resources that would be created (Added), updated (Changed) or pruned
+	// (Removed). Updated resources list their changed fields when the content the namespace
+	// applied last is still known from its revision.
+	// +optional
+	Changes []ResourceChange `json:"changes,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=ncp
+// +kubebuilder:printcolumn:name="Class",type=string,JSONPath=`.spec.className`
+// +kubebuilder:printcolumn:name="Generation",type=integer,JSONPath=`.spec.generation`
+// +kubebuilder:printcolumn:name="Hash",type=string,JSONPath=`.spec.hash`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// NamespaceClassPlan lists what rolling out a NamespaceClass generation would change, for classes
+// that require approval
+type NamespaceClassPlan struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec is the planned change
+	// +required
+	Spec NamespaceClassPlanSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// NamespaceClassPlanList contains a list of NamespaceClassPlan
+type NamespaceClassPlanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespaceClassPlan `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NamespaceClassPlan{}, &NamespaceClassPlanList{})
+}
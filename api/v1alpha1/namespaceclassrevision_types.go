@@ -0,0 +1,143 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// NamespaceClassRevisionSpec is the content of one NamespaceClass generation
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type NamespaceClassRevisionSpec struct {
+	// ClassName is the name of the NamespaceClass this revision was taken from
+	// +required
+	ClassName string `json:"className"`
+
+	// Generation is the NamespaceClass generation this revision was taken from
+	// +required
+	Generation int64 `json:"generation"`
+
+	// Class is the NamespaceClass spec at that generation
+	// +required
+	Class NamespaceClassSpec `json:"class"`
+
+	// Sources are the revisions the fetched sources of the class resolved to when this revision
+	// was taken. Revisions without SourcesHash are applied by reading their sources at these revisions.
+	// +listType=map
+	// +listMapKey=index
+	// +optional
+	Sources []SourceStatus `json:"sources,omitempty"`
+
+	// SourceResources are the resources the sources of the class held when this revision was taken.
+	// Bindings applying the revision use them instead of reading the sources again.
+	// +optional
+	SourceResources []runtime.RawExtension `json:"sourceResources,omitempty"`
+
+	// SourcesHash is the content hash of SourceResources, set when the class has sources
+	// +optional
+	SourcesHash string `json:"sourcesHash,omitempty"`
+}
+
+// NamespaceClassRevisionStatus defines the observed state of NamespaceClassRevision
+type NamespaceClassRevisionStatus struct {
+	// Comparison lists how this revision differs from the revision named in the
+	// namespaceclass.akuity.io/compare-to annotation
+	// +optional
+	Comparison *RevisionComparison `json:"comparison,omitempty"`
+}
+
+// RevisionComparison is the difference between two revisions of a class
+type RevisionComparison struct {
+	// Revision is the revision this one was compared to
+	Revision string `json:"revision"`
+
+	// Changes lists the resources that were added, removed or changed going from Revision to
+	// this revision. It is empty when both revisions have the same resources.
+	// +optional
+	Changes []ResourceChange `json:"changes,omitempty"`
+}
+
+// ResourceChange describes how one class resource differs between two revisions
+type ResourceChange struct {
+	// APIVersion of the resource
+	APIVersion string `json:"apiVersion"`
+	// Kind of the resource
+	Kind string `json:"kind"`
+	// Name of the resource
+	Name string `json:"name"`
+	// Namespace of the resource when the class sets one
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Type is Added, Removed or Changed
+	Type ResourceChangeType `json:"type"`
+	// Fields lists the paths of the changed fields of a Changed resource
+	// +optional
+	Fields []string `json:"fields,omitempty"`
+}
+
+// ResourceChangeType is how a resource differs between two revisions
+// +kubebuilder:validation:Enum=Added;Removed;Changed
+type ResourceChangeType string
+
+const (
+	// ResourceChangeAdded means the resource only exists in the newer revision
+	ResourceChangeAdded ResourceChangeType = "Added"
+
+	// ResourceChangeRemoved means the resource only exists in the older revision
+	ResourceChangeRemoved ResourceChangeType = "Removed"
+
+	// ResourceChangeChanged means the resource exists in both revisions with different content
+	ResourceChangeChanged ResourceChangeType = "Changed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName=ncr
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Class",type=string,JSONPath=`.spec.className`
+// +kubebuilder:printcolumn:name="Generation",type=integer,JSONPath=`.spec.generation`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// NamespaceClassRevision is an immutable snapshot of a NamespaceClass generation
+type NamespaceClassRevision struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec is the snapshotted class content
+	// +required
+	Spec NamespaceClassRevisionSpec `json:"spec"`
+
+	// status defines the observed state of NamespaceClassRevision
+	// +optional
+	Status NamespaceClassRevisionStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// NamespaceClassRevisionList contains a list of NamespaceClassRevision
+type NamespaceClassRevisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespaceClassRevision `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NamespaceClassRevision{}, &NamespaceClassRevisionList{})
+}
@@ -18,13 +18,18 @@ package main
 
 import (
 	"crypto/tls"
+	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -37,6 +42,8 @@ import (
 
 	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
 	"github.com/jacobboykin/namespaceclass-operator/internal/controller"
+	webhookcorev1 "github.com/jacobboykin/namespaceclass-operator/internal/webhook/v1"
+	webhookv1alpha1 "github.com/jacobboykin/namespaceclass-operator/internal/webhook/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -47,6 +54,7 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 
 	utilruntime.Must(akuityv1alpha1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
@@ -61,6 +69,13 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var recreateTimeout time.Duration
+	var maxParallelApplies int
+	var gitWebhookAddr string
+	var functionTimeout time.Duration
+	var forbiddenKinds string
+	var secretNamespace string
+	functionPaths := map[string]string{}
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -79,6 +94,31 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.DurationVar(&recreateTimeout, "recreate-timeout", 30*time.Second,
+		"How long a resource deleted to change an immutable field may take to go away before "+
+			"its binding reports Degraded.")
+	flag.IntVar(&maxParallelApplies, "max-parallel-applies", 8,
+		"How many resources of one binding are applied concurrently. Kinds other resources depend on "+
+			"are still applied first; 1 applies serially.")
+	flag.StringVar(&gitWebhookAddr, "git-webhook-bind-address", "0",
+		"The address the Git push webhook endpoint ("+controller.GitWebhookPath+") binds to, or 0 to disable it. "+
+			"Requires GIT_WEBHOOK_SECRET, the shared secret pushes must be signed with.")
+	flag.Func("krm-function", "Allowlists a KRM function executable as name=/absolute/path; classes refer to "+
+		"it by name in spec.functions. May be repeated.", func(value string) error {
+		name, path, ok := strings.Cut(value, "=")
+		if !ok || name == "" || path == "" {
+			return fmt.Errorf("expected name=/absolute/path, got %q", value)
+		}
+		functionPaths[name] = path
+		return nil
+	})
+	flag.DurationVar(&functionTimeout, "krm-function-timeout", 30*time.Second,
+		"How long a single KRM function run may take.")
+	flag.StringVar(&forbiddenKinds, "forbidden-kinds", "",
+		"Comma-separated kinds the NamespaceClass webhook rejects in class resources, as Kind for every group "+
+			"or Kind.group for one group.")
+	flag.StringVar(&secretNamespace, "secret-namespace", os.Getenv("POD_NAMESPACE"),
+		"The only namespace credentials Secrets of class sources are read from, by default the operator's own.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -178,27 +218,130 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Only ConfigMaps labeled as class sources and Secrets of the secret namespace are cached for sources,
+	// not every ConfigMap and Secret in the cluster
+	sourceCache, err := controller.NewSourceCache(mgr.GetConfig(), mgr.GetScheme(), mgr.GetRESTMapper(),
+		secretNamespace)
+	if err != nil {
+		setupLog.Error(err, "unable to create source cache")
+		os.Exit(1)
+	}
+	if err := mgr.Add(sourceCache); err != nil {
+		setupLog.Error(err, "unable to set up source cache")
+		os.Exit(1)
+	}
+
+	// Git repositories, OCI artifacts and Helm charts of class sources are fetched once and shared by
+	// the controllers, as are the builds of Kustomize sources
+	gitRepositories := controller.NewGitRepositories()
+	ociArtifacts := controller.NewOCIArtifacts()
+	helmCharts := controller.NewHelmCharts()
+	kustomizeBuilds := controller.NewKustomizeBuilds()
+
+	// Classes can only run the KRM functions allowlisted on the command line
+	renderer := &controller.KRMRenderer{Functions: map[string]*controller.ExecFunction{}}
+	for name, path := range functionPaths {
+		function, err := controller.NewExecFunction(path, functionTimeout)
+		if err != nil {
+			setupLog.Error(err, "invalid KRM function", "function", name)
+			os.Exit(1)
+		}
+		renderer.Functions[name] = function
+	}
+
 	// Setup NamespaceClassBinding controller (manages resources)
 	if err := (&controller.NamespaceClassBindingReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("namespaceclassbinding-controller"),
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		Recorder:           mgr.GetEventRecorderFor("namespaceclassbinding-controller"),
+		RecreateTimeout:    recreateTimeout,
+		MaxParallelApplies: maxParallelApplies,
+		Git:                gitRepositories,
+		OCI:                ociArtifacts,
+		Helm:               helmCharts,
+		Kustomize:          kustomizeBuilds,
+		Renderer:           renderer,
+		SecretNamespace:    secretNamespace,
+		SourceCache:        sourceCache,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "NamespaceClassBinding")
 		os.Exit(1)
 	}
 
+	// Setup NamespaceClass controller (rolls out class changes)
+	if err := (&controller.NamespaceClassReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Recorder:        mgr.GetEventRecorderFor("namespaceclass-controller"),
+		Git:             gitRepositories,
+		OCI:             ociArtifacts,
+		Helm:            helmCharts,
+		Kustomize:       kustomizeBuilds,
+		Renderer:        renderer,
+		SecretNamespace: secretNamespace,
+		SourceCache:     sourceCache,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "NamespaceClass")
+		os.Exit(1)
+	}
+
 	// Setup Namespace controller (manages bindings based on labels)
 	if err := (&controller.NamespaceReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("namespace-controller"),
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Recorder:        mgr.GetEventRecorderFor("namespace-controller"),
+		Git:             gitRepositories,
+		OCI:             ociArtifacts,
+		Helm:            helmCharts,
+		Kustomize:       kustomizeBuilds,
+		Renderer:        renderer,
+		SecretNamespace: secretNamespace,
+		SourceCache:     sourceCache,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Namespace")
 		os.Exit(1)
 	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		var forbidden []string
+		for _, kind := range strings.Split(forbiddenKinds, ",") {
+			if kind = strings.TrimSpace(kind); kind != "" {
+				forbidden = append(forbidden, kind)
+			}
+		}
+		if err := webhookv1alpha1.SetupNamespaceClassWebhookWithManager(mgr, forbidden); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "NamespaceClass")
+			os.Exit(1)
+		}
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookcorev1.SetupNamespaceWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Namespace")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	if gitWebhookAddr != "0" {
+		// Unsigned pushes would let anyone who reaches the endpoint make every class refetch its sources
+		gitWebhookSecret := os.Getenv("GIT_WEBHOOK_SECRET")
+		if gitWebhookSecret == "" {
+			setupLog.Error(errors.New("GIT_WEBHOOK_SECRET is not set"), "unable to set up Git webhook server")
+			os.Exit(1)
+		}
+		if err := mgr.Add(&controller.GitWebhookServer{
+			Addr: gitWebhookAddr,
+			Handler: &controller.GitWebhookHandler{
+				Client: mgr.GetClient(),
+				Secret: gitWebhookSecret,
+			},
+		}); err != nil {
+			setupLog.Error(err, "unable to set up Git webhook server")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -0,0 +1,170 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// parsedResource is a class resource parsed once per class generation. Object is shared between
+// bindings and must be deep copied before it is rendered for a namespace.
+type parsedResource struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+	Object     *unstructured.Unstructured
+}
+
+// parsedClass is the parsed and validated content of one class generation, with the content of its
+// sources when it has any
+type parsedClass struct {
+	generation  int64
+	sourcesHash string
+	resources   []parsedResource
+	err         error
+}
+
+// classCache shares parsed class content between the bindings of a class
+type classCache struct {
+	renderer Renderer
+
+	mu      sync.RWMutex
+	entries map[types.UID]*parsedClass
+	names   map[string]types.UID
+}
+
+// newClassCache returns an empty class cache that renders classes with renderer
+func newClassCache(renderer Renderer) *classCache {
+	return &classCache{
+		renderer: renderer,
+		entries:  map[types.UID]*parsedClass{},
+		names:    map[string]types.UID{},
+	}
+}
+
+// get returns the parsed content of the class, parsing it only if this generation isn't cached yet
+func (c *classCache) get(ctx context.Context, class *akuityv1alpha1.NamespaceClass) ([]parsedResource, error) {
+	c.mu.RLock()
+	parsed, ok := c.entries[class.UID]
+	c.mu.RUnlock()
+	if ok && parsed.generation == class.Generation && parsed.sourcesHash == sourcesHash(class) {
+		return parsed.resources, parsed.err
+	}
+
+	parsed = parseClass(ctx, c.renderer, class)
+	if parsed.err != nil && !isPermanentFailure(parsed.err) {
+		// A renderer that timed out or failed to run is retried
+		return nil, parsed.err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cur, ok := c.entries[class.UID]; ok && cur.generation > parsed.generation {
+		// A newer generation was cached concurrently; don't roll it back
+		return parsed.resources, parsed.err
+	}
+	if uid, ok := c.names[class.Name]; ok && uid != class.UID {
+		// The class was recreated under the same name
+		delete(c.entries, uid)
+	}
+	c.entries[class.UID] = parsed
+	c.names[class.Name] = class.UID
+	return parsed.resources, parsed.err
+}
+
+// forget drops the cached content of the named class
+func (c *classCache) forget(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if uid, ok := c.names[name]; ok {
+		delete(c.entries, uid)
+		delete(c.names, name)
+	}
+}
+
+// parseClass renders the class and validates every resource, then applies the class-wide labels,
+// annotations and name prefix and suffix to it. A nil renderer renders the resources as written.
+func parseClass(ctx context.Context, renderer Renderer, class *akuityv1alpha1.NamespaceClass) *parsedClass {
+	parsed := &parsedClass{
+		generation:  class.Generation,
+		sourcesHash: sourcesHash(class),
+	}
+	if err := validateCommonTransforms(&class.Spec); err != nil {
+		parsed.err = permanent(fmt.Errorf("invalid NamespaceClass %q: %w", class.Name, err))
+		return parsed
+	}
+
+	objects, err := classRenderer(renderer).Render(ctx, class)
+	if err != nil {
+		parsed.err = fmt.Errorf("render NamespaceClass %q: %w", class.Name, err)
+		return parsed
+	}
+
+	parsed.resources = make([]parsedResource, 0, len(objects))
+	renamed := map[string]map[string]string{}
+	for _, u := range objects {
+		res, err := parseObject(u)
+		if err != nil {
+			parsed.resources = nil
+			parsed.err = permanent(fmt.Errorf("render NamespaceClass %q: %w", class.Name, err))
+			return parsed
+		}
+		name := res.Name
+		if err := applyCommonTransforms(&class.Spec, &res); err != nil {
+			parsed.resources = nil
+			parsed.err = permanent(fmt.Errorf("invalid resource %s %s in NamespaceClass %q: %w",
+				res.Kind, res.Name, class.Name, err))
+			return parsed
+		}
+		if res.Name != name {
+			if renamed[res.Kind] == nil {
+				renamed[res.Kind] = map[string]string{}
+			}
+			renamed[res.Kind][name] = res.Name
+		}
+		parsed.resources = append(parsed.resources, res)
+	}
+	if len(renamed) > 0 {
+		for i := range parsed.resources {
+			renameReferences(&parsed.resources[i], renamed)
+		}
+	}
+
+	return parsed
+}
+
+// parseObject identifies a rendered class resource
+func parseObject(u *unstructured.Unstructured) (parsedResource, error) {
+	if u.GetAPIVersion() == "" || u.GetKind() == "" || u.GetName() == "" {
+		return parsedResource{}, errMissingObjectMeta
+	}
+	return parsedResource{
+		APIVersion: u.GetAPIVersion(),
+		Kind:       u.GetKind(),
+		Namespace:  u.GetNamespace(),
+		Name:       u.GetName(),
+		Object:     u,
+	}, nil
+}
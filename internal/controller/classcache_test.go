@@ -0,0 +1,132 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// newCacheTestClass returns a class with n ConfigMap and Deployment resources
+func newCacheTestClass(uid string, generation int64, n int) *akuityv1alpha1.NamespaceClass {
+	class := &akuityv1alpha1.NamespaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-class", UID: types.UID(uid), Generation: generation},
+	}
+	for i := 0; i < n; i++ {
+		class.Spec.Resources = append(class.Spec.Resources,
+			runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"apiVersion": "v1", "kind": "ConfigMap",
+				"metadata": {"name": "config-%d", "labels": {"team": "$(NAMESPACE)"}},
+				"data": {"namespace": "$(NAMESPACE)", "index": "%d"}}`, i, i))},
+			runtime.RawExtension{Raw: []byte(fmt.Sprintf(`{"apiVersion": "apps/v1", "kind": "Deployment",
+				"metadata": {"name": "app-%d"},
+				"spec": {"replicas": 1, "selector": {"matchLabels": {"app": "app-%d"}},
+					"template": {"metadata": {"labels": {"app": "app-%d"}},
+						"spec": {"containers": [{"name": "app", "image": "nginx:1.27",
+							"env": [{"name": "NAMESPACE", "value": "$(NAMESPACE)"}]}]}}}}`, i, i, i))},
+		)
+	}
+	return class
+}
+
+func TestClassCache(t *testing.T) {
+	t.Run("parses once per generation", func(t *testing.T) {
+		cache := newClassCache(nil)
+		class := newCacheTestClass("a", 1, 2)
+
+		first, err := cache.get(context.Background(), class)
+		require.NoError(t, err)
+		require.Len(t, first, 4)
+		assert.Equal(t, "config-0", first[0].Name)
+		assert.Equal(t, "apps/v1", first[1].APIVersion)
+
+		second, err := cache.get(context.Background(), class)
+		require.NoError(t, err)
+		assert.Same(t, first[0].Object, second[0].Object, "expected the cached parse to be reused")
+
+		class.Generation = 2
+		class.Spec.Resources = class.Spec.Resources[:1]
+		third, err := cache.get(context.Background(), class)
+		require.NoError(t, err)
+		assert.Len(t, third, 1, "expected a new generation to be parsed again")
+	})
+
+	t.Run("caches invalid content as a permanent failure", func(t *testing.T) {
+		cache := newClassCache(nil)
+		class := newCacheTestClass("a", 1, 0)
+		class.Spec.Resources = []runtime.RawExtension{{Raw: []byte(`{"kind": "ConfigMap"}`)}}
+
+		_, err := cache.get(context.Background(), class)
+		require.Error(t, err)
+		assert.True(t, isPermanentFailure(err))
+		assert.Contains(t, err.Error(), "apiVersion, kind and metadata.name are required")
+	})
+
+	t.Run("drops classes that were deleted or recreated", func(t *testing.T) {
+		cache := newClassCache(nil)
+		_, err := cache.get(context.Background(), newCacheTestClass("a", 1, 1))
+		require.NoError(t, err)
+
+		// Recreating the class under the same name replaces the old UID
+		_, err = cache.get(context.Background(), newCacheTestClass("b", 1, 1))
+		require.NoError(t, err)
+		assert.Len(t, cache.entries, 1)
+
+		cache.forget("test-class")
+		assert.Empty(t, cache.entries)
+		assert.Empty(t, cache.names)
+	})
+}
+
+// renderForBinding renders every parsed resource the way applyResource does for one namespace
+func renderForBinding(resources []parsedResource, namespace string) {
+	for _, res := range resources {
+		u := res.Object.DeepCopy()
+		renderPlaceholders(u.Object, namespace)
+	}
+}
+
+// BenchmarkBindingRender compares parsing the class for every binding with sharing one parse per
+// class generation. Run with -benchmem to see the allocation counts.
+func BenchmarkBindingRender(b *testing.B) {
+	class := newCacheTestClass("a", 1, 20)
+
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			parsed := parseClass(context.Background(), nil, class)
+			renderForBinding(parsed.resources, "team-a")
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		cache := newClassCache(nil)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resources, _ := cache.get(context.Background(), class)
+			renderForBinding(resources, "team-a")
+		}
+	})
+}
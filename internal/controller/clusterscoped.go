@@ -0,0 +1,268 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// labelBindingNamespace records the namespace of the binding managing a resource
+	labelBindingNamespace = "namespaceclass.akuity.io/binding-namespace"
+
+	// labelBindingName records the name of the binding managing a resource
+	labelBindingName = "namespaceclass.akuity.io/binding-name"
+
+	// finalizerCleanup is set on bindings that manage resources not covered by ownerReferences, or
+	// metadata on their namespace
+	finalizerCleanup = "namespaceclass.akuity.io/cleanup"
+)
+
+// isClusterScoped reports whether the kind of u is cluster-scoped according to the RESTMapper
+func (r *NamespaceClassBindingReconciler) isClusterScoped(u *unstructured.Unstructured) (bool, error) {
+	gvk := u.GroupVersionKind()
+	mapping, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return false, err
+	}
+	return mapping.Scope.Name() == meta.RESTScopeNameRoot, nil
+}
+
+// prepareClusterScoped validates and labels a cluster-scoped resource before it is applied
+func (r *NamespaceClassBindingReconciler) prepareClusterScoped(ctx context.Context,
+	binding *akuityv1alpha1.NamespaceClassBinding, class *akuityv1alpha1.NamespaceClass,
+	u *unstructured.Unstructured) error {
+	if !class.Spec.AllowClusterScoped {
+		return permanent(fmt.Errorf("cluster-scoped %s %s is not allowed by NamespaceClass %q; "+
+			"set spec.allowClusterScoped to opt in", u.GetKind(), u.GetName(), class.Name))
+	}
+
+	if u.GetNamespace() != "" {
+		return permanent(fmt.Errorf("cluster-scoped %s %s must not set metadata.namespace", u.GetKind(), u.GetName()))
+	}
+
+	return r.prepareTracked(ctx, binding, u)
+}
+
+// prepareCrossNamespace validates and labels a resource that targets another namespace
+func (r *NamespaceClassBindingReconciler) prepareCrossNamespace(ctx context.Context,
+	binding *akuityv1alpha1.NamespaceClassBinding, class *akuityv1alpha1.NamespaceClass,
+	u *unstructured.Unstructured) error {
+	if !targetNamespaceAllowed(binding, class, u.GetNamespace()) {
+		return permanent(fmt.Errorf("%s %s targets namespace %q, which NamespaceClass %q may not write into; "+
+			"add it to spec.allowedTargetNamespaces", u.GetKind(), u.GetName(), u.GetNamespace(), class.Name))
+	}
+
+	return r.prepareTracked(ctx, binding, u)
+}
+
+// prepareTracked labels a resource that can't be owned by the binding and makes sure it can be cleaned up
+func (r *NamespaceClassBindingReconciler) prepareTracked(ctx context.Context,
+	binding *akuityv1alpha1.NamespaceClassBinding, u *unstructured.Unstructured) error {
+	setTrackingLabels(u, binding)
+
+	if err := r.checkTrackedOwnership(ctx, binding, u); err != nil {
+		return err
+	}
+
+	// The finalizer must be in place before the object exists so it can never be orphaned
+	return r.ensureCleanupFinalizer(ctx, binding)
+}
+
+// checkTrackedOwnership refuses to take over a label-tracked resource this binding doesn't manage
+func (r *NamespaceClassBindingReconciler) checkTrackedOwnership(ctx context.Context,
+	binding *akuityv1alpha1.NamespaceClassBinding, u *unstructured.Unstructured) error {
+	if isBindingNamespace(u, binding) {
+		// Labelling the binding's own namespace is always allowed
+		return nil
+	}
+
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(u.GroupVersionKind())
+	if err := r.Get(ctx, client.ObjectKeyFromObject(u), current); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("get %s/%s: %w", u.GetKind(), u.GetName(), err)
+	}
+
+	if !isTrackedBy(current, binding) {
+		return fmt.Errorf("%s %s already exists and is not managed by binding %s/%s",
+			u.GetKind(), objectRef(u), binding.Namespace, binding.Name)
+	}
+	return nil
+}
+
+// targetNamespaceAllowed reports whether the class may write into namespace for the binding
+func targetNamespaceAllowed(binding *akuityv1alpha1.NamespaceClassBinding,
+	class *akuityv1alpha1.NamespaceClass, namespace string) bool {
+	if namespace == binding.Namespace {
+		return true
+	}
+	for _, pattern := range class.Spec.AllowedTargetNamespaces {
+		if ok, err := path.Match(renderString(pattern, binding.Namespace), namespace); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureCleanupFinalizer adds the cleanup finalizer to the binding if it is missing. It is called from
+// concurrent applies, so it patches a copy and only copies back the fields the patch changed.
+func (r *NamespaceClassBindingReconciler) ensureCleanupFinalizer(ctx context.Context,
+	binding *akuityv1alpha1.NamespaceClassBinding) error {
+	r.finalizerMu.Lock()
+	defer r.finalizerMu.Unlock()
+
+	if controllerutil.ContainsFinalizer(binding, finalizerCleanup) {
+		return nil
+	}
+
+	patched := binding.DeepCopy()
+	controllerutil.AddFinalizer(patched, finalizerCleanup)
+	if err := r.Patch(ctx, patched, client.MergeFromWithOptions(binding.DeepCopy(),
+		client.MergeFromWithOptimisticLock{})); err != nil {
+		return fmt.Errorf("add finalizer: %w", err)
+	}
+	binding.SetFinalizers(patched.GetFinalizers())
+	binding.SetResourceVersion(patched.GetResourceVersion())
+	return nil
+}
+
+// finalizeBinding deletes tracked resources that aren't garbage collected and releases the binding
+func (r *NamespaceClassBindingReconciler) finalizeBinding(ctx context.Context,
+	binding *akuityv1alpha1.NamespaceClassBinding) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(binding, finalizerCleanup) {
+		return ctrl.Result{}, nil
+	}
+
+	logger := log.FromContext(ctx)
+	logger.Info("cleaning up resources for deleted binding")
+
+	if err := r.deleteOldResources(ctx, binding); err != nil {
+		logger.Error(err, "failed to clean up resources for deleted binding")
+		return ctrl.Result{}, err
+	}
+	if err := r.clearNamespaceMetadata(ctx, binding); err != nil {
+		logger.Error(err, "failed to remove namespace metadata for deleted binding")
+		return ctrl.Result{}, err
+	}
+
+	base := binding.DeepCopy()
+	controllerutil.RemoveFinalizer(binding, finalizerCleanup)
+	if err := r.Patch(ctx, binding, client.MergeFrom(base)); err != nil && !errors.IsNotFound(err) {
+		logger.Error(err, "failed to remove finalizer")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// trackedObject builds a reference to a resource tracked in the binding status
+func trackedObject(binding *akuityv1alpha1.NamespaceClassBinding,
+	res akuityv1alpha1.AppliedResource) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(res.APIVersion)
+	u.SetKind(res.Kind)
+	u.SetName(res.Name)
+	switch {
+	case res.ClusterScoped:
+	case res.Namespace != "":
+		u.SetNamespace(res.Namespace)
+	default:
+		u.SetNamespace(binding.Namespace)
+	}
+	return u
+}
+
+// deleteTrackedResource deletes a resource tracked in the binding status
+func (r *NamespaceClassBindingReconciler) deleteTrackedResource(ctx context.Context,
+	binding *akuityv1alpha1.NamespaceClassBinding, res akuityv1alpha1.AppliedResource) error {
+	// Pending resources were never created
+	if res.Phase == akuityv1alpha1.ResourcePhasePending {
+		return nil
+	}
+
+	u := trackedObject(binding, res)
+
+	if res.ClusterScoped || res.Namespace != "" {
+		// Never delete the namespace the binding itself lives in
+		if isBindingNamespace(u, binding) {
+			return nil
+		}
+
+		// Only delete label-tracked objects that are still labelled for this binding
+		if err := r.Get(ctx, client.ObjectKeyFromObject(u), u); err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if !isTrackedBy(u, binding) {
+			return nil
+		}
+	}
+
+	if err := r.Delete(ctx, u); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// setTrackingLabels marks a resource as managed by the binding
+func setTrackingLabels(u *unstructured.Unstructured, binding *akuityv1alpha1.NamespaceClassBinding) {
+	labels := u.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[labelBindingNamespace] = binding.Namespace
+	labels[labelBindingName] = binding.Name
+	u.SetLabels(labels)
+}
+
+// isTrackedBy reports whether obj carries the tracking labels of the binding
+func isTrackedBy(obj metav1.Object, binding *akuityv1alpha1.NamespaceClassBinding) bool {
+	labels := obj.GetLabels()
+	return labels[labelBindingNamespace] == binding.Namespace && labels[labelBindingName] == binding.Name
+}
+
+// isBindingNamespace reports whether u is the Namespace the binding lives in
+func isBindingNamespace(u *unstructured.Unstructured, binding *akuityv1alpha1.NamespaceClassBinding) bool {
+	gvk := u.GroupVersionKind()
+	return gvk.Group == "" && gvk.Kind == "Namespace" && u.GetName() == binding.Namespace
+}
+
+// objectRef formats the namespace/name of an object, or just the name when it is cluster-scoped
+func objectRef(obj metav1.Object) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
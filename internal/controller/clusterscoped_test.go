@@ -0,0 +1,316 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// newTestRESTMapper returns a RESTMapper that knows the kinds used in the binding tests
+func newTestRESTMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Secret"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+	mapper.Add(rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), meta.RESTScopeRoot)
+	return mapper
+}
+
+// newBindingTestScheme returns a scheme with the types used in the binding tests
+func newBindingTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, rbacv1.AddToScheme(scheme))
+	require.NoError(t, akuityv1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+// newBindingTestReconciler returns a binding reconciler backed by a fake client holding objs
+func newBindingTestReconciler(scheme *runtime.Scheme,
+	objs ...client.Object) (*NamespaceClassBindingReconciler, client.Client) {
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(newTestRESTMapper()).
+		WithStatusSubresource(&akuityv1alpha1.NamespaceClassBinding{}).
+		WithObjects(objs...).
+		Build()
+	return &NamespaceClassBindingReconciler{
+		Client:   c,
+		Scheme:   scheme,
+		Recorder: record.NewFakeRecorder(10),
+	}, c
+}
+
+// degradedMessage returns the message of the binding's Degraded condition, failing if it isn't True
+func degradedMessage(t *testing.T, c client.Client, key types.NamespacedName) string {
+	t.Helper()
+	binding := &akuityv1alpha1.NamespaceClassBinding{}
+	require.NoError(t, c.Get(context.Background(), key, binding))
+	cond := meta.FindStatusCondition(binding.Status.Conditions, akuityv1alpha1.BindingConditionDegraded)
+	require.NotNil(t, cond, "expected a Degraded condition")
+	require.Equal(t, metav1.ConditionTrue, cond.Status)
+	return cond.Message
+}
+
+// clusterRoleRaw is a class entry for a per-namespace ClusterRole
+var clusterRoleRaw = runtime.RawExtension{Raw: []byte(`{
+	"apiVersion": "rbac.authorization.k8s.io/v1",
+	"kind": "ClusterRole",
+	"metadata": {"name": "reader-$(NAMESPACE)"},
+	"rules": [{"apiGroups": [""], "resources": ["pods"], "verbs": ["get"]}]
+}`)}
+
+func TestNamespaceClassBindingReconciler_ClusterScopedResources(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-ns", Namespace: "test-ns"}}
+
+	newBinding := func() *akuityv1alpha1.NamespaceClassBinding {
+		return &akuityv1alpha1.NamespaceClassBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-ns", Namespace: "test-ns"},
+			Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+		}
+	}
+	newClass := func(allow bool) *akuityv1alpha1.NamespaceClass {
+		return &akuityv1alpha1.NamespaceClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-class", Generation: 1},
+			Spec: akuityv1alpha1.NamespaceClassSpec{
+				AllowClusterScoped: allow,
+				Resources:          []runtime.RawExtension{clusterRoleRaw},
+			},
+		}
+	}
+	newReconciler := func(objs ...client.Object) (*NamespaceClassBindingReconciler, client.Client) {
+		return newBindingTestReconciler(scheme, objs...)
+	}
+
+	t.Run("rejects cluster-scoped resources unless the class opts in", func(t *testing.T) {
+		ctx := context.Background()
+		reconciler, c := newReconciler(newBinding(), newClass(false))
+
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Contains(t, degradedMessage(t, c, req.NamespacedName), "allowClusterScoped")
+
+		err = c.Get(ctx, client.ObjectKey{Name: "reader-test-ns"}, &rbacv1.ClusterRole{})
+		assert.True(t, errors.IsNotFound(err))
+	})
+
+	t.Run("applies with labels and cleans up through the finalizer", func(t *testing.T) {
+		ctx := context.Background()
+		reconciler, c := newReconciler(newBinding(), newClass(true))
+
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+
+		role := &rbacv1.ClusterRole{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "reader-test-ns"}, role))
+		assert.Empty(t, role.OwnerReferences)
+		assert.Equal(t, "test-ns", role.Labels[labelBindingNamespace])
+		assert.Equal(t, "test-ns", role.Labels[labelBindingName])
+
+		binding := &akuityv1alpha1.NamespaceClassBinding{}
+		require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+		assert.Contains(t, binding.Finalizers, finalizerCleanup)
+		require.Len(t, binding.Status.AppliedResources, 1)
+		assert.True(t, binding.Status.AppliedResources[0].ClusterScoped)
+		assert.Equal(t, "reader-test-ns", binding.Status.AppliedResources[0].Name)
+
+		// Deleting the binding keeps it around until the finalizer has run
+		require.NoError(t, c.Delete(ctx, binding))
+		_, err = reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+
+		err = c.Get(ctx, client.ObjectKey{Name: "reader-test-ns"}, &rbacv1.ClusterRole{})
+		assert.True(t, errors.IsNotFound(err), "expected ClusterRole to be deleted")
+		err = c.Get(ctx, req.NamespacedName, &akuityv1alpha1.NamespaceClassBinding{})
+		assert.True(t, errors.IsNotFound(err), "expected binding to be released")
+	})
+
+	t.Run("refuses to take over an unmanaged cluster-scoped resource", func(t *testing.T) {
+		ctx := context.Background()
+		existing := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "reader-test-ns"}}
+		reconciler, c := newReconciler(newBinding(), newClass(true), existing)
+
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Contains(t, degradedMessage(t, c, req.NamespacedName), "not managed by binding")
+
+		role := &rbacv1.ClusterRole{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "reader-test-ns"}, role))
+		assert.Empty(t, role.Labels)
+	})
+
+	t.Run("never deletes the binding's own namespace", func(t *testing.T) {
+		ctx := context.Background()
+		binding := newBinding()
+		binding.Status.AppliedResources = []akuityv1alpha1.AppliedResource{
+			{APIVersion: "v1", Kind: "Namespace", Name: "test-ns", ClusterScoped: true},
+		}
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name: "test-ns",
+			Labels: map[string]string{
+				labelBindingNamespace: "test-ns",
+				labelBindingName:      "test-ns",
+			},
+		}}
+		reconciler, c := newReconciler(binding, ns)
+
+		require.NoError(t, reconciler.deleteOldResources(ctx, binding))
+		assert.NoError(t, c.Get(ctx, client.ObjectKey{Name: "test-ns"}, &corev1.Namespace{}))
+	})
+}
+
+func TestNamespaceClassBindingReconciler_CrossNamespaceResources(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-ns", Namespace: "test-ns"}}
+	monitoringRaw := runtime.RawExtension{Raw: []byte(`{
+		"apiVersion": "v1",
+		"kind": "ConfigMap",
+		"metadata": {"name": "$(NAMESPACE)-scrape", "namespace": "monitoring"},
+		"data": {"target": "$(NAMESPACE)"}
+	}`)}
+
+	newObjects := func(allowed ...string) []client.Object {
+		return []client.Object{
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-ns", Namespace: "test-ns"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			},
+			&akuityv1alpha1.NamespaceClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-class", Generation: 1},
+				Spec: akuityv1alpha1.NamespaceClassSpec{
+					AllowedTargetNamespaces: allowed,
+					Resources:               []runtime.RawExtension{monitoringRaw},
+				},
+			},
+		}
+	}
+
+	t.Run("rejects namespaces outside the allowed list", func(t *testing.T) {
+		ctx := context.Background()
+		reconciler, c := newBindingTestReconciler(scheme, newObjects("shared-*")...)
+
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Contains(t, degradedMessage(t, c, req.NamespacedName), "allowedTargetNamespaces")
+
+		err = c.Get(ctx, client.ObjectKey{Name: "test-ns-scrape", Namespace: "monitoring"}, &corev1.ConfigMap{})
+		assert.True(t, errors.IsNotFound(err))
+	})
+
+	t.Run("applies, tracks and cleans up resources in other namespaces", func(t *testing.T) {
+		ctx := context.Background()
+		reconciler, c := newBindingTestReconciler(scheme, newObjects("monitoring")...)
+
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "test-ns-scrape", Namespace: "monitoring"}, cm))
+		assert.Empty(t, cm.OwnerReferences)
+		assert.Equal(t, "test-ns", cm.Data["target"])
+		assert.Equal(t, "test-ns", cm.Labels[labelBindingNamespace])
+
+		binding := &akuityv1alpha1.NamespaceClassBinding{}
+		require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+		assert.Contains(t, binding.Finalizers, finalizerCleanup)
+		assert.Equal(t, []akuityv1alpha1.AppliedResource{
+			{APIVersion: "v1", Kind: "ConfigMap", Name: "test-ns-scrape", Namespace: "monitoring",
+				Phase: akuityv1alpha1.ResourcePhaseApplied},
+		}, withoutApplyState(binding.Status.AppliedResources))
+
+		// A second reconcile must not prune what the first one applied
+		require.NoError(t, reconciler.pruneRemovedResources(ctx, binding, &akuityv1alpha1.NamespaceClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-class"},
+			Spec:       akuityv1alpha1.NamespaceClassSpec{Resources: []runtime.RawExtension{monitoringRaw}},
+		}))
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(cm), &corev1.ConfigMap{}))
+
+		require.NoError(t, c.Delete(ctx, binding))
+		_, err = reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+
+		err = c.Get(ctx, client.ObjectKeyFromObject(cm), &corev1.ConfigMap{})
+		assert.True(t, errors.IsNotFound(err), "expected cross-namespace ConfigMap to be deleted")
+	})
+}
+
+func TestTargetNamespaceAllowed(t *testing.T) {
+	binding := &akuityv1alpha1.NamespaceClassBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+	}
+
+	tests := []struct {
+		name      string
+		allowed   []string
+		namespace string
+		expect    bool
+	}{
+		{"own namespace is always allowed", nil, "team-a", true},
+		{"other namespace denied by default", nil, "monitoring", false},
+		{"exact match", []string{"monitoring"}, "monitoring", true},
+		{"glob match", []string{"shared-*"}, "shared-tools", true},
+		{"placeholder match", []string{"$(NAMESPACE)-*"}, "team-a-sandbox", true},
+		{"placeholder mismatch", []string{"$(NAMESPACE)-*"}, "team-b-sandbox", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class := &akuityv1alpha1.NamespaceClass{
+				Spec: akuityv1alpha1.NamespaceClassSpec{AllowedTargetNamespaces: tt.allowed},
+			}
+			assert.Equal(t, tt.expect, targetNamespaceAllowed(binding, class, tt.namespace))
+		})
+	}
+}
+
+func TestRenderPlaceholders(t *testing.T) {
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "reader-$(NAMESPACE)"},
+		"subjects": []interface{}{
+			map[string]interface{}{"namespace": "$(NAMESPACE)", "kind": "ServiceAccount"},
+		},
+		"replicas": int64(2),
+	}
+
+	renderPlaceholders(obj, "team-a")
+
+	assert.Equal(t, map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "reader-team-a"},
+		"subjects": []interface{}{
+			map[string]interface{}{"namespace": "team-a", "kind": "ServiceAccount"},
+		},
+		"replicas": int64(2),
+	}, obj)
+}
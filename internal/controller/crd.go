@@ -0,0 +1,119 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// pendingKindsField indexes bindings by the kinds of their Pending resources
+	pendingKindsField = "status.pendingKinds"
+
+	// reasonCRDNotInstalled marks resources whose kind isn't served by the cluster yet
+	reasonCRDNotInstalled = "CRDNotInstalled"
+)
+
+// hasPendingResources reports whether the binding has resources that still need to be applied
+func hasPendingResources(binding *akuityv1alpha1.NamespaceClassBinding) bool {
+	for _, res := range binding.Status.AppliedResources {
+		if res.Phase == akuityv1alpha1.ResourcePhasePending {
+			return true
+		}
+	}
+	return false
+}
+
+// countPendingResources returns how many of the resources are Pending
+func countPendingResources(resources []akuityv1alpha1.AppliedResource) int {
+	n := 0
+	for _, res := range resources {
+		if res.Phase == akuityv1alpha1.ResourcePhasePending {
+			n++
+		}
+	}
+	return n
+}
+
+// pendingKindKey formats the group and kind of a resource for the pending kinds index
+func pendingKindKey(group, kind string) string {
+	return schema.GroupKind{Group: group, Kind: kind}.String()
+}
+
+// indexPendingKinds returns the pending kinds index values for a binding
+func indexPendingKinds(obj client.Object) []string {
+	binding := obj.(*akuityv1alpha1.NamespaceClassBinding)
+
+	var kinds []string
+	for _, res := range binding.Status.AppliedResources {
+		if res.Phase != akuityv1alpha1.ResourcePhasePending {
+			continue
+		}
+		gk := schema.FromAPIVersionAndKind(res.APIVersion, res.Kind).GroupKind()
+		kinds = append(kinds, pendingKindKey(gk.Group, gk.Kind))
+	}
+	return kinds
+}
+
+// crdEstablished reports whether the CRD is ready to serve its kind
+func crdEstablished(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	for _, cond := range crd.Status.Conditions {
+		if cond.Type == apiextensionsv1.Established {
+			return cond.Status == apiextensionsv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// crdEstablishedPredicate only passes CRDs that are (or just became) established
+var crdEstablishedPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		crd, ok := e.Object.(*apiextensionsv1.CustomResourceDefinition)
+		return ok && crdEstablished(crd)
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldCRD, okOld := e.ObjectOld.(*apiextensionsv1.CustomResourceDefinition)
+		newCRD, okNew := e.ObjectNew.(*apiextensionsv1.CustomResourceDefinition)
+		return okOld && okNew && !crdEstablished(oldCRD) && crdEstablished(newCRD)
+	},
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
+
+// findBindingsForCRD returns reconcile requests for bindings waiting on the kind the CRD serves. The
+// RESTMapper looks up kinds it doesn't know yet on its own, so the bindings resolve the new kind.
+func (r *NamespaceClassBindingReconciler) findBindingsForCRD(ctx context.Context,
+	obj client.Object) []reconcile.Request {
+	crd := obj.(*apiextensionsv1.CustomResourceDefinition)
+
+	var bindings akuityv1alpha1.NamespaceClassBindingList
+	if err := r.List(ctx, &bindings,
+		client.MatchingFields{pendingKindsField: pendingKindKey(crd.Spec.Group, crd.Spec.Names.Kind)}); err != nil {
+		return nil
+	}
+
+	return bindingRequests(bindings.Items)
+}
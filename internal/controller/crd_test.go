@@ -0,0 +1,143 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+func TestNamespaceClassBindingReconciler_MissingCRD(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-ns", Namespace: "test-ns"}}
+
+	class := &akuityv1alpha1.NamespaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-class", Generation: 1},
+		Spec: akuityv1alpha1.NamespaceClassSpec{
+			Resources: []runtime.RawExtension{
+				{Raw: []byte(`{"apiVersion": "monitoring.coreos.com/v1", "kind": "ServiceMonitor",
+					"metadata": {"name": "app"}}`)},
+				{Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings"}}`)},
+			},
+		},
+	}
+	binding := &akuityv1alpha1.NamespaceClassBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ns", Namespace: "test-ns"},
+		Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+	}
+	reconciler, c := newBindingTestReconciler(scheme, binding, class)
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	// The rest of the class is applied
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: "test-ns"}, &corev1.ConfigMap{}))
+
+	require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+	assert.Equal(t, []akuityv1alpha1.AppliedResource{
+		{APIVersion: "monitoring.coreos.com/v1", Kind: "ServiceMonitor", Name: "app",
+			Phase: akuityv1alpha1.ResourcePhasePending, Reason: reasonCRDNotInstalled},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "settings", Phase: akuityv1alpha1.ResourcePhaseApplied},
+	}, withoutApplyState(binding.Status.AppliedResources))
+	assert.True(t, reconciler.needsUpdate(binding, class), "pending resources must be retried")
+	assert.Equal(t, []string{"ServiceMonitor.monitoring.coreos.com"}, indexPendingKinds(binding))
+
+	events := reconciler.Recorder.(*record.FakeRecorder).Events
+	assert.Contains(t, <-events, "ResourcesPending")
+	assert.Contains(t, <-events, "Successfully applied 1 resources")
+
+	// Pending resources were never created, so cleanup skips them
+	assert.NoError(t, reconciler.deleteOldResources(ctx, binding))
+}
+
+func TestNamespaceClassBindingReconciler_FindBindingsForCRD(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	require.NoError(t, apiextensionsv1.AddToScheme(scheme))
+
+	waiting := &akuityv1alpha1.NamespaceClassBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns1", Namespace: "ns1"},
+		Status: akuityv1alpha1.NamespaceClassBindingStatus{
+			AppliedResources: []akuityv1alpha1.AppliedResource{{
+				APIVersion: "monitoring.coreos.com/v1", Kind: "ServiceMonitor", Name: "app",
+				Phase: akuityv1alpha1.ResourcePhasePending, Reason: reasonCRDNotInstalled,
+			}},
+		},
+	}
+	synced := &akuityv1alpha1.NamespaceClassBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns2", Namespace: "ns2"},
+		Status: akuityv1alpha1.NamespaceClassBindingStatus{
+			AppliedResources: []akuityv1alpha1.AppliedResource{{
+				APIVersion: "monitoring.coreos.com/v1", Kind: "ServiceMonitor", Name: "app",
+				Phase: akuityv1alpha1.ResourcePhaseApplied,
+			}},
+		},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(waiting, synced).
+		WithIndex(&akuityv1alpha1.NamespaceClassBinding{}, pendingKindsField, indexPendingKinds).
+		Build()
+	reconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme}
+
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "monitoring.coreos.com",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Kind: "ServiceMonitor"},
+		},
+	}
+
+	requests := reconciler.findBindingsForCRD(context.Background(), crd)
+	require.Len(t, requests, 1)
+	assert.Equal(t, types.NamespacedName{Name: "ns1", Namespace: "ns1"}, requests[0].NamespacedName)
+}
+
+func TestCRDEstablishedPredicate(t *testing.T) {
+	withEstablished := func(status apiextensionsv1.ConditionStatus) *apiextensionsv1.CustomResourceDefinition {
+		return &apiextensionsv1.CustomResourceDefinition{
+			Status: apiextensionsv1.CustomResourceDefinitionStatus{
+				Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+					{Type: apiextensionsv1.Established, Status: status},
+				},
+			},
+		}
+	}
+	established := withEstablished(apiextensionsv1.ConditionTrue)
+	notEstablished := withEstablished(apiextensionsv1.ConditionFalse)
+
+	assert.True(t, crdEstablishedPredicate.Create(event.CreateEvent{Object: established}))
+	assert.False(t, crdEstablishedPredicate.Create(event.CreateEvent{Object: notEstablished}))
+	assert.True(t, crdEstablishedPredicate.Update(event.UpdateEvent{ObjectOld: notEstablished, ObjectNew: established}))
+	assert.False(t, crdEstablishedPredicate.Update(event.UpdateEvent{ObjectOld: established, ObjectNew: established}))
+	assert.False(t, crdEstablishedPredicate.Delete(event.DeleteEvent{Object: established}))
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// retryBaseDelay is the backoff after the first transient failure
+	retryBaseDelay = 5 * time.Second
+
+	// retryMaxDelay caps the per-binding backoff
+	retryMaxDelay = 5 * time.Minute
+
+	// reasonInvalidClass is the Degraded reason for failures only a class change can fix
+	reasonInvalidClass = "InvalidClass"
+
+	// reasonApplyFailed is the Degraded reason for failures that are retried with backoff
+	reasonApplyFailed = "ApplyFailed"
+)
+
+// permanentError marks a failure that retrying can't fix until the class changes
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// permanent marks err as a permanent failure
+func permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// isPermanentFailure reports whether err can't be fixed by retrying: invalid class content, kinds the
+// class isn't allowed to use, or objects the API server rejected against its schema
+func isPermanentFailure(err error) bool {
+	var perm *permanentError
+	if stderrors.As(err, &perm) {
+		return true
+	}
+	return errors.IsInvalid(err) || errors.IsBadRequest(err)
+}
+
+// failureBackoff returns the exponential backoff after the given number of consecutive failures
+func failureBackoff(attempts int32) time.Duration {
+	delay := retryBaseDelay
+	for i := int32(1); i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMaxDelay)
+}
+
+// retryDelay returns how long a binding must still wait before retrying a transient failure. A changed
+// class is retried right away.
+func retryDelay(binding *akuityv1alpha1.NamespaceClassBinding, class *akuityv1alpha1.NamespaceClass) time.Duration {
+	if binding.Status.NextRetryTime == nil ||
+		binding.Status.ObservedClassGeneration != class.Generation ||
+		binding.Status.ObservedClassName != binding.Spec.ClassName {
+		return 0
+	}
+	return time.Until(binding.Status.NextRetryTime.Time)
+}
+
+// mergeTracked appends previously tracked resources missing from applied, so a failed apply never
+// forgets resources it should still prune later
+func mergeTracked(applied, previous []akuityv1alpha1.AppliedResource) []akuityv1alpha1.AppliedResource {
+	seen := make(map[string]struct{}, len(applied))
+	for _, res := range applied {
+		seen[getKey(res.APIVersion, res.Kind, res.Namespace, res.Name)] = struct{}{}
+	}
+
+	merged := append([]akuityv1alpha1.AppliedResource{}, applied...)
+	for _, prev := range previous {
+		if _, ok := seen[getKey(prev.APIVersion, prev.Kind, prev.Namespace, prev.Name)]; !ok {
+			merged = append(merged, prev)
+		}
+	}
+	return merged
+}
+
+// handleApplyFailure records a failed apply on the binding. Permanent failures stop retrying until the
+// class changes; transient failures are retried with a per-binding exponential backoff.
+func (r *NamespaceClassBindingReconciler) handleApplyFailure(ctx context.Context, req ctrl.Request,
+	binding *akuityv1alpha1.NamespaceClassBinding, class *akuityv1alpha1.NamespaceClass,
+	applied []akuityv1alpha1.AppliedResource, cause error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	isPermanent := isPermanentFailure(cause)
+	logger.Error(cause, "failed to apply resources", "permanent", isPermanent)
+
+	var result ctrl.Result
+	if err := r.patchBindingStatus(ctx, req.NamespacedName, func(b *akuityv1alpha1.NamespaceClassBinding) {
+		b.Status.ObservedClassName = class.Name
+		b.Status.ObservedClassGeneration = class.Generation
+		b.Status.ObservedRevision = revisionName(class.Name, class.Generation)
+		b.Status.ObservedSourcesHash = sourcesHash(class)
+		if applied != nil {
+			b.Status.AppliedResources = applied
+		}
+		// What is live no longer matches a single rendered set
+		b.Status.RenderedHash = ""
+
+		degraded := metav1.Condition{
+			Type:               akuityv1alpha1.BindingConditionDegraded,
+			Status:             metav1.ConditionTrue,
+			Reason:             reasonInvalidClass,
+			Message:            cause.Error(),
+			ObservedGeneration: b.Generation,
+		}
+
+		if isPermanent {
+			b.Status.FailedAttempts = 0
+			b.Status.NextRetryTime = nil
+		} else {
+			b.Status.FailedAttempts++
+			result.RequeueAfter = failureBackoff(b.Status.FailedAttempts)
+			next := metav1.NewTime(time.Now().Add(result.RequeueAfter))
+			b.Status.NextRetryTime = &next
+			degraded.Reason = reasonApplyFailed
+			degraded.Message = fmt.Sprintf("%s (retry %d at %s)", cause.Error(), b.Status.FailedAttempts,
+				next.UTC().Format(time.RFC3339))
+		}
+		meta.SetStatusCondition(&b.Status.Conditions, degraded)
+	}); err != nil {
+		logger.Error(err, "failed to update binding status")
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Event(binding, corev1.EventTypeWarning, "ReconcileFailed",
+		fmt.Sprintf("Failed to apply resources from class %s: %v", binding.Spec.ClassName, cause))
+
+	return result, nil
+}
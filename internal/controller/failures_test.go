@@ -0,0 +1,167 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+func TestIsPermanentFailure(t *testing.T) {
+	gr := schema.GroupResource{Resource: "configmaps"}
+
+	tests := []struct {
+		name   string
+		err    error
+		expect bool
+	}{
+		{"marked permanent", permanent(fmt.Errorf("bad entry")), true},
+		{"wrapped permanent", fmt.Errorf("apply: %w", permanent(fmt.Errorf("bad entry"))), true},
+		{"schema rejection", errors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "x",
+			field.ErrorList{field.NotSupported(field.NewPath("spec", "mode"), "Foo", []string{"Bar"})}), true},
+		{"bad request", errors.NewBadRequest("unknown field"), true},
+		{"conflict", errors.NewConflict(gr, "x", fmt.Errorf("modified")), false},
+		{"throttled", errors.NewTooManyRequests("slow down", 1), false},
+		{"timeout", errors.NewServerTimeout(gr, "patch", 1), false},
+		{"network", fmt.Errorf("dial tcp: connection refused"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, isPermanentFailure(tt.err))
+		})
+	}
+}
+
+func TestFailureBackoff(t *testing.T) {
+	assert.Equal(t, 5*time.Second, failureBackoff(1))
+	assert.Equal(t, 10*time.Second, failureBackoff(2))
+	assert.Equal(t, 40*time.Second, failureBackoff(4))
+	assert.Equal(t, retryMaxDelay, failureBackoff(20))
+}
+
+func TestMergeTracked(t *testing.T) {
+	applied := []akuityv1alpha1.AppliedResource{{APIVersion: "v1", Kind: "ConfigMap", Name: "a"}}
+	previous := []akuityv1alpha1.AppliedResource{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "a"},
+		{APIVersion: "v1", Kind: "Secret", Name: "b"},
+	}
+
+	assert.Equal(t, []akuityv1alpha1.AppliedResource{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "a"},
+		{APIVersion: "v1", Kind: "Secret", Name: "b"},
+	}, mergeTracked(applied, previous))
+}
+
+func TestNamespaceClassBindingReconciler_FailureHandling(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-ns", Namespace: "test-ns"}}
+
+	newObjects := func(raw string) []client.Object {
+		return []client.Object{
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-ns", Namespace: "test-ns"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			},
+			&akuityv1alpha1.NamespaceClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-class", Generation: 3},
+				Spec: akuityv1alpha1.NamespaceClassSpec{
+					Resources: []runtime.RawExtension{{Raw: []byte(raw)}},
+				},
+			},
+		}
+	}
+
+	t.Run("permanent failure stops retrying until the class changes", func(t *testing.T) {
+		ctx := context.Background()
+		reconciler, c := newBindingTestReconciler(scheme, newObjects(`{"apiVersion": 1}`)...)
+
+		result, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, ctrl.Result{}, result)
+
+		binding := &akuityv1alpha1.NamespaceClassBinding{}
+		require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+		assert.Equal(t, int64(3), binding.Status.ObservedClassGeneration)
+		assert.Nil(t, binding.Status.NextRetryTime)
+		cond := meta.FindStatusCondition(binding.Status.Conditions, akuityv1alpha1.BindingConditionDegraded)
+		require.NotNil(t, cond)
+		assert.Equal(t, reasonInvalidClass, cond.Reason)
+
+		class := &akuityv1alpha1.NamespaceClass{}
+		require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "test-class"}, class))
+		assert.False(t, reconciler.needsUpdate(binding, class))
+	})
+
+	t.Run("transient failure backs off per binding", func(t *testing.T) {
+		ctx := context.Background()
+		patches := 0
+		c := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithRESTMapper(newTestRESTMapper()).
+			WithStatusSubresource(&akuityv1alpha1.NamespaceClassBinding{}).
+			WithObjects(newObjects(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "a"}}`)...).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+					opts ...client.PatchOption) error {
+					patches++
+					return errors.NewTooManyRequests("slow down", 1)
+				},
+			}).
+			Build()
+		reconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+
+		result, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, retryBaseDelay, result.RequeueAfter)
+
+		binding := &akuityv1alpha1.NamespaceClassBinding{}
+		require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+		assert.Equal(t, int32(1), binding.Status.FailedAttempts)
+		require.NotNil(t, binding.Status.NextRetryTime)
+		cond := meta.FindStatusCondition(binding.Status.Conditions, akuityv1alpha1.BindingConditionDegraded)
+		require.NotNil(t, cond)
+		assert.Equal(t, reasonApplyFailed, cond.Reason)
+
+		// Reconciling again before the retry time waits instead of applying
+		applied := patches
+		result, err = reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Positive(t, result.RequeueAfter)
+		assert.LessOrEqual(t, result.RequeueAfter, retryBaseDelay)
+		assert.Equal(t, applied, patches)
+	})
+}
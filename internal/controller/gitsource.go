@@ -0,0 +1,379 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// maxCachedGitRenders bounds how many rendered commit paths are kept per repository
+	maxCachedGitRenders = 32
+
+	// gitRemoteTimeout bounds a single clone, fetch or ref listing of a remote repository
+	gitRemoteTimeout = 2 * time.Minute
+)
+
+// GitRepositories keeps the Git repositories of class sources cloned in memory. One instance is
+// shared by the reconcilers so every repository is cloned once and only fetched afterwards.
+type GitRepositories struct {
+	mu    sync.Mutex
+	repos map[string]*gitRepository
+}
+
+// gitRepository is one cloned repository and the resources read from its commits
+type gitRepository struct {
+	mu        sync.Mutex
+	repo      *git.Repository
+	resources map[string][]runtime.RawExtension
+}
+
+// NewGitRepositories returns an empty set of Git repositories
+func NewGitRepositories() *GitRepositories {
+	return &GitRepositories{repos: map[string]*gitRepository{}}
+}
+
+// repository returns the repository of url, which may not be cloned yet
+func (g *GitRepositories) repository(url string) *gitRepository {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, ok := g.repos[url]
+	if !ok {
+		repo = &gitRepository{resources: map[string][]runtime.RawExtension{}}
+		g.repos[url] = repo
+	}
+	return repo
+}
+
+// resolve fetches the repository and returns the commit SHA ref points at
+func (g *GitRepositories) resolve(ctx context.Context, url, ref string, auth transport.AuthMethod) (string, error) {
+	repo := g.repository(url)
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if err := repo.fetch(ctx, url, auth); err != nil {
+		return "", err
+	}
+	hash, err := repo.resolveRef(ctx, ref, auth)
+	if err != nil {
+		return "", err
+	}
+	commit, err := repo.commit(hash)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", refOrDefault(ref), err)
+	}
+	return commit.Hash.String(), nil
+}
+
+// resources returns the resources in the files below dir at the given commit, fetching the
+// repository if the commit isn't known yet
+func (g *GitRepositories) resources(ctx context.Context, url, sha, dir string,
+	auth transport.AuthMethod) ([]runtime.RawExtension, error) {
+	repo := g.repository(url)
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	key := sha + "\x00" + dir
+	if resources, ok := repo.resources[key]; ok {
+		return resources, nil
+	}
+
+	commit, err := repo.fetchedCommit(ctx, url, sha, auth)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := commitResources(commit, dir)
+	if err != nil {
+		return nil, permanent(fmt.Errorf("commit %s: %w", sha, err))
+	}
+	if len(repo.resources) >= maxCachedGitRenders {
+		repo.resources = map[string][]runtime.RawExtension{}
+	}
+	repo.resources[key] = resources
+	return resources, nil
+}
+
+// files returns every file below dir at the given commit by its path relative to dir, fetching the
+// repository if the commit isn't known yet
+func (g *GitRepositories) files(ctx context.Context, url, sha, dir string,
+	auth transport.AuthMethod) (map[string][]byte, error) {
+	repo := g.repository(url)
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	commit, err := repo.fetchedCommit(ctx, url, sha, auth)
+	if err != nil {
+		return nil, err
+	}
+	files, err := commitFiles(commit, dir, func(string) bool { return true })
+	if err != nil {
+		return nil, permanent(fmt.Errorf("commit %s: %w", sha, err))
+	}
+	return files, nil
+}
+
+// fetchedCommit returns the commit with the given SHA, fetching the repository if it isn't known yet
+func (r *gitRepository) fetchedCommit(ctx context.Context, url, sha string,
+	auth transport.AuthMethod) (*object.Commit, error) {
+	commit, err := r.commit(plumbing.NewHash(sha))
+	if r.repo == nil || stderrors.Is(err, plumbing.ErrObjectNotFound) {
+		if err := r.fetch(ctx, url, auth); err != nil {
+			return nil, err
+		}
+		commit, err = r.commit(plumbing.NewHash(sha))
+	}
+	if err != nil {
+		return nil, permanent(fmt.Errorf("commit %s: %w", sha, err))
+	}
+	return commit, nil
+}
+
+// fetch clones the repository into memory, or fetches new commits once it is cloned
+func (r *gitRepository) fetch(ctx context.Context, url string, auth transport.AuthMethod) error {
+	// A remote that stops responding would otherwise hold the repository lock and a worker forever
+	ctx, cancel := context.WithTimeout(ctx, gitRemoteTimeout)
+	defer cancel()
+
+	if r.repo == nil {
+		repo, err := git.CloneContext(ctx, memory.NewStorage(), nil, &git.CloneOptions{
+			URL:  url,
+			Auth: auth,
+			Tags: git.AllTags,
+		})
+		if err != nil {
+			return fmt.Errorf("clone %s: %w", url, err)
+		}
+		r.repo = repo
+		return nil
+	}
+
+	err := r.repo.FetchContext(ctx, &git.FetchOptions{
+		Auth:     auth,
+		RefSpecs: []config.RefSpec{"+refs/heads/*:refs/remotes/origin/*"},
+		Tags:     git.AllTags,
+		Force:    true,
+	})
+	if err != nil && !stderrors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("fetch %s: %w", url, err)
+	}
+	return nil
+}
+
+// resolveRef returns the object a branch, tag or commit SHA points at on the remote. An empty ref is
+// the default branch.
+func (r *gitRepository) resolveRef(ctx context.Context, ref string, auth transport.AuthMethod) (plumbing.Hash, error) {
+	if plumbing.IsHash(ref) {
+		return plumbing.NewHash(ref), nil
+	}
+
+	remote, err := r.repo.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	listCtx, cancel := context.WithTimeout(ctx, gitRemoteTimeout)
+	defer cancel()
+	refs, err := remote.ListContext(listCtx, &git.ListOptions{Auth: auth})
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("list refs: %w", err)
+	}
+	byName := make(map[plumbing.ReferenceName]*plumbing.Reference, len(refs))
+	for _, r := range refs {
+		byName[r.Name()] = r
+	}
+
+	candidates := []plumbing.ReferenceName{plumbing.HEAD}
+	if ref != "" {
+		candidates = []plumbing.ReferenceName{
+			plumbing.NewBranchReferenceName(ref),
+			plumbing.NewTagReferenceName(ref),
+			plumbing.ReferenceName(ref),
+		}
+	}
+	for _, name := range candidates {
+		found, ok := byName[name]
+		if ok && found.Type() == plumbing.SymbolicReference {
+			found, ok = byName[found.Target()]
+		}
+		if ok {
+			return found.Hash(), nil
+		}
+	}
+	return plumbing.ZeroHash, permanent(fmt.Errorf("ref %s not found", refOrDefault(ref)))
+}
+
+// commit returns the commit hash points at, peeling annotated tags
+func (r *gitRepository) commit(hash plumbing.Hash) (*object.Commit, error) {
+	if r.repo == nil {
+		return nil, plumbing.ErrObjectNotFound
+	}
+	if tag, err := r.repo.TagObject(hash); err == nil {
+		return tag.Commit()
+	}
+	return r.repo.CommitObject(hash)
+}
+
+// commitResources reads the resources in the .yaml, .yml and .json files below dir, in path order
+func commitResources(commit *object.Commit, dir string) ([]runtime.RawExtension, error) {
+	files, err := commitFiles(commit, dir, isManifestFile)
+	if err != nil {
+		return nil, err
+	}
+	return manifestResources(files)
+}
+
+// commitFiles reads the files below dir that keep accepts, by their path relative to dir
+func commitFiles(commit *object.Commit, dir string, keep func(name string) bool) (map[string][]byte, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	if dir = strings.Trim(path.Clean("/"+dir), "/"); dir != "" {
+		if tree, err = tree.Tree(dir); err != nil {
+			return nil, fmt.Errorf("path %s: %w", dir, err)
+		}
+	}
+
+	files := map[string][]byte{}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if !keep(f.Name) {
+			return nil
+		}
+		contents, err := f.Contents()
+		if err != nil {
+			return fmt.Errorf("read %s: %w", f.Name, err)
+		}
+		files[f.Name] = []byte(contents)
+		return nil
+	})
+	return files, err
+}
+
+// isManifestFile reports whether a file is read for resources: .yaml, .yml and .json files
+func isManifestFile(name string) bool {
+	switch path.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// manifestResources reads the resources in the files in path order
+func manifestResources(files map[string][]byte) ([]runtime.RawExtension, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var resources []runtime.RawExtension
+	for _, name := range names {
+		docs, err := splitYAMLDocuments(files[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		resources = append(resources, docs...)
+	}
+	return resources, nil
+}
+
+// gitAuth builds the credentials of a Git source from its Secret
+func gitAuth(ctx context.Context, c client.Reader, source *akuityv1alpha1.GitSource) (transport.AuthMethod, error) {
+	if source.SecretRef == nil {
+		return nil, nil
+	}
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: source.SecretRef.Name, Namespace: source.SecretRef.Namespace}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return nil, fmt.Errorf("get Secret %s: %w", key, err)
+	}
+
+	if identity, ok := secret.Data["identity"]; ok {
+		knownHosts, ok := secret.Data["known_hosts"]
+		if !ok {
+			return nil, permanent(fmt.Errorf("secret %s has an identity but no known_hosts", key))
+		}
+		user := "git"
+		if endpoint, err := transport.NewEndpoint(source.URL); err == nil && endpoint.User != "" {
+			user = endpoint.User
+		}
+		auth, err := gitssh.NewPublicKeys(user, identity, string(secret.Data["password"]))
+		if err != nil {
+			return nil, permanent(fmt.Errorf("secret %s: %w", key, err))
+		}
+		if auth.HostKeyCallback, err = knownHostsCallback(knownHosts); err != nil {
+			return nil, permanent(fmt.Errorf("secret %s: known_hosts: %w", key, err))
+		}
+		return auth, nil
+	}
+
+	username := string(secret.Data["username"])
+	if username == "" {
+		// Hosts that take a token as password still want some username
+		username = "git"
+	}
+	return &githttp.BasicAuth{Username: username, Password: string(secret.Data["password"])}, nil
+}
+
+// knownHostsCallback verifies SSH host keys against known_hosts content. The parser only reads
+// files, so the content is staged in a temporary file that is removed once it is loaded.
+func knownHostsCallback(knownHosts []byte) (ssh.HostKeyCallback, error) {
+	f, err := os.CreateTemp("", "known_hosts")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+	if _, err := f.Write(knownHosts); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return gitssh.NewKnownHostsCallback(f.Name())
+}
+
+// refOrDefault names ref in messages
+func refOrDefault(ref string) string {
+	if ref == "" {
+		return "default branch"
+	}
+	return ref
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// testGitRepo is a working repository that pushes to a local bare repository sources can read
+type testGitRepo struct {
+	t    *testing.T
+	dir  string
+	repo *git.Repository
+	bare string
+}
+
+// newTestGitRepo creates a bare repository and a working repository pushing to it
+func newTestGitRepo(t *testing.T) *testGitRepo {
+	bare := filepath.Join(t.TempDir(), "classes.git")
+	_, err := git.PlainInit(bare, true)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{bare}})
+	require.NoError(t, err)
+	return &testGitRepo{t: t, dir: dir, repo: repo, bare: bare}
+}
+
+// commit writes files (an empty content deletes the file), commits them and pushes, returning the SHA
+func (r *testGitRepo) commit(files map[string]string) string {
+	worktree, err := r.repo.Worktree()
+	require.NoError(r.t, err)
+	for name, content := range files {
+		path := filepath.Join(r.dir, name)
+		if content == "" {
+			_, err := worktree.Remove(name)
+			require.NoError(r.t, err)
+			continue
+		}
+		require.NoError(r.t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(r.t, os.WriteFile(path, []byte(content), 0o644))
+		_, err := worktree.Add(name)
+		require.NoError(r.t, err)
+	}
+	hash, err := worktree.Commit("update", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	require.NoError(r.t, err)
+	require.NoError(r.t, r.repo.Push(&git.PushOptions{RemoteName: "origin"}))
+	return hash.String()
+}
+
+func TestGitRepositories(t *testing.T) {
+	ctx := context.Background()
+	origin := newTestGitRepo(t)
+	first := origin.commit(map[string]string{
+		"README.md":   "not a resource",
+		"team/b.json": `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "token"}}`,
+		"team/a.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: one\n---\n" +
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: two\n",
+	})
+	tag, err := origin.repo.CreateTag("v1", mustHash(t, origin.repo), nil)
+	require.NoError(t, err)
+	require.NoError(t, origin.repo.Push(&git.PushOptions{RemoteName: "origin",
+		RefSpecs: []config.RefSpec{config.RefSpec(tag.Name() + ":" + tag.Name())}}))
+
+	repos := NewGitRepositories()
+	sha, err := repos.resolve(ctx, origin.bare, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, first, sha)
+
+	// Only manifests below the path are read, in path order
+	resources, err := repos.resources(ctx, origin.bare, sha, "team", nil)
+	require.NoError(t, err)
+	parsed := parseClass(context.Background(), nil, &akuityv1alpha1.NamespaceClass{Spec: akuityv1alpha1.NamespaceClassSpec{Resources: resources}})
+	require.NoError(t, parsed.err)
+	var names []string
+	for _, res := range parsed.resources {
+		names = append(names, res.Name)
+	}
+	assert.Equal(t, []string{"one", "two", "token"}, names)
+
+	// New commits are fetched; old commits stay readable
+	second := origin.commit(map[string]string{"team/b.json": ""})
+	sha, err = repos.resolve(ctx, origin.bare, "master", nil)
+	require.NoError(t, err)
+	assert.Equal(t, second, sha)
+	resources, err = repos.resources(ctx, origin.bare, second, "team", nil)
+	require.NoError(t, err)
+	assert.Len(t, resources, 2)
+	resources, err = repos.resources(ctx, origin.bare, first, "team", nil)
+	require.NoError(t, err)
+	assert.Len(t, resources, 3)
+
+	// Tags and commit SHAs resolve too
+	sha, err = repos.resolve(ctx, origin.bare, "v1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, first, sha)
+	sha, err = repos.resolve(ctx, origin.bare, first, nil)
+	require.NoError(t, err)
+	assert.Equal(t, first, sha)
+
+	_, err = repos.resolve(ctx, origin.bare, "missing", nil)
+	assert.True(t, isPermanentFailure(err))
+	_, err = repos.resources(ctx, origin.bare, second, "missing", nil)
+	assert.True(t, isPermanentFailure(err))
+}
+
+// mustHash returns the commit HEAD of repo points at
+func mustHash(t *testing.T, repo *git.Repository) plumbing.Hash {
+	head, err := repo.Head()
+	require.NoError(t, err)
+	return head.Hash()
+}
+
+func TestNamespaceClassReconciler_GitSource(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	origin := newTestGitRepo(t)
+	first := origin.commit(map[string]string{
+		"limits.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: limits\ndata:\n  cpu: \"1\"\n",
+	})
+
+	class := settingsClass(1, "1")
+	class.Spec.Sources = []akuityv1alpha1.ResourceSource{{Git: &akuityv1alpha1.GitSource{URL: origin.bare}}}
+	c := newRolloutTestClientBuilder(scheme).
+		WithObjects(class, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			}).
+		Build()
+	repos := NewGitRepositories()
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10),
+		Git: repos}
+	bindingReconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme,
+		Recorder: record.NewFakeRecorder(10), Git: repos}
+	classReq := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-class"}}
+	bindingReq := ctrl.Request{NamespacedName: types.NamespacedName{Name: "team-a", Namespace: "team-a"}}
+
+	// The class records the commit and polls again after the interval
+	result, err := classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+	assert.Equal(t, defaultSourceInterval, result.RequeueAfter.Round(time.Minute))
+	require.NoError(t, c.Get(ctx, classReq.NamespacedName, class))
+	require.Len(t, class.Status.Sources, 1)
+	assert.Equal(t, first, class.Status.Sources[0].Revision)
+	assert.True(t, meta.IsStatusConditionTrue(class.Status.Conditions, akuityv1alpha1.ClassConditionSourcesReady))
+
+	_, err = bindingReconciler.Reconcile(ctx, bindingReq)
+	require.NoError(t, err)
+	limits := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "limits", Namespace: "team-a"}, limits))
+	assert.Equal(t, "1", limits.Data["cpu"])
+
+	// A push isn't picked up before the next poll unless the class is refreshed
+	second := origin.commit(map[string]string{
+		"limits.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: limits\ndata:\n  cpu: \"2\"\n",
+	})
+	_, err = classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, classReq.NamespacedName, class))
+	assert.Equal(t, first, class.Status.Sources[0].Revision)
+
+	old := class.DeepCopy()
+	class.Annotations = map[string]string{annotationRefresh: "1"}
+	require.NoError(t, c.Update(ctx, class))
+	_, err = classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, classReq.NamespacedName, class))
+	assert.Equal(t, second, class.Status.Sources[0].Revision)
+	assert.Equal(t, "1", class.Status.ObservedRefresh)
+	assert.True(t, sourceRevisionsChanged.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: class}))
+
+	_, err = bindingReconciler.Reconcile(ctx, bindingReq)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "limits", Namespace: "team-a"}, limits))
+	assert.Equal(t, "2", limits.Data["cpu"])
+
+	// A ref that doesn't resolve is reported on the class
+	class.Spec.Sources[0].Git.Ref = "missing"
+	class.Generation = 2
+	require.NoError(t, c.Update(ctx, class))
+	_, err = classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, classReq.NamespacedName, class))
+	cond := meta.FindStatusCondition(class.Status.Conditions, akuityv1alpha1.ClassConditionSourcesReady)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Contains(t, cond.Message, "ref missing not found")
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// GitWebhookPath is where GitWebhookServer receives push notifications
+	GitWebhookPath = "/hooks/git"
+
+	// maxWebhookPayload bounds the size of a push notification
+	maxWebhookPayload = 4 << 20
+)
+
+// GitWebhookHandler receives push notifications from Git hosts and refreshes the classes with a Git
+// source for the pushed repository, so they don't wait for their next poll. It understands the
+// payloads of GitHub, GitLab, Gitea and Bitbucket Server, which all name the repository URL.
+type GitWebhookHandler struct {
+	Client client.Client
+
+	// Secret is the shared secret of the webhook. Pushes must be signed with it (X-Hub-Signature-256)
+	// or carry it as token (X-Gitlab-Token); without it every push is refused.
+	Secret string
+}
+
+// ServeHTTP refreshes the classes of the repository named in the push notification
+func (h *GitWebhookHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	logger := log.FromContext(req.Context()).WithName("git-webhook")
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxWebhookPayload))
+	if err != nil {
+		http.Error(w, "failed to read payload", http.StatusBadRequest)
+		return
+	}
+	if !h.authorized(req, body) {
+		http.Error(w, "invalid signature or token", http.StatusUnauthorized)
+		return
+	}
+
+	urls, err := pushedRepositoryURLs(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	refreshed, err := h.refresh(req.Context(), urls)
+	if err != nil {
+		logger.Error(err, "failed to refresh classes")
+		http.Error(w, "failed to refresh classes", http.StatusInternalServerError)
+		return
+	}
+	logger.Info("refreshed classes for push", "classes", refreshed)
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = fmt.Fprintf(w, "refreshed %d classes\n", len(refreshed))
+}
+
+// authorized checks the signature or token of the push against the shared secret
+func (h *GitWebhookHandler) authorized(req *http.Request, body []byte) bool {
+	if h.Secret == "" {
+		return false
+	}
+	if signature := req.Header.Get("X-Hub-Signature-256"); signature != "" {
+		mac := hmac.New(sha256.New, []byte(h.Secret))
+		mac.Write(body)
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(signature), []byte(expected))
+	}
+	if token := req.Header.Get("X-Gitlab-Token"); token != "" {
+		return subtle.ConstantTimeCompare([]byte(token), []byte(h.Secret)) == 1
+	}
+	return false
+}
+
+// refresh sets the refresh annotation on every class with a Git source for one of urls and returns
+// their names
+func (h *GitWebhookHandler) refresh(ctx context.Context, urls []string) ([]string, error) {
+	pushed := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		pushed[normalizeGitURL(url)] = true
+	}
+
+	var classes akuityv1alpha1.NamespaceClassList
+	if err := h.Client.List(ctx, &classes); err != nil {
+		return nil, fmt.Errorf("list classes: %w", err)
+	}
+
+	var refreshed []string
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for i := range classes.Items {
+		class := &classes.Items[i]
+		if !readsGitRepository(class, pushed) {
+			continue
+		}
+		base := class.DeepCopy()
+		if class.Annotations == nil {
+			class.Annotations = map[string]string{}
+		}
+		class.Annotations[annotationRefresh] = now
+		if err := h.Client.Patch(ctx, class, client.MergeFrom(base)); err != nil {
+			return refreshed, fmt.Errorf("refresh class %s: %w", class.Name, err)
+		}
+		refreshed = append(refreshed, class.Name)
+	}
+	return refreshed, nil
+}
+
+// readsGitRepository reports whether the class has a Git source for one of the normalized URLs
+func readsGitRepository(class *akuityv1alpha1.NamespaceClass, urls map[string]bool) bool {
+	for _, source := range class.Spec.Sources {
+		if source.Git != nil && urls[normalizeGitURL(source.Git.URL)] {
+			return true
+		}
+	}
+	return false
+}
+
+// pushedRepositoryURLs returns the URLs a push notification names for its repository
+func pushedRepositoryURLs(body []byte) ([]string, error) {
+	var payload struct {
+		Repository map[string]json.RawMessage `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+
+	var urls []string
+	for _, field := range []string{"clone_url", "git_url", "ssh_url", "html_url", "url",
+		"git_http_url", "git_ssh_url", "homepage"} {
+		var url string
+		if err := json.Unmarshal(payload.Repository[field], &url); err == nil && url != "" {
+			urls = append(urls, url)
+		}
+	}
+	if len(urls) == 0 {
+		return nil, stderrors.New("payload names no repository URL")
+	}
+	return urls, nil
+}
+
+// normalizeGitURL reduces the HTTPS, SSH and scp-like forms of a repository URL to host/path, so
+// a push matches sources however they spell the URL
+func normalizeGitURL(url string) string {
+	url = strings.ToLower(strings.TrimSpace(url))
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+	} else if i := strings.Index(url, ":"); i >= 0 {
+		// scp-like git@host:owner/repo
+		url = url[:i] + "/" + url[i+1:]
+	}
+	if i := strings.Index(url, "@"); i >= 0 && i < strings.Index(url+"/", "/") {
+		url = url[i+1:]
+	}
+	if host, path, ok := strings.Cut(url, "/"); ok {
+		// Drop the port, which differs between the HTTPS and SSH URLs of the same repository
+		host, _, _ = strings.Cut(host, ":")
+		url = host + "/" + path
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+}
+
+// GitWebhookServer serves GitWebhookHandler. It runs on every replica, not just the leader, since any
+// of them can take the push.
+type GitWebhookServer struct {
+	// Addr is the address to listen on
+	Addr string
+
+	Handler *GitWebhookHandler
+}
+
+// Start serves push notifications until ctx is done
+func (s *GitWebhookServer) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle(GitWebhookPath, s.Handler)
+	server := &http.Server{
+		Addr:              s.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- server.ListenAndServe() }()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		if err := <-errs; !stderrors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// NeedLeaderElection lets the server run on replicas that aren't the leader
+func (s *GitWebhookServer) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+func TestNormalizeGitURL(t *testing.T) {
+	for _, url := range []string{
+		"https://github.com/Example/classes.git",
+		"https://github.com/example/classes",
+		"ssh://git@github.com:22/example/classes.git",
+		"git@github.com:example/classes.git",
+		"git://github.com/example/classes/",
+	} {
+		assert.Equal(t, "github.com/example/classes", normalizeGitURL(url), url)
+	}
+}
+
+func TestGitWebhookHandler(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+
+	gitClass := func(name, url string) *akuityv1alpha1.NamespaceClass {
+		return &akuityv1alpha1.NamespaceClass{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: akuityv1alpha1.NamespaceClassSpec{
+				Sources: []akuityv1alpha1.ResourceSource{{Git: &akuityv1alpha1.GitSource{URL: url}}},
+			},
+		}
+	}
+	c := newRolloutTestClientBuilder(scheme).WithObjects(
+		gitClass("pushed", "git@github.com:example/classes.git"),
+		gitClass("other", "https://github.com/example/other.git"),
+	).Build()
+	handler := &GitWebhookHandler{Client: c, Secret: "s3cret"}
+
+	payload := `{"ref": "refs/heads/main", "repository": {"clone_url": "https://github.com/example/classes.git"}}`
+	push := func(signature string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, GitWebhookPath, strings.NewReader(payload))
+		req.Header.Set("X-Hub-Signature-256", signature)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Unsigned pushes are rejected
+	assert.Equal(t, http.StatusUnauthorized, push("sha256=00").Code)
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(payload))
+	assert.Equal(t, http.StatusAccepted, push("sha256="+hex.EncodeToString(mac.Sum(nil))).Code)
+
+	// Only classes reading the pushed repository are refreshed
+	class := &akuityv1alpha1.NamespaceClass{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "pushed"}, class))
+	assert.NotEmpty(t, class.Annotations[annotationRefresh])
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "other"}, class))
+	assert.NotContains(t, class.Annotations, annotationRefresh)
+
+	// Without a secret, even pushes signed with an empty key are refused
+	handler.Secret = ""
+	mac = hmac.New(sha256.New, nil)
+	mac.Write([]byte(payload))
+	assert.Equal(t, http.StatusUnauthorized, push("sha256="+hex.EncodeToString(mac.Sum(nil))).Code)
+}
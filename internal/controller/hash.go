@@ -0,0 +1,97 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// objectHash returns the content hash of a rendered object
+func objectHash(u *unstructured.Unstructured) (string, error) {
+	// encoding/json sorts map keys, so equal objects always hash the same
+	b, err := json.Marshal(u.Object)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// renderedSetHash returns the content hash of a whole rendered resource set together with the
+// namespace metadata, as rendered for the given namespace
+func renderedSetHash(resources []parsedResource, namespace string,
+	metadata *akuityv1alpha1.NamespaceMetadata) (string, error) {
+	h := sha256.New()
+	for _, res := range resources {
+		u := res.Object.DeepCopy()
+		u.SetAPIVersion(res.APIVersion)
+		u.SetKind(res.Kind)
+		u.SetName(res.Name)
+		renderPlaceholders(u.Object, namespace)
+		hash, err := objectHash(u)
+		if err != nil {
+			return "", fmt.Errorf("hash %s/%s: %w", res.Kind, res.Name, err)
+		}
+		h.Write([]byte(hash))
+		h.Write([]byte{0})
+	}
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+	h.Write([]byte(renderString(string(b), namespace)))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// renderedUnchanged reports whether applying the class can be skipped as a whole: the rendered set and
+// the source content are what was last applied successfully and nothing is left to finish
+func renderedUnchanged(binding *akuityv1alpha1.NamespaceClassBinding, class *akuityv1alpha1.NamespaceClass,
+	renderedHash string) bool {
+	return binding.Status.RenderedHash == renderedHash &&
+		binding.Status.ObservedClassName == class.Name &&
+		binding.Status.ObservedSourcesHash == sourcesHash(class) &&
+		!hasPendingResources(binding) &&
+		!hasRecreatingResources(binding) &&
+		binding.Status.NextRetryTime == nil
+}
+
+// applyUnchanged reports whether the apply of u can be skipped: the rendered content matches what was
+// last applied and the live object hasn't been modified since
+func (r *NamespaceClassBindingReconciler) applyUnchanged(ctx context.Context, prev akuityv1alpha1.AppliedResource,
+	hash string, u *unstructured.Unstructured) bool {
+	if prev.Phase != akuityv1alpha1.ResourcePhaseApplied || prev.Hash != hash || prev.ResourceVersion == "" {
+		return false
+	}
+
+	// Only metadata is needed, which the manager's client serves from a metadata-only informer cache
+	live := &metav1.PartialObjectMetadata{}
+	live.SetGroupVersionKind(u.GroupVersionKind())
+	if err := r.Get(ctx, client.ObjectKeyFromObject(u), live); err != nil {
+		return false
+	}
+	return live.ResourceVersion == prev.ResourceVersion
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// withoutApplyState clears the fields that record apply bookkeeping, for comparing tracked resources
+func withoutApplyState(resources []akuityv1alpha1.AppliedResource) []akuityv1alpha1.AppliedResource {
+	out := make([]akuityv1alpha1.AppliedResource, len(resources))
+	for i, res := range resources {
+		res.Hash = ""
+		res.ResourceVersion = ""
+		out[i] = res
+	}
+	return out
+}
+
+func TestObjectHash(t *testing.T) {
+	a := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "ConfigMap", "data": map[string]interface{}{"a": "1", "b": "2"},
+	}}
+	b := &unstructured.Unstructured{Object: map[string]interface{}{
+		"data": map[string]interface{}{"b": "2", "a": "1"}, "kind": "ConfigMap",
+	}}
+	c := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "ConfigMap", "data": map[string]interface{}{"a": "1", "b": "3"},
+	}}
+
+	hashA, err := objectHash(a)
+	require.NoError(t, err)
+	hashB, err := objectHash(b)
+	require.NoError(t, err)
+	hashC, err := objectHash(c)
+	require.NoError(t, err)
+
+	assert.Equal(t, hashA, hashB)
+	assert.NotEqual(t, hashA, hashC)
+}
+
+func TestNamespaceClassBindingReconciler_SkipUnchanged(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-ns", Namespace: "test-ns"}}
+
+	class := &akuityv1alpha1.NamespaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-class", Generation: 1},
+		Spec: akuityv1alpha1.NamespaceClassSpec{
+			Resources: []runtime.RawExtension{
+				{Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "a"}, "data": {"k": "v"}}`)},
+				{Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "b"}, "data": {"k": "v"}}`)},
+			},
+		},
+	}
+	binding := &akuityv1alpha1.NamespaceClassBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ns", Namespace: "test-ns"},
+		Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+	}
+
+	applies := map[string]int{}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(newTestRESTMapper()).
+		WithStatusSubresource(&akuityv1alpha1.NamespaceClassBinding{}).
+		WithObjects(binding, class,
+			// The fake client only reports resourceVersions for objects it didn't create through apply
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "test-ns"}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "test-ns"}},
+		).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+				opts ...client.PatchOption) error {
+				if patch == client.Apply {
+					applies[obj.GetName()]++
+				}
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	reconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1, "b": 1}, applies)
+
+	require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+	require.Len(t, binding.Status.AppliedResources, 2)
+	assert.NotEmpty(t, binding.Status.AppliedResources[0].Hash)
+	assert.NotEmpty(t, binding.Status.AppliedResources[0].ResourceVersion)
+	assert.NotEmpty(t, binding.Status.RenderedHash)
+
+	// A new class generation with the same content doesn't write anything, and skips the resources altogether
+	renderedHash := binding.Status.RenderedHash
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "test-class"}, class))
+	class.Generation = 2
+	require.NoError(t, c.Update(ctx, class))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1, "b": 1}, applies)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+	assert.Equal(t, int64(2), binding.Status.ObservedClassGeneration)
+	assert.Equal(t, renderedHash, binding.Status.RenderedHash)
+
+	// When the set changes, unchanged resources are only applied again if their live object drifted
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "b", Namespace: "test-ns"}, cm))
+	cm.Data["k"] = "drifted"
+	require.NoError(t, c.Update(ctx, cm))
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "test-class"}, class))
+	class.Spec.Resources = append(class.Spec.Resources, runtime.RawExtension{
+		Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "c"}, "data": {"k": "v"}}`),
+	})
+	class.Generation = 3
+	require.NoError(t, c.Update(ctx, class))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, applies["a"])
+	assert.Greater(t, applies["b"], 1)
+	assert.Equal(t, 1, applies["c"])
+	require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+	assert.NotEqual(t, renderedHash, binding.Status.RenderedHash)
+
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "b", Namespace: "test-ns"}, cm))
+	assert.Equal(t, "v", cm.Data["k"])
+}
@@ -0,0 +1,475 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// helmChartLayerMediaType is the media type of the packaged chart in an OCI chart artifact
+	helmChartLayerMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+
+	// maxCachedHelmCharts bounds how many downloaded charts are kept
+	maxCachedHelmCharts = 32
+
+	// maxCachedHelmRenders bounds how many rendered charts are kept
+	maxCachedHelmRenders = 32
+
+	// maxHelmDownloadSize bounds the size of a repository index or a packaged chart
+	maxHelmDownloadSize = 16 << 20
+
+	// helmDownloadTimeout bounds a single download from a chart repository
+	helmDownloadTimeout = 30 * time.Second
+)
+
+// HelmCharts downloads the Helm charts of class sources from repositories and OCI registries and
+// keeps the packaged charts by version or digest, and their renders by chart digest and values. One
+// instance is shared by the reconcilers so every chart is downloaded and rendered once.
+type HelmCharts struct {
+	client *http.Client
+
+	mu       sync.Mutex
+	archives map[string][]byte
+	rendered map[string][]runtime.RawExtension
+}
+
+// helmRepositoryIndex is the part of a chart repository's index.yaml the operator reads
+type helmRepositoryIndex struct {
+	Entries map[string][]helmChartVersion `json:"entries"`
+}
+
+// helmChartVersion is one version of a chart in a repository index
+type helmChartVersion struct {
+	Version string   `json:"version"`
+	URLs    []string `json:"urls"`
+	Digest  string   `json:"digest,omitempty"`
+}
+
+// NewHelmCharts returns an empty set of Helm charts
+func NewHelmCharts() *HelmCharts {
+	return &HelmCharts{
+		client:   &http.Client{Timeout: helmDownloadTimeout},
+		archives: map[string][]byte{},
+		rendered: map[string][]runtime.RawExtension{},
+	}
+}
+
+// helmChartTarget returns the URL and ref a repository or OCI chart is recorded under in the class
+// status
+func helmChartTarget(chart akuityv1alpha1.HelmChartReference) (url, ref string) {
+	switch {
+	case chart.Repository != nil:
+		return strings.TrimSuffix(chart.Repository.URL, "/") + "/" + chart.Repository.Name, chart.Repository.Version
+	case chart.OCI != nil:
+		return chart.OCI.URL, chart.OCI.Version
+	default:
+		return "", ""
+	}
+}
+
+// ociChartArtifact returns the OCI artifact holding the version of the chart
+func ociChartArtifact(chart *akuityv1alpha1.HelmOCIChart) *akuityv1alpha1.OCISource {
+	return &akuityv1alpha1.OCISource{
+		URL:       chart.URL + ":" + chart.Version,
+		SecretRef: chart.SecretRef,
+		Insecure:  chart.Insecure,
+	}
+}
+
+// resolve returns the revision a repository or OCI chart points at: the chart version that matches
+// the requested version for a repository, and the manifest digest for OCI
+func (h *HelmCharts) resolve(ctx context.Context, c client.Reader, chart akuityv1alpha1.HelmChartReference) (string, error) {
+	switch {
+	case chart.Repository != nil:
+		entry, err := h.repositoryEntry(ctx, c, chart.Repository, chart.Repository.Version)
+		if err != nil {
+			return "", err
+		}
+		return entry.Version, nil
+	case chart.OCI != nil:
+		artifact := ociChartArtifact(chart.OCI)
+		ref, err := ociReference(artifact)
+		if err != nil {
+			return "", err
+		}
+		auth, err := ociAuth(ctx, c, artifact)
+		if err != nil {
+			return "", err
+		}
+		headCtx, cancel := context.WithTimeout(ctx, ociRemoteTimeout)
+		defer cancel()
+		desc, err := remote.Head(ref, remote.WithContext(headCtx), remote.WithAuth(auth))
+		if err != nil {
+			return "", fmt.Errorf("resolve %s: %w", ref, err)
+		}
+		return desc.Digest.String(), nil
+	default:
+		return "", permanent(stderrors.New("chart is not fetched from a repository or registry"))
+	}
+}
+
+// archive returns the packaged chart at the revision resolve returned, downloading it if it isn't
+// cached yet
+func (h *HelmCharts) archive(ctx context.Context, c client.Reader, chart akuityv1alpha1.HelmChartReference,
+	revision string) ([]byte, error) {
+	url, _ := helmChartTarget(chart)
+	key := url + "@" + revision
+
+	h.mu.Lock()
+	archive, ok := h.archives[key]
+	h.mu.Unlock()
+	if ok {
+		return archive, nil
+	}
+
+	var err error
+	switch {
+	case chart.Repository != nil:
+		archive, err = h.downloadRepositoryChart(ctx, c, chart.Repository, revision)
+	case chart.OCI != nil:
+		archive, err = pullOCIChart(ctx, c, chart.OCI, revision)
+	default:
+		err = permanent(stderrors.New("chart is not fetched from a repository or registry"))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.archives) >= maxCachedHelmCharts {
+		h.archives = map[string][]byte{}
+	}
+	h.archives[key] = archive
+	return archive, nil
+}
+
+// repositoryEntry reads the index of the repository and returns the chart version that matches
+// version
+func (h *HelmCharts) repositoryEntry(ctx context.Context, c client.Reader, repo *akuityv1alpha1.HelmRepositoryChart,
+	version string) (*helmChartVersion, error) {
+	indexURL := strings.TrimSuffix(repo.URL, "/") + "/index.yaml"
+	data, err := h.download(ctx, c, repo, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	index := &helmRepositoryIndex{}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, permanent(fmt.Errorf("parse %s: %w", indexURL, err))
+	}
+	versions, ok := index.Entries[repo.Name]
+	if !ok {
+		return nil, permanent(fmt.Errorf("chart %s not found in %s", repo.Name, repo.URL))
+	}
+	return selectChartVersion(repo.Name, versions, version)
+}
+
+// selectChartVersion returns the exact version if the index has it, or else the newest version that
+// meets the version constraint. An empty version is the newest stable version.
+func selectChartVersion(chart string, versions []helmChartVersion, version string) (*helmChartVersion, error) {
+	for i := range versions {
+		if versions[i].Version == version {
+			return &versions[i], nil
+		}
+	}
+
+	constraint := version
+	if constraint == "" {
+		constraint = "*"
+	}
+	constraints, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return nil, permanent(fmt.Errorf("chart version %s: %w", version, err))
+	}
+	var best *helmChartVersion
+	var bestVersion *semver.Version
+	for i := range versions {
+		v, err := semver.NewVersion(versions[i].Version)
+		if err != nil || !constraints.Check(v) {
+			continue
+		}
+		if bestVersion == nil || v.GreaterThan(bestVersion) {
+			best, bestVersion = &versions[i], v
+		}
+	}
+	if best == nil {
+		return nil, permanent(fmt.Errorf("chart %s has no version matching %s", chart, constraint))
+	}
+	return best, nil
+}
+
+// downloadRepositoryChart downloads a chart version from the repository and verifies it against the
+// digest in the index
+func (h *HelmCharts) downloadRepositoryChart(ctx context.Context, c client.Reader,
+	repo *akuityv1alpha1.HelmRepositoryChart, version string) ([]byte, error) {
+	entry, err := h.repositoryEntry(ctx, c, repo, version)
+	if err != nil {
+		return nil, err
+	}
+	if len(entry.URLs) == 0 {
+		return nil, permanent(fmt.Errorf("chart %s %s has no URL in the index", repo.Name, entry.Version))
+	}
+	base, err := url.Parse(strings.TrimSuffix(repo.URL, "/") + "/")
+	if err != nil {
+		return nil, permanent(fmt.Errorf("repository URL %s: %w", repo.URL, err))
+	}
+	chartURL, err := base.Parse(entry.URLs[0])
+	if err != nil {
+		return nil, permanent(fmt.Errorf("chart URL %s: %w", entry.URLs[0], err))
+	}
+
+	archive, err := h.download(ctx, c, repo, chartURL.String())
+	if err != nil {
+		return nil, err
+	}
+	if entry.Digest != "" {
+		sum := sha256.Sum256(archive)
+		if got := hex.EncodeToString(sum[:]); got != entry.Digest {
+			return nil, fmt.Errorf("chart %s %s has digest %s, the index says %s", repo.Name, entry.Version, got,
+				entry.Digest)
+		}
+	}
+	return archive, nil
+}
+
+// download fetches a file of the repository. Credentials are only sent to the repository's host.
+func (h *HelmCharts) download(ctx context.Context, c client.Reader, repo *akuityv1alpha1.HelmRepositoryChart,
+	target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, permanent(err)
+	}
+	if repo.SecretRef != nil {
+		if repoURL, err := url.Parse(repo.URL); err == nil && repoURL.Host == req.URL.Host {
+			username, password, err := basicCredentials(ctx, c, repo.SecretRef)
+			if err != nil {
+				return nil, err
+			}
+			req.SetBasicAuth(username, password)
+		}
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", target, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("get %s: %s", target, resp.Status)
+		if resp.StatusCode == http.StatusNotFound {
+			err = permanent(err)
+		}
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHelmDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", target, err)
+	}
+	if len(data) > maxHelmDownloadSize {
+		return nil, permanent(fmt.Errorf("get %s: larger than %d bytes", target, maxHelmDownloadSize))
+	}
+	return data, nil
+}
+
+// pullOCIChart pulls the chart with the given manifest digest. Pulling by digest verifies the
+// manifest and the chart layer against their digests.
+func pullOCIChart(ctx context.Context, c client.Reader, chart *akuityv1alpha1.HelmOCIChart,
+	digest string) ([]byte, error) {
+	artifact := ociChartArtifact(chart)
+	ref, err := ociReference(artifact)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := ociAuth(ctx, c, artifact)
+	if err != nil {
+		return nil, err
+	}
+	pinned := ref.Context().Digest(digest)
+	// The chart layer is read with this context too, so the deadline covers the whole pull
+	ctx, cancel := context.WithTimeout(ctx, ociRemoteTimeout)
+	defer cancel()
+	image, err := remote.Image(pinned, remote.WithContext(ctx), remote.WithAuth(auth))
+	if err != nil {
+		return nil, fmt.Errorf("pull %s: %w", pinned, err)
+	}
+	layers, err := image.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("pull %s: %w", pinned, err)
+	}
+	for _, layer := range layers {
+		mediaType, err := layer.MediaType()
+		if err != nil || mediaType != helmChartLayerMediaType {
+			continue
+		}
+		rc, err := layer.Compressed()
+		if err != nil {
+			return nil, fmt.Errorf("pull %s: %w", pinned, err)
+		}
+		defer func() { _ = rc.Close() }()
+		return readLimited(rc)
+	}
+	return nil, permanent(fmt.Errorf("%s has no %s layer", pinned, helmChartLayerMediaType))
+}
+
+// configMapChart reads a packaged chart from the binaryData of a ConfigMap
+func configMapChart(ctx context.Context, c client.Reader, source *akuityv1alpha1.HelmConfigMapChart) ([]byte, error) {
+	cm, err := sourceConfigMap(ctx, c, source.Namespace, source.Name)
+	if err != nil {
+		return nil, err
+	}
+	archive, ok := cm.BinaryData[source.Key]
+	if !ok {
+		return nil, permanent(fmt.Errorf("no binaryData key %s in ConfigMap %s/%s", source.Key,
+			source.Namespace, source.Name))
+	}
+	return archive, nil
+}
+
+// basicCredentials reads username and password from a Secret
+func basicCredentials(ctx context.Context, c client.Reader, ref *akuityv1alpha1.SecretReference) (string, string, error) {
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return "", "", fmt.Errorf("get Secret %s: %w", key, err)
+	}
+	return string(secret.Data["username"]), string(secret.Data["password"]), nil
+}
+
+// render returns the resources of a packaged chart rendered with the values of the source, rendering
+// it if the same chart wasn't rendered with the same values yet
+func (h *HelmCharts) render(archive []byte, source *akuityv1alpha1.HelmSource) ([]runtime.RawExtension, error) {
+	inputs, err := json.Marshal([]interface{}{source.Values, source.ReleaseName, source.IncludeCRDs})
+	if err != nil {
+		return nil, permanent(fmt.Errorf("values: %w", err))
+	}
+	chartSum := sha256.Sum256(archive)
+	inputsSum := sha256.Sum256(inputs)
+	key := hex.EncodeToString(chartSum[:]) + "/" + hex.EncodeToString(inputsSum[:])
+
+	h.mu.Lock()
+	resources, ok := h.rendered[key]
+	h.mu.Unlock()
+	if ok {
+		return resources, nil
+	}
+
+	resources, err = renderChart(archive, source)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.rendered) >= maxCachedHelmRenders {
+		h.rendered = map[string][]runtime.RawExtension{}
+	}
+	h.rendered[key] = resources
+	return resources, nil
+}
+
+// renderChart renders a packaged chart with the values of the source, as helm template would. The
+// release namespace is the namespace placeholder, which is filled in when the resources are applied.
+// Manifests are read in template path order, after the CRDs if the source includes them.
+func renderChart(archive []byte, source *akuityv1alpha1.HelmSource) ([]runtime.RawExtension, error) {
+	chart, err := loader.LoadArchive(bytes.NewReader(archive))
+	if err != nil {
+		return nil, permanent(fmt.Errorf("load chart: %w", err))
+	}
+	values := map[string]interface{}{}
+	if source.Values != nil && len(source.Values.Raw) > 0 {
+		if err := json.Unmarshal(source.Values.Raw, &values); err != nil {
+			return nil, permanent(fmt.Errorf("values: %w", err))
+		}
+	}
+	releaseName := source.ReleaseName
+	if releaseName == "" {
+		releaseName = chart.Name()
+	}
+
+	if err := chartutil.ProcessDependenciesWithMerge(chart, values); err != nil {
+		return nil, permanent(fmt.Errorf("chart %s dependencies: %w", chart.Name(), err))
+	}
+	renderValues, err := chartutil.ToRenderValues(chart, values, chartutil.ReleaseOptions{
+		Name:      releaseName,
+		Namespace: placeholderNamespace,
+		Revision:  1,
+		IsInstall: true,
+	}, nil)
+	if err != nil {
+		return nil, permanent(fmt.Errorf("chart %s values: %w", chart.Name(), err))
+	}
+	manifests, err := engine.Render(chart, renderValues)
+	if err != nil {
+		return nil, permanent(fmt.Errorf("render chart %s: %w", chart.Name(), err))
+	}
+
+	var resources []runtime.RawExtension
+	if source.IncludeCRDs {
+		for _, crd := range chart.CRDObjects() {
+			docs, err := splitYAMLDocuments(crd.File.Data)
+			if err != nil {
+				return nil, permanent(fmt.Errorf("%s: %w", crd.Filename, err))
+			}
+			resources = append(resources, docs...)
+		}
+	}
+
+	names := make([]string, 0, len(manifests))
+	for name := range manifests {
+		if path.Base(name) == "NOTES.txt" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		docs, err := splitYAMLDocuments([]byte(manifests[name]))
+		if err != nil {
+			return nil, permanent(fmt.Errorf("%s: %w", name, err))
+		}
+		resources = append(resources, docs...)
+	}
+	return resources, nil
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// limitsTemplate renders a ConfigMap named after the release from the cpu value
+const limitsTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}-limits
+  namespace: {{ .Release.Namespace }}
+data:
+  cpu: {{ .Values.cpu | quote }}
+  owner: {{ .Values.owner | quote }}
+`
+
+// testChartArchive packages a chart with the limits template and the given version
+func testChartArchive(t *testing.T, version string) []byte {
+	return tarGzLayer(t,
+		"limits/Chart.yaml", "apiVersion: v2\nname: limits\nversion: "+version+"\n",
+		"limits/values.yaml", "cpu: \"1\"\nowner: nobody\n",
+		"limits/templates/limits.yaml", limitsTemplate,
+		"limits/templates/NOTES.txt", "Installed {{ .Release.Name }}",
+		"limits/crds/widgets.yaml", "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\n"+
+			"metadata:\n  name: widgets.example.com\n")
+}
+
+func TestRenderChart(t *testing.T) {
+	archive := testChartArchive(t, "1.0.0")
+	render := func(source *akuityv1alpha1.HelmSource) []parsedResource {
+		resources, err := renderChart(archive, source)
+		require.NoError(t, err)
+		parsed := parseClass(context.Background(), nil, &akuityv1alpha1.NamespaceClass{Spec: akuityv1alpha1.NamespaceClassSpec{Resources: resources}})
+		require.NoError(t, parsed.err)
+		return parsed.resources
+	}
+
+	// Defaults render under the chart name; the release namespace is the placeholder
+	resources := render(&akuityv1alpha1.HelmSource{})
+	require.Len(t, resources, 1)
+	assert.Equal(t, "limits-limits", resources[0].Name)
+	assert.Equal(t, placeholderNamespace, resources[0].Namespace)
+	data, _, _ := unstructured.NestedStringMap(resources[0].Object.Object, "data")
+	assert.Equal(t, map[string]string{"cpu": "1", "owner": "nobody"}, data)
+
+	// Values override the defaults and may use the placeholder; CRDs come first when included
+	resources = render(&akuityv1alpha1.HelmSource{
+		ReleaseName: "team",
+		Values:      &runtime.RawExtension{Raw: []byte(`{"cpu": "2", "owner": "$(NAMESPACE)-admins"}`)},
+		IncludeCRDs: true,
+	})
+	require.Len(t, resources, 2)
+	assert.Equal(t, "CustomResourceDefinition", resources[0].Kind)
+	assert.Equal(t, "team-limits", resources[1].Name)
+	data, _, _ = unstructured.NestedStringMap(resources[1].Object.Object, "data")
+	assert.Equal(t, map[string]string{"cpu": "2", "owner": "$(NAMESPACE)-admins"}, data)
+
+	// Broken charts and templates are permanent failures
+	_, err := renderChart([]byte("not a chart"), &akuityv1alpha1.HelmSource{})
+	assert.True(t, isPermanentFailure(err))
+	_, err = renderChart(tarGzLayer(t,
+		"broken/Chart.yaml", "apiVersion: v2\nname: broken\nversion: 1.0.0\n",
+		"broken/templates/broken.yaml", "{{ .Values.missing.field }}"), &akuityv1alpha1.HelmSource{})
+	assert.True(t, isPermanentFailure(err))
+}
+
+func TestHelmChartsRender(t *testing.T) {
+	charts := NewHelmCharts()
+	archive := testChartArchive(t, "1.0.0")
+	source := &akuityv1alpha1.HelmSource{Values: &runtime.RawExtension{Raw: []byte(`{"cpu": "2"}`)}}
+
+	// The same chart with the same values renders once
+	first, err := charts.render(archive, source)
+	require.NoError(t, err)
+	require.NotEmpty(t, first)
+	second, err := charts.render(archive, source.DeepCopy())
+	require.NoError(t, err)
+	assert.Same(t, &first[0], &second[0])
+
+	// Other values or another chart render again
+	other, err := charts.render(archive, &akuityv1alpha1.HelmSource{})
+	require.NoError(t, err)
+	assert.NotSame(t, &first[0], &other[0])
+	assert.NotEqual(t, first, other)
+	other, err = charts.render(testChartArchive(t, "1.0.1"), source)
+	require.NoError(t, err)
+	assert.NotSame(t, &first[0], &other[0])
+}
+
+// newTestChartRepository serves a chart repository with the given chart versions that only answers
+// requests with the robot credentials
+func newTestChartRepository(t *testing.T, archives map[string][]byte, digests map[string]string) string {
+	mux := http.NewServeMux()
+	index := "apiVersion: v1\nentries:\n  limits:\n"
+	for version, archive := range archives {
+		digest := digests[version]
+		if digest == "" {
+			sum := sha256.Sum256(archive)
+			digest = hex.EncodeToString(sum[:])
+		}
+		index += fmt.Sprintf("  - version: %s\n    digest: %s\n    urls: [charts/limits-%s.tgz]\n", version, digest, version)
+		mux.HandleFunc("/charts/limits-"+version+".tgz", func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write(archive)
+		})
+	}
+	mux.HandleFunc("/index.yaml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(index))
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "robot" || pass != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestHelmCharts(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "robot", Namespace: "system"},
+		Data:       map[string][]byte{"username": []byte("robot"), "password": []byte("token")},
+	}).Build()
+	robot := &akuityv1alpha1.SecretReference{Name: "robot", Namespace: "system"}
+	repoURL := newTestChartRepository(t, map[string][]byte{
+		"1.0.0":      testChartArchive(t, "1.0.0"),
+		"1.0.1":      testChartArchive(t, "1.0.1"),
+		"1.1.0":      testChartArchive(t, "1.1.0"),
+		"2.0.0-rc.1": testChartArchive(t, "2.0.0-rc.1"),
+		"3.0.0":      testChartArchive(t, "3.0.0"),
+	}, map[string]string{"3.0.0": "tampered"})
+	charts := NewHelmCharts()
+	resolve := func(version string) (string, error) {
+		return charts.resolve(ctx, c, akuityv1alpha1.HelmChartReference{Repository: &akuityv1alpha1.HelmRepositoryChart{
+			URL: repoURL, Name: "limits", Version: version, SecretRef: robot,
+		}})
+	}
+
+	// Versions resolve exactly, by constraint, or to the newest stable version
+	version, err := resolve("1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", version)
+	version, err = resolve("~1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.1", version)
+	version, err = resolve("")
+	require.NoError(t, err)
+	assert.Equal(t, "3.0.0", version)
+	_, err = resolve("4.x")
+	assert.True(t, isPermanentFailure(err))
+
+	// Downloads are verified against the index digest
+	chart := akuityv1alpha1.HelmChartReference{Repository: &akuityv1alpha1.HelmRepositoryChart{
+		URL: repoURL, Name: "limits", SecretRef: robot,
+	}}
+	archive, err := charts.archive(ctx, c, chart, "1.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, testChartArchive(t, "1.1.0"), archive)
+	_, err = charts.archive(ctx, c, chart, "3.0.0")
+	assert.ErrorContains(t, err, "the index says tampered")
+
+	// Without credentials the repository refuses
+	chart.Repository.SecretRef = nil
+	_, err = charts.resolve(ctx, c, chart)
+	assert.ErrorContains(t, err, "401")
+
+	// OCI charts resolve to the digest of the version's tag and pull the chart layer
+	host := newTestRegistry(t, "", "")
+	layer := static.NewLayer(testChartArchive(t, "1.0.0"), types.MediaType(helmChartLayerMediaType))
+	image, err := mutate.AppendLayers(empty.Image, layer)
+	require.NoError(t, err)
+	ref, err := name.ParseReference(host + "/charts/limits:1.0.0")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, image, remote.WithAuth(authn.Anonymous)))
+	digest, err := image.Digest()
+	require.NoError(t, err)
+
+	ociChart := akuityv1alpha1.HelmChartReference{OCI: &akuityv1alpha1.HelmOCIChart{
+		URL: "oci://" + host + "/charts/limits", Version: "1.0.0",
+	}}
+	resolved, err := charts.resolve(ctx, c, ociChart)
+	require.NoError(t, err)
+	assert.Equal(t, digest.String(), resolved)
+	archive, err = charts.archive(ctx, c, ociChart, resolved)
+	require.NoError(t, err)
+	assert.Equal(t, testChartArchive(t, "1.0.0"), archive)
+}
+
+func TestNamespaceClassBindingReconciler_HelmSource(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: k8stypes.NamespacedName{Name: "team-a", Namespace: "team-a"}}
+
+	chart := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "charts", Namespace: "platform"},
+		BinaryData: map[string][]byte{"limits.tgz": testChartArchive(t, "1.0.0")},
+	}
+	class := settingsClass(1, "1")
+	class.Spec.Sources = []akuityv1alpha1.ResourceSource{{Helm: &akuityv1alpha1.HelmSource{
+		Chart: akuityv1alpha1.HelmChartReference{ConfigMap: &akuityv1alpha1.HelmConfigMapChart{
+			Name: "charts", Namespace: "platform", Key: "limits.tgz",
+		}},
+		Values: &runtime.RawExtension{Raw: []byte(`{"owner": "$(NAMESPACE)-admins"}`)},
+	}}}
+	c := newRolloutTestClientBuilder(scheme).
+		WithIndex(&akuityv1alpha1.NamespaceClass{}, classSourcesField, indexClassSources).
+		WithObjects(class, chart, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			}).
+		Build()
+	r := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+
+	// The rendered chart is applied in the namespace, with the namespace filled into the values
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	limits := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "limits-limits", Namespace: "team-a"}, limits))
+	assert.Equal(t, "team-a-admins", limits.Data["owner"])
+	assert.Equal(t, "1", limits.Data["cpu"])
+
+	// A new chart in the ConfigMap wakes up the bindings and replaces the rendered resources
+	assert.Equal(t, []ctrl.Request{req}, r.findBindingsForSource("ConfigMap")(ctx, chart))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(chart), chart))
+	chart.BinaryData["limits.tgz"] = tarGzLayer(t,
+		"limits/Chart.yaml", "apiVersion: v2\nname: limits\nversion: 1.1.0\n",
+		"limits/templates/quota.yaml", "apiVersion: v1\nkind: Secret\nmetadata:\n  name: {{ .Release.Name }}-token\n")
+	require.NoError(t, c.Update(ctx, chart))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "limits-token", Namespace: "team-a"}, &corev1.Secret{}))
+	err = c.Get(ctx, client.ObjectKey{Name: "limits-limits", Namespace: "team-a"}, limits)
+	assert.True(t, errors.IsNotFound(err))
+}
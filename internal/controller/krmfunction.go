@@ -0,0 +1,238 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// resourceListAPIVersion and resourceListKind identify the KRM function input and output
+	resourceListAPIVersion = "config.kubernetes.io/v1"
+	resourceListKind       = "ResourceList"
+
+	// defaultFunctionTimeout bounds a function run when no timeout is configured
+	defaultFunctionTimeout = 30 * time.Second
+
+	// maxFunctionOutput caps what is read from the standard output of a function
+	maxFunctionOutput = 32 << 20
+
+	// maxFunctionStderr caps the standard error kept for failure messages
+	maxFunctionStderr = 4 << 10
+
+	// annotationLocalConfig marks function output that is not applied
+	annotationLocalConfig = "config.kubernetes.io/local-config"
+)
+
+// resourceList is the KRM function input and output
+type resourceList struct {
+	APIVersion     string                `json:"apiVersion"`
+	Kind           string                `json:"kind"`
+	Items          []json.RawMessage     `json:"items"`
+	FunctionConfig *runtime.RawExtension `json:"functionConfig,omitempty"`
+	Results        []functionResult      `json:"results,omitempty"`
+}
+
+// functionResult is one result a function reports
+type functionResult struct {
+	Message  string `json:"message"`
+	Severity string `json:"severity,omitempty"`
+}
+
+// ExecFunction runs a KRM function from a local executable. The ResourceList is written to its
+// standard input and read back from its standard output. The executable runs without arguments and
+// without the operator's environment.
+type ExecFunction struct {
+	// Path is the absolute path of the executable
+	Path string
+
+	// Timeout bounds a single run. Defaults to 30 seconds.
+	Timeout time.Duration
+}
+
+// NewExecFunction returns a function running the executable at path, which must exist
+func NewExecFunction(path string, timeout time.Duration) (*ExecFunction, error) {
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("function executable %q must be an absolute path", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+		return nil, fmt.Errorf("function executable %q is not an executable file", path)
+	}
+	return &ExecFunction{Path: path, Timeout: timeout}, nil
+}
+
+// Run pipes the objects through the function with config as its functionConfig and returns the
+// objects it outputs. Output that isn't a valid ResourceList and errors the function reports are
+// permanent failures; timeouts and runs that fail without reporting anything are retried.
+func (f *ExecFunction) Run(ctx context.Context, objects []*unstructured.Unstructured,
+	config *runtime.RawExtension) ([]*unstructured.Unstructured, error) {
+	input, err := functionInput(objects, config)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := f.Timeout
+	if timeout <= 0 {
+		timeout = defaultFunctionTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout := &cappedBuffer{limit: maxFunctionOutput + 1}
+	stderr := &cappedBuffer{limit: maxFunctionStderr}
+	cmd := exec.CommandContext(ctx, f.Path)
+	cmd.Env = []string{}
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+	runErr := cmd.Run()
+	if stderrors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+	if stdout.Len() > maxFunctionOutput {
+		return nil, permanent(fmt.Errorf("output is larger than %d bytes", maxFunctionOutput))
+	}
+
+	output, parseErr := parseFunctionOutput(stdout.Bytes())
+	if parseErr == nil {
+		if messages := errorResults(output.Results); len(messages) > 0 {
+			return nil, permanent(fmt.Errorf("function reported errors: %s", strings.Join(messages, "; ")))
+		}
+	}
+	if runErr != nil {
+		return nil, fmt.Errorf("run %s: %w: %s", f.Path, runErr, strings.TrimSpace(stderr.String()))
+	}
+	if parseErr != nil {
+		return nil, permanent(parseErr)
+	}
+	return functionObjects(output)
+}
+
+// functionInput encodes objects and config as a ResourceList
+func functionInput(objects []*unstructured.Unstructured, config *runtime.RawExtension) ([]byte, error) {
+	list := resourceList{
+		APIVersion: resourceListAPIVersion,
+		Kind:       resourceListKind,
+		Items:      make([]json.RawMessage, 0, len(objects)),
+	}
+	for _, u := range objects {
+		item, err := u.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("encode %s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+		list.Items = append(list.Items, item)
+	}
+	if config != nil && len(config.Raw) > 0 {
+		var m map[string]interface{}
+		if err := json.Unmarshal(config.Raw, &m); err != nil || m == nil {
+			return nil, permanent(stderrors.New("functionConfig must be an object"))
+		}
+		list.FunctionConfig = config
+	}
+	return json.Marshal(list)
+}
+
+// parseFunctionOutput decodes the ResourceList a function wrote, which may be YAML or JSON
+func parseFunctionOutput(data []byte) (*resourceList, error) {
+	raw, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse output: %w", err)
+	}
+	list := &resourceList{}
+	if err := json.Unmarshal(raw, list); err != nil {
+		return nil, fmt.Errorf("parse output: %w", err)
+	}
+	if list.APIVersion != resourceListAPIVersion || list.Kind != resourceListKind {
+		return nil, fmt.Errorf("output is %q %q instead of a %s %s", list.APIVersion, list.Kind,
+			resourceListAPIVersion, resourceListKind)
+	}
+	return list, nil
+}
+
+// errorResults returns the messages of the results with error severity
+func errorResults(results []functionResult) []string {
+	var messages []string
+	for _, result := range results {
+		if result.Severity == "error" {
+			messages = append(messages, result.Message)
+		}
+	}
+	return messages
+}
+
+// functionObjects validates the items of a function's output. Local-config items are dropped and the
+// annotations functions use to track items are removed.
+func functionObjects(list *resourceList) ([]*unstructured.Unstructured, error) {
+	objects := make([]*unstructured.Unstructured, 0, len(list.Items))
+	for i, item := range list.Items {
+		u := &unstructured.Unstructured{}
+		if err := u.UnmarshalJSON(item); err != nil {
+			return nil, permanent(fmt.Errorf("output item %d: %w", i, err))
+		}
+		if u.GetName() == "" {
+			return nil, permanent(fmt.Errorf("output item %d: %s has no metadata.name", i, u.GetKind()))
+		}
+
+		annotations := u.GetAnnotations()
+		if annotations[annotationLocalConfig] == "true" {
+			continue
+		}
+		for key := range annotations {
+			if strings.HasPrefix(key, "config.kubernetes.io/") ||
+				strings.HasPrefix(key, "internal.config.kubernetes.io/") {
+				delete(annotations, key)
+			}
+		}
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		u.SetAnnotations(annotations)
+		objects = append(objects, u)
+	}
+	return objects, nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it and discards the rest
+type cappedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
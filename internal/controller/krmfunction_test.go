@@ -0,0 +1,221 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// generatorOutput is what the test generator writes: a ConfigMap owned by the namespace, and a
+// local-config object that isn't applied
+const generatorOutput = `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: generated
+    labels:
+      owner: $(NAMESPACE)
+    annotations:
+      config.kubernetes.io/index: "0"
+      internal.config.kubernetes.io/path: generated.yaml
+  data:
+    replicas: "3"
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: generator-settings
+    annotations:
+      config.kubernetes.io/local-config: "true"
+`
+
+// writeFunction writes an executable shell script to a temporary directory
+func writeFunction(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "function")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755))
+	return path
+}
+
+func TestExecFunction(t *testing.T) {
+	ctx := context.Background()
+	objects, err := manifestObjects(settingsClass(1, "1").Spec.Resources)
+	require.NoError(t, err)
+
+	t.Run("pipes a ResourceList through the executable", func(t *testing.T) {
+		dir := t.TempDir()
+		input := filepath.Join(dir, "input.json")
+		fn := &ExecFunction{Path: writeFunction(t, "/bin/cat > "+input+"\n/bin/cat "+input+"\n")}
+
+		out, err := fn.Run(ctx, objects, &runtime.RawExtension{Raw: []byte(`{"kind": "Settings"}`)})
+		require.NoError(t, err)
+		require.Len(t, out, 1)
+		assert.Equal(t, "settings", out[0].GetName())
+
+		var list resourceList
+		data, err := os.ReadFile(input)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &list))
+		assert.Equal(t, resourceListAPIVersion, list.APIVersion)
+		assert.Equal(t, resourceListKind, list.Kind)
+		assert.Len(t, list.Items, 1)
+		assert.JSONEq(t, `{"kind": "Settings"}`, string(list.FunctionConfig.Raw))
+	})
+
+	t.Run("drops local config and tracking annotations from YAML output", func(t *testing.T) {
+		fn := &ExecFunction{Path: writeFunction(t, "/bin/cat > /dev/null\n/bin/cat <<'EOF'\n"+generatorOutput+"EOF\n")}
+
+		out, err := fn.Run(ctx, objects, nil)
+		require.NoError(t, err)
+		require.Len(t, out, 1)
+		assert.Equal(t, "generated", out[0].GetName())
+		assert.Equal(t, placeholderNamespace, out[0].GetLabels()["owner"])
+		assert.Empty(t, out[0].GetAnnotations())
+		replicas, _, _ := unstructured.NestedString(out[0].Object, "data", "replicas")
+		assert.Equal(t, "3", replicas)
+	})
+
+	t.Run("rejects invalid config and output", func(t *testing.T) {
+		fn := &ExecFunction{Path: writeFunction(t, "/bin/cat > /dev/null\necho '{\"kind\": \"List\"}'\n")}
+
+		_, err := fn.Run(ctx, objects, &runtime.RawExtension{Raw: []byte(`["not", "an", "object"]`)})
+		require.Error(t, err)
+		assert.True(t, isPermanentFailure(err))
+
+		_, err = fn.Run(ctx, objects, nil)
+		require.Error(t, err)
+		assert.True(t, isPermanentFailure(err))
+		assert.Contains(t, err.Error(), "instead of a config.kubernetes.io/v1 ResourceList")
+	})
+
+	t.Run("reports error results as permanent failures", func(t *testing.T) {
+		fn := &ExecFunction{Path: writeFunction(t, "/bin/cat > /dev/null\n"+
+			`echo '{"apiVersion": "config.kubernetes.io/v1", "kind": "ResourceList", "items": [],`+
+			` "results": [{"message": "replicas must be set", "severity": "error"}]}'`+"\nexit 1\n")}
+
+		_, err := fn.Run(ctx, objects, nil)
+		require.Error(t, err)
+		assert.True(t, isPermanentFailure(err))
+		assert.Contains(t, err.Error(), "replicas must be set")
+	})
+
+	t.Run("retries failed runs and timeouts", func(t *testing.T) {
+		fn := &ExecFunction{Path: writeFunction(t, "echo 'out of memory' >&2\nexit 2\n")}
+		_, err := fn.Run(ctx, objects, nil)
+		require.Error(t, err)
+		assert.False(t, isPermanentFailure(err))
+		assert.Contains(t, err.Error(), "out of memory")
+
+		fn = &ExecFunction{Path: writeFunction(t, "exec /bin/sleep 10\n"), Timeout: 100 * time.Millisecond}
+		_, err = fn.Run(ctx, objects, nil)
+		require.Error(t, err)
+		assert.False(t, isPermanentFailure(err))
+		assert.Contains(t, err.Error(), "timed out")
+	})
+}
+
+func TestNewExecFunction(t *testing.T) {
+	_, err := NewExecFunction("function", time.Second)
+	assert.Error(t, err, "expected relative paths to be rejected")
+
+	path := filepath.Join(t.TempDir(), "function")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0o644))
+	_, err = NewExecFunction(path, time.Second)
+	assert.Error(t, err, "expected files that aren't executable to be rejected")
+
+	require.NoError(t, os.Chmod(path, 0o755))
+	fn, err := NewExecFunction(path, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, path, fn.Path)
+}
+
+func TestKRMRenderer(t *testing.T) {
+	ctx := context.Background()
+	class := settingsClass(1, "1")
+	class.Spec.Functions = []akuityv1alpha1.KRMFunction{{Name: "generate"}}
+
+	// Without a renderer that runs functions the class can't be rendered
+	parsed := parseClass(ctx, nil, class)
+	require.Error(t, parsed.err)
+	assert.True(t, isPermanentFailure(parsed.err))
+
+	// Only allowlisted functions run
+	renderer := &KRMRenderer{Functions: map[string]*ExecFunction{}}
+	parsed = parseClass(ctx, renderer, class)
+	require.Error(t, parsed.err)
+	assert.True(t, isPermanentFailure(parsed.err))
+	assert.Contains(t, parsed.err.Error(), `function "generate" is not allowlisted`)
+
+	// Common transforms apply to what functions output
+	renderer.Functions["generate"] = &ExecFunction{
+		Path: writeFunction(t, "/bin/cat > /dev/null\n/bin/cat <<'EOF'\n"+generatorOutput+"EOF\n"),
+	}
+	class.Spec.NamePrefix = "team-"
+	parsed = parseClass(ctx, renderer, class)
+	require.NoError(t, parsed.err)
+	require.Len(t, parsed.resources, 1)
+	assert.Equal(t, "team-generated", parsed.resources[0].Name)
+}
+
+func TestNamespaceClassBindingReconciler_KRMFunction(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "team-a", Namespace: "team-a"}}
+
+	class := settingsClass(1, "1")
+	class.Spec.Functions = []akuityv1alpha1.KRMFunction{{Name: "generate"}}
+	c := newRolloutTestClientBuilder(scheme).
+		WithObjects(class, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			}).
+		Build()
+	r := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10),
+		Renderer: &KRMRenderer{Functions: map[string]*ExecFunction{
+			"generate": {Path: writeFunction(t, "/bin/cat > /dev/null\n/bin/cat <<'EOF'\n"+generatorOutput+"EOF\n")},
+		}}}
+
+	// The function output replaces the class resources, with the namespace filled in
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	generated := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "generated", Namespace: "team-a"}, generated))
+	assert.Equal(t, "team-a", generated.Labels["owner"])
+	assert.Equal(t, "3", generated.Data["replicas"])
+	err = c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: "team-a"}, &corev1.ConfigMap{})
+	assert.Error(t, err, "expected the function to drop the inline resources")
+}
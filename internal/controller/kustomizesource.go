@@ -0,0 +1,253 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// kustomizeFilesRoot is where the files of a kustomization are placed in the build filesystem
+	kustomizeFilesRoot = "/files"
+
+	// kustomizeBuildRoot holds the kustomization the operator wraps around the class's kustomization
+	kustomizeBuildRoot = "/namespaceclass"
+
+	// namespaceConfigMapName is the local-config ConfigMap that carries the namespace placeholder
+	// into kustomize builds
+	namespaceConfigMapName = "namespaceclass-namespace"
+
+	// maxCachedKustomizeBuilds bounds how many kustomize builds are kept
+	maxCachedKustomizeBuilds = 32
+)
+
+// KustomizeBuilds keeps the output of kustomize builds by the files and settings they were built from.
+// One instance is shared by the reconcilers so every kustomization is built once.
+type KustomizeBuilds struct {
+	mu    sync.Mutex
+	built map[string][]runtime.RawExtension
+}
+
+// NewKustomizeBuilds returns an empty set of kustomize builds
+func NewKustomizeBuilds() *KustomizeBuilds {
+	return &KustomizeBuilds{built: map[string][]runtime.RawExtension{}}
+}
+
+// build returns the build of the kustomization at the source path among files, building it if the
+// same files weren't built with the same settings yet
+func (k *KustomizeBuilds) build(files map[string][]byte,
+	source *akuityv1alpha1.KustomizeSource) ([]runtime.RawExtension, error) {
+	key, err := kustomizeBuildKey(files, source)
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	resources, ok := k.built[key]
+	k.mu.Unlock()
+	if ok {
+		return resources, nil
+	}
+
+	resources, err = buildKustomization(files, source)
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.built) >= maxCachedKustomizeBuilds {
+		k.built = map[string][]runtime.RawExtension{}
+	}
+	k.built[key] = resources
+	return resources, nil
+}
+
+// kustomizeBuildKey hashes the files of a kustomize build in path order, along with the source path
+// and replacements
+func kustomizeBuildKey(files map[string][]byte, source *akuityv1alpha1.KustomizeSource) (string, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write(files[name])
+		h.Write([]byte{0})
+	}
+	settings, err := json.Marshal([]interface{}{source.Path, source.Replacements})
+	if err != nil {
+		return "", permanent(fmt.Errorf("replacements: %w", err))
+	}
+	h.Write(settings)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// kustomizeSourceResources builds the kustomization of a Kustomize source. Files from Git and OCI are
+// read at the revision recorded in the class status.
+func kustomizeSourceResources(ctx context.Context, c client.Reader, fetchers sourceFetchers,
+	class *akuityv1alpha1.NamespaceClass, index int, source *akuityv1alpha1.KustomizeSource) ([]runtime.RawExtension, error) {
+	var files map[string][]byte
+	var err error
+	switch {
+	case source.Git != nil:
+		if fetchers.git == nil {
+			return nil, permanent(stderrors.New("git sources are not enabled"))
+		}
+		var commit string
+		if commit, err = fetchedRevision(class, index, source.Git.URL, source.Git.Ref); err != nil {
+			return nil, err
+		}
+		var auth transport.AuthMethod
+		if auth, err = gitAuth(ctx, c, source.Git); err != nil {
+			return nil, err
+		}
+		files, err = fetchers.git.files(ctx, source.Git.URL, commit, source.Git.Path, auth)
+	case source.OCI != nil:
+		if fetchers.oci == nil {
+			return nil, permanent(stderrors.New("OCI sources are not enabled"))
+		}
+		var digest string
+		if digest, err = fetchedRevision(class, index, source.OCI.URL, ""); err != nil {
+			return nil, err
+		}
+		var auth authn.Authenticator
+		if auth, err = ociAuth(ctx, c, source.OCI); err != nil {
+			return nil, err
+		}
+		files, err = fetchers.oci.files(ctx, source.OCI, digest, auth)
+	default:
+		files, err = configMapFiles(ctx, c, source.ConfigMaps)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []runtime.RawExtension
+	if fetchers.kustomize != nil {
+		resources, err = fetchers.kustomize.build(files, source)
+	} else {
+		resources, err = buildKustomization(files, source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kustomize source: %w", err)
+	}
+	return resources, nil
+}
+
+// configMapFiles places the keys of every ConfigMap as files in its directory
+func configMapFiles(ctx context.Context, c client.Reader,
+	sources []akuityv1alpha1.KustomizeConfigMap) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for _, source := range sources {
+		cm, err := sourceConfigMap(ctx, c, source.Namespace, source.Name)
+		if err != nil {
+			return nil, err
+		}
+		for key, data := range cm.Data {
+			files[path.Join(source.Path, key)] = []byte(data)
+		}
+		for key, data := range cm.BinaryData {
+			files[path.Join(source.Path, key)] = data
+		}
+	}
+	return files, nil
+}
+
+// buildKustomization builds the kustomization at the source path among files. The build runs in
+// memory from a kustomization wrapped around the source's, which adds the namespace ConfigMap and the
+// source's replacements; local-config resources are left out of the output.
+func buildKustomization(files map[string][]byte, source *akuityv1alpha1.KustomizeSource) ([]runtime.RawExtension, error) {
+	fs := filesys.MakeFsInMemory()
+	for name, data := range files {
+		file := path.Join(kustomizeFilesRoot, path.Clean("/"+name))
+		if err := fs.MkdirAll(path.Dir(file)); err != nil {
+			return nil, err
+		}
+		if err := fs.WriteFile(file, data); err != nil {
+			return nil, err
+		}
+	}
+
+	replacements := make([]json.RawMessage, 0, len(source.Replacements))
+	for _, replacement := range source.Replacements {
+		replacements = append(replacements, replacement.Raw)
+	}
+	target := strings.TrimSuffix(path.Join(kustomizeFilesRoot, path.Clean("/"+source.Path)), "/")
+	wrapper, err := json.Marshal(map[string]interface{}{
+		"apiVersion":   "kustomize.config.k8s.io/v1beta1",
+		"kind":         "Kustomization",
+		"resources":    []string{"namespace.yaml", ".." + target},
+		"replacements": replacements,
+	})
+	if err != nil {
+		return nil, err
+	}
+	namespace, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":        namespaceConfigMapName,
+			"annotations": map[string]string{"config.kubernetes.io/local-config": "true"},
+		},
+		"data": map[string]string{"name": placeholderNamespace},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.MkdirAll(kustomizeBuildRoot); err != nil {
+		return nil, err
+	}
+	if err := fs.WriteFile(path.Join(kustomizeBuildRoot, "kustomization.yaml"), wrapper); err != nil {
+		return nil, err
+	}
+	if err := fs.WriteFile(path.Join(kustomizeBuildRoot, "namespace.yaml"), namespace); err != nil {
+		return nil, err
+	}
+
+	built, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fs, kustomizeBuildRoot)
+	if err != nil {
+		return nil, permanent(fmt.Errorf("build %s: %w", path.Clean("/"+source.Path), err))
+	}
+	out, err := built.AsYaml()
+	if err != nil {
+		return nil, permanent(err)
+	}
+	return splitYAMLDocuments(out)
+}
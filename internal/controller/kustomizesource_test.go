@@ -0,0 +1,205 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// Test kustomization files: a base with a limits ConfigMap and a prod overlay that patches it
+const (
+	kustomizeBase = "resources:\n- limits.yaml\n"
+
+	kustomizeLimits = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: limits\ndata:\n  cpu: \"1\"\n"
+
+	kustomizeOverlay = "resources:\n- ../../base\nnamePrefix: prod-\npatches:\n- patch: |\n" +
+		"    apiVersion: v1\n    kind: ConfigMap\n    metadata:\n      name: limits\n    data:\n      cpu: \"4\"\n"
+)
+
+// ownerReplacement copies the namespace into the owner label of every ConfigMap
+var ownerReplacement = runtime.RawExtension{Raw: []byte(`{
+	"source": {"kind": "ConfigMap", "name": "namespaceclass-namespace", "fieldPath": "data.name"},
+	"targets": [{
+		"select": {"kind": "ConfigMap"},
+		"fieldPaths": ["metadata.labels.owner"],
+		"options": {"create": true}
+	}]
+}`)}
+
+func TestBuildKustomization(t *testing.T) {
+	files := map[string][]byte{
+		"base/kustomization.yaml":          []byte(kustomizeBase),
+		"base/limits.yaml":                 []byte(kustomizeLimits),
+		"overlays/prod/kustomization.yaml": []byte(kustomizeOverlay),
+	}
+
+	// The overlay builds on the base; the namespace ConfigMap is used by replacements but not output
+	resources, err := buildKustomization(files, &akuityv1alpha1.KustomizeSource{
+		Path:         "overlays/prod",
+		Replacements: []runtime.RawExtension{ownerReplacement},
+	})
+	require.NoError(t, err)
+	parsed := parseClass(context.Background(), nil, &akuityv1alpha1.NamespaceClass{Spec: akuityv1alpha1.NamespaceClassSpec{Resources: resources}})
+	require.NoError(t, parsed.err)
+	require.Len(t, parsed.resources, 1)
+	limits := parsed.resources[0].Object
+	assert.Equal(t, "prod-limits", limits.GetName())
+	assert.Equal(t, placeholderNamespace, limits.GetLabels()["owner"])
+	assert.Equal(t, "4", limits.Object["data"].(map[string]interface{})["cpu"])
+
+	// Without a path the root kustomization is built, and there is none
+	_, err = buildKustomization(files, &akuityv1alpha1.KustomizeSource{})
+	require.Error(t, err)
+	assert.True(t, isPermanentFailure(err))
+}
+
+func TestKustomizeBuilds(t *testing.T) {
+	builds := NewKustomizeBuilds()
+	files := map[string][]byte{
+		"base/kustomization.yaml":          []byte(kustomizeBase),
+		"base/limits.yaml":                 []byte(kustomizeLimits),
+		"overlays/prod/kustomization.yaml": []byte(kustomizeOverlay),
+	}
+	source := &akuityv1alpha1.KustomizeSource{Path: "overlays/prod"}
+
+	// The same files with the same settings build once
+	first, err := builds.build(files, source)
+	require.NoError(t, err)
+	require.NotEmpty(t, first)
+	second, err := builds.build(files, source.DeepCopy())
+	require.NoError(t, err)
+	assert.Same(t, &first[0], &second[0])
+
+	// Other settings or changed files build again
+	other, err := builds.build(files, &akuityv1alpha1.KustomizeSource{Path: "base"})
+	require.NoError(t, err)
+	assert.NotSame(t, &first[0], &other[0])
+	files["base/limits.yaml"] = []byte(kustomizeLimits + "  memory: 1Gi\n")
+	other, err = builds.build(files, source)
+	require.NoError(t, err)
+	assert.NotSame(t, &first[0], &other[0])
+}
+
+func TestNamespaceClassBindingReconciler_KustomizeSource(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "team-a", Namespace: "team-a"}}
+
+	base := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "base", Namespace: "platform"},
+		Data:       map[string]string{"kustomization.yaml": kustomizeBase, "limits.yaml": kustomizeLimits},
+	}
+	overlay := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod", Namespace: "platform"},
+		Data:       map[string]string{"kustomization.yaml": kustomizeOverlay},
+	}
+	class := settingsClass(1, "1")
+	class.Spec.Sources = []akuityv1alpha1.ResourceSource{{Kustomize: &akuityv1alpha1.KustomizeSource{
+		ConfigMaps: []akuityv1alpha1.KustomizeConfigMap{
+			{Name: "base", Namespace: "platform", Path: "base"},
+			{Name: "prod", Namespace: "platform", Path: "overlays/prod"},
+		},
+		Path:         "overlays/prod",
+		Replacements: []runtime.RawExtension{ownerReplacement},
+	}}}
+	c := newRolloutTestClientBuilder(scheme).
+		WithIndex(&akuityv1alpha1.NamespaceClass{}, classSourcesField, indexClassSources).
+		WithObjects(class, base, overlay, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			}).
+		Build()
+	r := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+
+	// The overlay is applied with the namespace replaced into it
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	limits := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "prod-limits", Namespace: "team-a"}, limits))
+	assert.Equal(t, "4", limits.Data["cpu"])
+	assert.Equal(t, "team-a", limits.Labels["owner"])
+
+	// Every ConfigMap of the kustomization wakes up the bindings
+	assert.Equal(t, []ctrl.Request{req}, r.findBindingsForSource("ConfigMap")(ctx, base))
+	assert.Equal(t, []ctrl.Request{req}, r.findBindingsForSource("ConfigMap")(ctx, overlay))
+
+	// Changing the overlay changes the output without a new generation
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(overlay), overlay))
+	overlay.Data["kustomization.yaml"] = "resources:\n- ../../base\nnamePrefix: prod-\n"
+	require.NoError(t, c.Update(ctx, overlay))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "prod-limits", Namespace: "team-a"}, limits))
+	assert.Equal(t, "1", limits.Data["cpu"])
+}
+
+func TestNamespaceClassReconciler_KustomizeGitSource(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	origin := newTestGitRepo(t)
+	commit := origin.commit(map[string]string{
+		"classes/base/kustomization.yaml":          kustomizeBase,
+		"classes/base/limits.yaml":                 kustomizeLimits,
+		"classes/overlays/prod/kustomization.yaml": kustomizeOverlay,
+	})
+
+	class := settingsClass(1, "1")
+	class.Spec.Sources = []akuityv1alpha1.ResourceSource{{Kustomize: &akuityv1alpha1.KustomizeSource{
+		Git:  &akuityv1alpha1.GitSource{URL: origin.bare, Path: "classes"},
+		Path: "overlays/prod",
+	}}}
+	c := newRolloutTestClientBuilder(scheme).
+		WithObjects(class, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			}).
+		Build()
+	repos := NewGitRepositories()
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10),
+		Git: repos}
+	bindingReconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme,
+		Recorder: record.NewFakeRecorder(10), Git: repos}
+
+	// The repository of the kustomization is polled like a Git source
+	_, err := classReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-class"}})
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(class), class))
+	require.Len(t, class.Status.Sources, 1)
+	assert.Equal(t, commit, class.Status.Sources[0].Revision)
+
+	_, err = bindingReconciler.Reconcile(ctx,
+		ctrl.Request{NamespacedName: types.NamespacedName{Name: "team-a", Namespace: "team-a"}})
+	require.NoError(t, err)
+	limits := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "prod-limits", Namespace: "team-a"}, limits))
+	assert.Equal(t, "4", limits.Data["cpu"])
+}
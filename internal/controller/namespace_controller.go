@@ -28,6 +28,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -48,9 +49,41 @@ type NamespaceReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+
+	// Git reads the Git sources of classes to work out the impact of a class switch
+	Git *GitRepositories
+
+	// OCI reads the OCI artifact sources of classes to work out the impact of a class switch
+	OCI *OCIArtifacts
+
+	// Helm downloads the charts of Helm sources to work out the impact of a class switch
+	Helm *HelmCharts
+
+	// Kustomize keeps the builds of Kustomize sources; without it they are built on every read
+	Kustomize *KustomizeBuilds
+
+	// SecretNamespace is the only namespace the credentials Secrets of class sources are read from,
+	// usually the operator's own. Without it sources can't use credentials.
+	SecretNamespace string
+
+	// SourceCache serves the ConfigMaps and Secrets class sources read, see NewSourceCache. Defaults to
+	// the manager's cache, or the client outside a manager.
+	SourceCache cache.Cache
+
+	// Renderer renders classes to work out the impact of a class switch. Defaults to the resources
+	// as written.
+	Renderer Renderer
 }
 
-// Reconcile manages NamespaceClassBindings based on namespace labels
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=akuity.io,resources=namespaceclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=akuity.io,resources=namespaceclassrevisions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=akuity.io,resources=namespaceclassfragments,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// Reconcile manages NamespaceClassBindings based on namespace labels. A class switch that would delete
+// resources waits for confirmation unless the class being left is safe to switch from.
 func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx).WithValues("namespace", req.Name)
 
@@ -128,13 +161,38 @@ func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, nil
 	}
 
-	// If the class has changed, update the binding
+	// If the class has changed, update the binding once the impact of the switch is acceptable
 	if desiredClass != "" && bindingExists && binding.Spec.ClassName != desiredClass {
+		impact, err := r.computeSwitchImpact(ctx, binding, desiredClass)
+		if err != nil {
+			logger.Error(err, "failed to compute class switch impact")
+			return ctrl.Result{}, err
+		}
+		held, err := r.switchHeld(ctx, namespace, impact)
+		if err != nil {
+			logger.Error(err, "failed to check class switch")
+			return ctrl.Result{}, err
+		}
+		if held {
+			logger.Info("holding class switch until it is confirmed", "oldClass", binding.Spec.ClassName,
+				"newClass", desiredClass, "deletes", len(impact.Delete))
+			if err := r.publishHeldSwitch(ctx, namespace, impact); err != nil {
+				logger.Error(err, "failed to publish class switch impact")
+				return ctrl.Result{}, err
+			}
+			if impact.Unknown != "" {
+				// Nothing brings the namespace back once the new class content can be read
+				return ctrl.Result{RequeueAfter: switchRetryInterval}, nil
+			}
+			return ctrl.Result{}, nil
+		}
+
 		logger.Info("updating NamespaceClassBinding", "oldClass",
 			binding.Spec.ClassName, "newClass", desiredClass)
 
-		// Update the binding
+		// Update the binding; a pinned revision belongs to the old class
 		binding.Spec.ClassName = desiredClass
+		binding.Spec.Revision = ""
 		if err := r.Update(ctx, binding); err != nil {
 			logger.Error(err, "failed to update NamespaceClassBinding",
 				"NamespaceClassBinding", bindingKey)
@@ -143,6 +201,14 @@ func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 		r.Recorder.Event(namespace, corev1.EventTypeNormal, "BindingUpdated",
 			fmt.Sprintf("Updated NamespaceClassBinding to class %s", desiredClass))
+		if impact != nil && len(impact.Delete)+len(impact.Change)+len(impact.Add) > 0 {
+			r.Recorder.Event(namespace, corev1.EventTypeNormal, reasonClassSwitchImpact, impact.String())
+		}
+
+		if err := r.clearSwitchAnnotations(ctx, namespace); err != nil {
+			logger.Error(err, "failed to clear class switch annotations")
+			return ctrl.Result{}, err
+		}
 		return ctrl.Result{}, nil
 	}
 
@@ -153,6 +219,9 @@ func (r *NamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 // SetupWithManager sets up the controller with the Manager.
 func (r *NamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Recorder = mgr.GetEventRecorderFor(namespaceControllerName)
+	if r.SourceCache == nil {
+		r.SourceCache = mgr.GetCache()
+	}
 
 	// Only reconcile when our class label changes (or is present on create)
 	nsPred := predicate.Funcs{
@@ -163,7 +232,10 @@ func (r *NamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		UpdateFunc: func(e event.UpdateEvent) bool {
 			oldLbl := e.ObjectOld.GetLabels()[labelNamespaceClass]
 			newLbl := e.ObjectNew.GetLabels()[labelNamespaceClass]
-			return oldLbl != newLbl
+			// A confirmation releases a held class switch
+			oldConfirm := e.ObjectOld.GetAnnotations()[annotationConfirmSwitch]
+			newConfirm := e.ObjectNew.GetAnnotations()[annotationConfirmSwitch]
+			return oldLbl != newLbl || oldConfirm != newConfirm
 		},
 		DeleteFunc:  func(event.DeleteEvent) bool { return false },
 		GenericFunc: func(event.GenericEvent) bool { return false },
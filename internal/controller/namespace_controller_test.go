@@ -18,6 +18,7 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -368,3 +369,169 @@ func (e *errorClient) Delete(ctx context.Context, obj client.Object, opts ...cli
 	}
 	return e.Client.Delete(ctx, obj, opts...)
 }
+
+func TestNamespaceReconciler_ClassSwitchConfirmation(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, akuityv1alpha1.AddToScheme(scheme))
+
+	newClass := func(name, data string, extra ...string) *akuityv1alpha1.NamespaceClass {
+		class := &akuityv1alpha1.NamespaceClass{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		for _, raw := range append([]string{`{"apiVersion": "v1", "kind": "ConfigMap",
+			"metadata": {"name": "settings"}, "data": {"tier": "` + data + `"}}`}, extra...) {
+			class.Spec.Resources = append(class.Spec.Resources, runtime.RawExtension{Raw: []byte(raw)})
+		}
+		return class
+	}
+
+	for _, safe := range []bool{false, true} {
+		t.Run(fmt.Sprintf("safeToSwitch=%v", safe), func(t *testing.T) {
+			ctx := context.Background()
+			dev := newClass("dev", "dev", `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "dev-only"}}`)
+			dev.Spec.SafeToSwitch = safe
+			prod := newClass("prod", "prod")
+			revision := &akuityv1alpha1.NamespaceClassRevision{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev-1"},
+				Spec:       akuityv1alpha1.NamespaceClassRevisionSpec{ClassName: "dev", Generation: 1, Class: dev.Spec},
+			}
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:   "test-ns",
+				Labels: map[string]string{labelNamespaceClass: "prod"},
+			}}
+			binding := &akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-ns", Namespace: "test-ns"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "dev"},
+				Status: akuityv1alpha1.NamespaceClassBindingStatus{
+					ObservedClassName:       "dev",
+					ObservedClassGeneration: 1,
+					ObservedRevision:        "dev-1",
+					AppliedResources: []akuityv1alpha1.AppliedResource{
+						{APIVersion: "v1", Kind: "ConfigMap", Name: "settings"},
+						{APIVersion: "v1", Kind: "Secret", Name: "dev-only"},
+					},
+				},
+			}
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(dev, prod, revision, namespace, binding).
+				Build()
+			recorder := record.NewFakeRecorder(10)
+			r := &NamespaceReconciler{Client: c, Scheme: scheme, Recorder: recorder}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-ns"}}
+			bindingKey := client.ObjectKeyFromObject(binding)
+
+			_, err := r.Reconcile(ctx, req)
+			require.NoError(t, err)
+
+			if safe {
+				// Classes that are safe to switch from let the switch go ahead right away
+				require.NoError(t, c.Get(ctx, bindingKey, binding))
+				assert.Equal(t, "prod", binding.Spec.ClassName)
+				assert.Contains(t, <-recorder.Events, "BindingUpdated")
+				assert.Contains(t, <-recorder.Events, reasonClassSwitchImpact)
+				return
+			}
+
+			// The switch is held and its impact published
+			require.NoError(t, c.Get(ctx, bindingKey, binding))
+			assert.Equal(t, "dev", binding.Spec.ClassName)
+			require.NoError(t, c.Get(ctx, req.NamespacedName, namespace))
+			assert.JSONEq(t, `{"from": "dev", "to": "prod", "delete": ["Secret/dev-only"],
+				"change": ["ConfigMap/settings"]}`, namespace.Annotations[annotationSwitchImpact])
+			event := <-recorder.Events
+			assert.Contains(t, event, reasonClassSwitchHeld)
+			assert.Contains(t, event, "Secret/dev-only")
+
+			// The same impact isn't reported twice
+			_, err = r.Reconcile(ctx, req)
+			require.NoError(t, err)
+			select {
+			case event := <-recorder.Events:
+				t.Errorf("expected no event, but got: %s", event)
+			default:
+			}
+
+			// A confirmation for another class doesn't release the switch
+			namespace.Annotations[annotationConfirmSwitch] = "staging"
+			require.NoError(t, c.Update(ctx, namespace))
+			_, err = r.Reconcile(ctx, req)
+			require.NoError(t, err)
+			require.NoError(t, c.Get(ctx, bindingKey, binding))
+			assert.Equal(t, "dev", binding.Spec.ClassName)
+
+			namespace.Annotations[annotationConfirmSwitch] = "prod"
+			require.NoError(t, c.Update(ctx, namespace))
+			_, err = r.Reconcile(ctx, req)
+			require.NoError(t, err)
+			require.NoError(t, c.Get(ctx, bindingKey, binding))
+			assert.Equal(t, "prod", binding.Spec.ClassName)
+			require.NoError(t, c.Get(ctx, req.NamespacedName, namespace))
+			assert.NotContains(t, namespace.Annotations, annotationSwitchImpact)
+			assert.NotContains(t, namespace.Annotations, annotationConfirmSwitch)
+		})
+	}
+}
+
+func TestNamespaceReconciler_ClassSwitchUnknownContent(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, akuityv1alpha1.AddToScheme(scheme))
+
+	// The new class reads a Git source that hasn't been fetched yet; the class being left is safe
+	// to switch from, which doesn't matter when the new content is unknown
+	dev := &akuityv1alpha1.NamespaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev"},
+		Spec:       akuityv1alpha1.NamespaceClassSpec{SafeToSwitch: true},
+	}
+	prod := &akuityv1alpha1.NamespaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+		Spec: akuityv1alpha1.NamespaceClassSpec{Sources: []akuityv1alpha1.ResourceSource{
+			{Git: &akuityv1alpha1.GitSource{URL: "https://example.com/classes.git"}},
+		}},
+	}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "test-ns",
+		Labels: map[string]string{labelNamespaceClass: "prod"},
+	}}
+	binding := &akuityv1alpha1.NamespaceClassBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ns", Namespace: "test-ns"},
+		Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "dev"},
+		Status: akuityv1alpha1.NamespaceClassBindingStatus{
+			ObservedClassName: "dev",
+			AppliedResources:  []akuityv1alpha1.AppliedResource{{APIVersion: "v1", Kind: "ConfigMap", Name: "settings"}},
+		},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(dev, prod, namespace, binding).
+		Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &NamespaceReconciler{Client: c, Scheme: scheme, Recorder: recorder, Git: NewGitRepositories()}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-ns"}}
+	bindingKey := client.ObjectKeyFromObject(binding)
+
+	// The switch is held and checked again later
+	result, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, switchRetryInterval, result.RequeueAfter)
+	require.NoError(t, c.Get(ctx, bindingKey, binding))
+	assert.Equal(t, "dev", binding.Spec.ClassName)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, namespace))
+	impact := &switchImpact{}
+	require.NoError(t, json.Unmarshal([]byte(namespace.Annotations[annotationSwitchImpact]), impact))
+	assert.Equal(t, "prod", impact.To)
+	assert.Contains(t, impact.Unknown, "has not been fetched yet")
+	event := <-recorder.Events
+	assert.Contains(t, event, reasonClassSwitchHeld)
+	assert.Contains(t, event, "unknown")
+
+	// A confirmation releases it
+	namespace.Annotations[annotationConfirmSwitch] = "prod"
+	require.NoError(t, c.Update(ctx, namespace))
+	result, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	require.NoError(t, c.Get(ctx, bindingKey, binding))
+	assert.Equal(t, "prod", binding.Spec.ClassName)
+}
@@ -0,0 +1,490 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// classControllerName is the name of this controller
+	classControllerName = "namespaceclass-controller"
+)
+
+// NamespaceClassReconciler rolls out NamespaceClass changes to bound namespaces. It relies on the
+// spec.className binding index and the class sources index registered by
+// NamespaceClassBindingReconciler.
+type NamespaceClassReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// Git fetches the Git sources of classes and resolves the commits bindings apply
+	Git *GitRepositories
+
+	// OCI resolves the OCI artifact sources of classes to the digests bindings apply
+	OCI *OCIArtifacts
+
+	// Helm resolves the repository and OCI charts of Helm sources to the versions and digests
+	// bindings render
+	Helm *HelmCharts
+
+	// Kustomize keeps the builds of Kustomize sources; without it they are built on every read
+	Kustomize *KustomizeBuilds
+
+	// SecretNamespace is the only namespace the credentials Secrets of class sources are read from,
+	// usually the operator's own. Without it sources can't use credentials.
+	SecretNamespace string
+
+	// SourceCache serves the ConfigMaps and Secrets class sources read, see NewSourceCache. Defaults to
+	// the manager's cache, or the client outside a manager.
+	SourceCache cache.Cache
+
+	// Renderer renders classes for plans and canary validation. Defaults to the resources as written.
+	Renderer Renderer
+}
+
+// +kubebuilder:rbac:groups=akuity.io,resources=namespaceclasses,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=akuity.io,resources=namespaceclasses/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=akuity.io,resources=namespaceclassbindings,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=akuity.io,resources=namespaceclassrevisions,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=akuity.io,resources=namespaceclassrevisions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=akuity.io,resources=namespaceclassplans,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=akuity.io,resources=namespaceclassfragments,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile admits the bindings of a class to its current generation according to its rollout strategy
+func (r *NamespaceClassReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("class", req.Name)
+
+	// Fetch the NamespaceClass
+	class := &akuityv1alpha1.NamespaceClass{}
+	if err := r.Get(ctx, req.NamespacedName, class); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		logger.Error(err, "unable to fetch NamespaceClass")
+		return ctrl.Result{}, err
+	}
+
+	if !class.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Fetched sources are polled here; bindings apply the revisions recorded in the class status
+	sourcesDue, err := r.syncSources(ctx, class)
+	if err != nil {
+		logger.Error(err, "failed to fetch class sources")
+		return ctrl.Result{}, err
+	}
+
+	// Rollouts admit bindings to the content of the sources along with the generation, so changed
+	// source content is throttled and held like a spec change
+	resolved, err := resolveSources(ctx, sourceReader(r, r.SourceCache, r.SecretNamespace),
+		sourceFetchers{git: r.Git, oci: r.OCI, helm: r.Helm, kustomize: r.Kustomize}, class)
+	if err != nil {
+		if !isPermanentFailure(err) {
+			logger.Error(err, "failed to read class sources")
+			return ctrl.Result{}, err
+		}
+		// The bindings report the missing source as well; the source watches bring the class back
+		return r.haltRollout(ctx, class, sourcesDue, fmt.Sprintf("class sources can't be read: %v", err))
+	}
+
+	return r.handleRollout(ctx, class, resolved, sourcesDue)
+}
+
+// haltRollout reports a rollout that can't go on until the class or its sources change
+func (r *NamespaceClassReconciler) haltRollout(ctx context.Context, class *akuityv1alpha1.NamespaceClass,
+	sourcesDue time.Duration, message string) (ctrl.Result, error) {
+	return ctrl.Result{RequeueAfter: sourcesDue}, r.patchClassStatus(ctx, class, func(c *akuityv1alpha1.NamespaceClass) {
+		meta.SetStatusCondition(&c.Status.Conditions, metav1.Condition{
+			Type:               akuityv1alpha1.ClassConditionRolloutProgressing,
+			Status:             metav1.ConditionTrue,
+			Reason:             reasonRolloutHalted,
+			Message:            message,
+			ObservedGeneration: c.Generation,
+		})
+	})
+}
+
+// handleRollout plans the rollout of the resolved class content, admits the bindings the plan allows
+// and records the progress in the class status
+func (r *NamespaceClassReconciler) handleRollout(ctx context.Context, class, resolved *akuityv1alpha1.NamespaceClass,
+	sourcesDue time.Duration) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	// Every generation is kept as a revision bindings can be pinned or rolled back to, with the content
+	// of its sources
+	if err := r.ensureRevision(ctx, class, resolved); err != nil {
+		logger.Error(err, "failed to record class revision")
+		return ctrl.Result{}, err
+	}
+
+	// A resume only applies to the rollout it was given for
+	if err := r.clearStaleResume(ctx, class, resolved); err != nil {
+		logger.Error(err, "failed to clear resume annotation")
+		return ctrl.Result{}, err
+	}
+
+	var bindings akuityv1alpha1.NamespaceClassBindingList
+	if err := r.List(ctx, &bindings, client.MatchingFields{"spec.className": class.Name}); err != nil {
+		logger.Error(err, "failed to list bindings")
+		return ctrl.Result{}, err
+	}
+
+	// Bindings pinned to a revision don't take part in rollouts
+	following := followingBindings(bindings.Items)
+
+	namespaceLabels, err := r.namespaceLabels(ctx, following)
+	if err != nil {
+		logger.Error(err, "failed to get bound namespaces")
+		return ctrl.Result{}, err
+	}
+
+	plan, err := planRollout(resolved, following, namespaceLabels)
+	if err != nil {
+		logger.Error(err, "invalid rollout strategy")
+		return r.haltRollout(ctx, class, sourcesDue, fmt.Sprintf("invalid rollout strategy: %v", err))
+	}
+
+	rollbackRevision, err := r.rollBackIfAborted(ctx, class, resolved, following, &plan)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Content the API server rejects in the canary namespaces doesn't reach anyone else
+	validated, err := r.validateClass(ctx, resolved)
+	if err != nil {
+		return r.handleValidationError(ctx, class, err)
+	}
+	if validated != nil && validated.Status != metav1.ConditionTrue {
+		plan.admit = nil
+		plan.invalid = true
+	}
+
+	planStatus, err := r.holdForApproval(ctx, class, resolved, following, &plan)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	for _, binding := range plan.admit {
+		if err := r.admitBinding(ctx, binding, resolved); err != nil {
+			logger.Error(err, "failed to admit binding", "binding", client.ObjectKeyFromObject(binding))
+			return ctrl.Result{}, err
+		}
+		logger.Info("admitted binding to class generation", "namespace", binding.Namespace,
+			"generation", class.Generation, "batch", plan.status.CurrentBatch)
+	}
+
+	// A generation every binding applied cleanly becomes the one to roll back to
+	knownGood := class.Status.LastKnownGoodRevision
+	if !plan.status.Aborted && plan.status.Unavailable == 0 && plan.status.Updated == plan.status.Total {
+		knownGood = revisionName(class.Name, class.Generation)
+	}
+
+	if err := r.syncRevisions(ctx, class, bindings.Items, knownGood, rollbackRevision); err != nil {
+		logger.Error(err, "failed to sync class revisions")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.updateRolloutStatus(ctx, class, plan, knownGood, validated, planStatus); err != nil {
+		logger.Error(err, "failed to update class status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: sourcesDue}, nil
+}
+
+// rollBackIfAborted rolls the bindings back to the known-good revision once too many of them went
+// Degraded, and keeps them there until the class changes. It returns the revision rolled back to, if any.
+func (r *NamespaceClassReconciler) rollBackIfAborted(ctx context.Context,
+	class, resolved *akuityv1alpha1.NamespaceClass, following []akuityv1alpha1.NamespaceClassBinding,
+	plan *rolloutPlan) (string, error) {
+	logger := log.FromContext(ctx)
+
+	rollbackRevision := ""
+	if rollbackActive(class) {
+		rollbackRevision = class.Status.Rollout.RollbackRevision
+	} else if abort, err := shouldAbort(resolved, *plan); err != nil {
+		logger.Error(err, "invalid rollout strategy")
+	} else if abort && class.Status.LastKnownGoodRevision != "" &&
+		class.Status.LastKnownGoodRevision != revisionName(class.Name, class.Generation) {
+		rollbackRevision = class.Status.LastKnownGoodRevision
+	}
+	if rollbackRevision == "" {
+		return "", nil
+	}
+
+	plan.admit = nil
+	plan.status.Aborted = true
+	plan.status.RollbackRevision = rollbackRevision
+
+	revision := &akuityv1alpha1.NamespaceClassRevision{}
+	if err := r.Get(ctx, types.NamespacedName{Name: rollbackRevision}, revision); err != nil {
+		logger.Error(err, "failed to get rollback revision", "revision", rollbackRevision)
+		return "", err
+	}
+	if err := r.rollBack(ctx, resolved, following, revision); err != nil {
+		logger.Error(err, "failed to roll back bindings")
+		return "", err
+	}
+	return rollbackRevision, nil
+}
+
+// handleValidationError reports that the class content couldn't be validated, and retries
+func (r *NamespaceClassReconciler) handleValidationError(ctx context.Context, class *akuityv1alpha1.NamespaceClass,
+	err error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.Error(err, "failed to validate class")
+	if patchErr := r.patchClassStatus(ctx, class, func(c *akuityv1alpha1.NamespaceClass) {
+		meta.SetStatusCondition(&c.Status.Conditions, validationPending(c, err))
+	}); patchErr != nil {
+		logger.Error(patchErr, "failed to update class status")
+	}
+	return ctrl.Result{}, err
+}
+
+// holdForApproval holds the change of classes that require approval until its plan is approved. It
+// returns the status of the plan, or nil when the class doesn't require approval.
+func (r *NamespaceClassReconciler) holdForApproval(ctx context.Context, class, resolved *akuityv1alpha1.NamespaceClass,
+	following []akuityv1alpha1.NamespaceClassBinding, plan *rolloutPlan) (*akuityv1alpha1.PlanStatus, error) {
+	logger := log.FromContext(ctx)
+
+	if !class.Spec.RequireApproval || plan.invalid {
+		if err := r.prunePlans(ctx, class, 0, ""); err != nil {
+			logger.Error(err, "failed to delete plans")
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	planStatus, err := r.approvalStatus(ctx, resolved, following)
+	if err != nil {
+		logger.Error(err, "failed to plan class change")
+		return nil, err
+	}
+	if planStatus == nil || !planStatus.Approved {
+		plan.admit = nil
+		plan.awaitingApproval = true
+	}
+	return planStatus, nil
+}
+
+// updateRolloutStatus records the rollout progress in the class status, and reports the rollout
+// states worth an event when the rollout enters them
+func (r *NamespaceClassReconciler) updateRolloutStatus(ctx context.Context, class *akuityv1alpha1.NamespaceClass,
+	plan rolloutPlan, knownGood string, validated *metav1.Condition, planStatus *akuityv1alpha1.PlanStatus) error {
+	cond := rolloutCondition(class, plan)
+	previousReason := ""
+	if previous := meta.FindStatusCondition(class.Status.Conditions,
+		akuityv1alpha1.ClassConditionRolloutProgressing); previous != nil {
+		previousReason = previous.Reason
+	}
+	if err := r.patchClassStatus(ctx, class, func(c *akuityv1alpha1.NamespaceClass) {
+		status := plan.status
+		c.Status.Rollout = &status
+		c.Status.LastKnownGoodRevision = knownGood
+		c.Status.Plan = planStatus
+		if validated != nil {
+			meta.SetStatusCondition(&c.Status.Conditions, *validated)
+		} else {
+			meta.RemoveStatusCondition(&c.Status.Conditions, akuityv1alpha1.ClassConditionValidated)
+		}
+		meta.SetStatusCondition(&c.Status.Conditions, cond)
+		if status.Aborted {
+			meta.SetStatusCondition(&c.Status.Conditions, metav1.Condition{
+				Type:               akuityv1alpha1.ClassConditionRolloutAborted,
+				Status:             metav1.ConditionTrue,
+				Reason:             reasonRolloutAborted,
+				Message:            cond.Message,
+				ObservedGeneration: c.Generation,
+			})
+		} else {
+			meta.RemoveStatusCondition(&c.Status.Conditions, akuityv1alpha1.ClassConditionRolloutAborted)
+		}
+	}); err != nil {
+		return err
+	}
+
+	if previousReason != cond.Reason {
+		switch cond.Reason {
+		case reasonRolloutPaused, reasonRolloutHalted, reasonRolloutAborted, reasonValidationFailed:
+			r.Recorder.Event(class, corev1.EventTypeWarning, cond.Reason, cond.Message)
+		case reasonAwaitingApproval:
+			r.Recorder.Event(class, corev1.EventTypeNormal, cond.Reason, cond.Message)
+		case reasonRolloutComplete:
+			if previousReason != "" {
+				r.Recorder.Event(class, corev1.EventTypeNormal, cond.Reason, cond.Message)
+			}
+		}
+	}
+	return nil
+}
+
+// syncRevisions prunes revisions beyond the history limit and records the comparisons asked for on
+// revisions. The current, last-known-good and rollback revisions and pinned revisions are kept.
+func (r *NamespaceClassReconciler) syncRevisions(ctx context.Context, class *akuityv1alpha1.NamespaceClass,
+	bindings []akuityv1alpha1.NamespaceClassBinding, knownGood, rollbackRevision string) error {
+	var revisions akuityv1alpha1.NamespaceClassRevisionList
+	if err := r.List(ctx, &revisions, client.MatchingLabels{labelRevisionClass: class.Name}); err != nil {
+		return fmt.Errorf("list revisions: %w", err)
+	}
+
+	keep := map[string]bool{
+		revisionName(class.Name, class.Generation): true,
+		knownGood:        true,
+		rollbackRevision: true,
+	}
+	for i := range bindings {
+		if pinned := pinnedRevision(&bindings[i]); pinned != "" {
+			keep[pinned] = true
+		}
+	}
+	if err := r.pruneRevisions(ctx, class, revisions.Items, keep); err != nil {
+		return err
+	}
+
+	return r.compareRevisions(ctx, revisions.Items)
+}
+
+// clearStaleResume removes a resume annotation left over from the rollout of earlier class content,
+// from the class and its resolved copy
+func (r *NamespaceClassReconciler) clearStaleResume(ctx context.Context,
+	class, resolved *akuityv1alpha1.NamespaceClass) error {
+	rollout := class.Status.Rollout
+	if _, ok := class.Annotations[annotationResume]; !ok || rollout == nil ||
+		(rollout.Generation == class.Generation && rollout.SourcesHash == sourcesHash(resolved)) {
+		return nil
+	}
+
+	base := class.DeepCopy()
+	delete(class.Annotations, annotationResume)
+	delete(resolved.Annotations, annotationResume)
+	return r.Patch(ctx, class, client.MergeFrom(base))
+}
+
+// namespaceLabels returns the labels of every bound namespace
+func (r *NamespaceClassReconciler) namespaceLabels(ctx context.Context,
+	bindings []akuityv1alpha1.NamespaceClassBinding) (map[string]labels.Set, error) {
+	result := make(map[string]labels.Set, len(bindings))
+	for _, binding := range bindings {
+		namespace := &corev1.Namespace{}
+		if err := r.Get(ctx, types.NamespacedName{Name: binding.Namespace}, namespace); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("get namespace %s: %w", binding.Namespace, err)
+		}
+		result[binding.Namespace] = namespace.Labels
+	}
+	return result, nil
+}
+
+// admitBinding lets the binding apply the current content of the resolved class
+func (r *NamespaceClassReconciler) admitBinding(ctx context.Context, binding *akuityv1alpha1.NamespaceClassBinding,
+	class *akuityv1alpha1.NamespaceClass) error {
+	base := binding.DeepCopy()
+	if binding.Annotations == nil {
+		binding.Annotations = map[string]string{}
+	}
+	binding.Annotations[annotationRolloutGeneration] = rolloutKey(class)
+	return r.Patch(ctx, binding, client.MergeFrom(base))
+}
+
+// patchClassStatus applies mutate to the class status and patches it
+func (r *NamespaceClassReconciler) patchClassStatus(ctx context.Context, class *akuityv1alpha1.NamespaceClass,
+	mutate func(*akuityv1alpha1.NamespaceClass)) error {
+	base := class.DeepCopy()
+	mutate(class)
+	return r.Status().Patch(ctx, class, client.MergeFrom(base))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NamespaceClassReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Recorder = mgr.GetEventRecorderFor(classControllerName)
+	if r.SourceCache == nil {
+		r.SourceCache = mgr.GetCache()
+	}
+
+	// Binding status changes move the rollout forward; revision annotations ask for comparisons; source
+	// changes start a new rollout; created canary namespaces let a held generation be validated
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: 2,
+		}).
+		For(&akuityv1alpha1.NamespaceClass{}).
+		Owns(&akuityv1alpha1.NamespaceClassRevision{}).
+		Owns(&akuityv1alpha1.NamespaceClassPlan{}).
+		Watches(
+			&akuityv1alpha1.NamespaceClassBinding{},
+			handler.EnqueueRequestsFromMapFunc(findClassForBinding),
+			builder.WithPredicates(bindingRolloutChanged),
+		).
+		WatchesRawSource(source.Kind[client.Object](r.SourceCache, &corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.findClassesForSource("ConfigMap")))).
+		Watches(
+			&akuityv1alpha1.NamespaceClassFragment{},
+			handler.EnqueueRequestsFromMapFunc(r.findClassesForSource("NamespaceClassFragment")),
+		).
+		Watches(
+			&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(r.findClassesForCanary),
+			builder.WithPredicates(predicate.Funcs{
+				UpdateFunc:  func(event.UpdateEvent) bool { return false },
+				DeleteFunc:  func(event.DeleteEvent) bool { return false },
+				GenericFunc: func(event.GenericEvent) bool { return false },
+			}),
+		).
+		Complete(r)
+}
+
+// findClassForBinding returns a reconcile request for the class a binding references
+func findClassForBinding(_ context.Context, obj client.Object) []reconcile.Request {
+	binding := obj.(*akuityv1alpha1.NamespaceClassBinding)
+	if binding.Spec.ClassName == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: binding.Spec.ClassName}}}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// newRolloutTestClientBuilder returns a fake client builder set up for rollout tests
+func newRolloutTestClientBuilder(scheme *runtime.Scheme) *fake.ClientBuilder {
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(newTestRESTMapper()).
+		WithStatusSubresource(&akuityv1alpha1.NamespaceClassBinding{}, &akuityv1alpha1.NamespaceClass{},
+			&akuityv1alpha1.NamespaceClassRevision{}).
+		WithIndex(&akuityv1alpha1.NamespaceClassBinding{}, "spec.className", func(obj client.Object) []string {
+			return []string{obj.(*akuityv1alpha1.NamespaceClassBinding).Spec.ClassName}
+		})
+}
+
+// reconcileRollout reconciles test-class, then the bindings of the given namespaces, then the class
+// again, and returns the updated class
+func reconcileRollout(t *testing.T, c client.Client, classReconciler *NamespaceClassReconciler,
+	bindingReconciler *NamespaceClassBindingReconciler, namespaces ...string) *akuityv1alpha1.NamespaceClass {
+	t.Helper()
+	ctx := context.Background()
+	classReq := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-class"}}
+
+	_, err := classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+	for _, name := range namespaces {
+		_, err := bindingReconciler.Reconcile(ctx, ctrl.Request{
+			NamespacedName: types.NamespacedName{Name: name, Namespace: name},
+		})
+		require.NoError(t, err)
+	}
+	_, err = classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+
+	current := &akuityv1alpha1.NamespaceClass{}
+	require.NoError(t, c.Get(ctx, classReq.NamespacedName, current))
+	require.NotNil(t, current.Status.Rollout)
+	return current
+}
+
+func TestNamespaceClassReconciler_Rollout(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+
+	class := newRolloutClass()
+	class.Spec.Resources = []runtime.RawExtension{
+		{Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings"}}`)},
+	}
+	objs := []client.Object{class}
+	for _, ns := range []struct{ name, tier string }{{"canary-1", "canary"}, {"prod-1", "prod"}, {"prod-2", "prod"}} {
+		binding := newRolloutBinding(ns.name, 1)
+		objs = append(objs,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns.name, Labels: map[string]string{"tier": ns.tier}}},
+			&binding,
+		)
+	}
+
+	c := newRolloutTestClientBuilder(scheme).WithObjects(objs...).Build()
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+	bindingReconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(30)}
+
+	step := func() *akuityv1alpha1.NamespaceClass {
+		return reconcileRollout(t, c, classReconciler, bindingReconciler, "canary-1", "prod-1", "prod-2")
+	}
+	applied := func(namespace string) bool {
+		err := c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: namespace}, &corev1.ConfigMap{})
+		return err == nil
+	}
+
+	// Only the canary is admitted, then the rollout pauses
+	current := step()
+	assert.True(t, applied("canary-1"))
+	assert.False(t, applied("prod-1"))
+	assert.False(t, applied("prod-2"))
+	assert.Equal(t, akuityv1alpha1.RolloutStatus{
+		Generation: 2, Total: 3, Updated: 1, CurrentBatch: "canary", Paused: true,
+	}, *current.Status.Rollout)
+	cond := meta.FindStatusCondition(current.Status.Conditions, akuityv1alpha1.ClassConditionRolloutProgressing)
+	require.NotNil(t, cond)
+	assert.Equal(t, reasonRolloutPaused, cond.Reason)
+
+	// Resuming admits the remaining namespaces one at a time
+	current.Annotations = map[string]string{annotationResume: "canary"}
+	require.NoError(t, c.Update(ctx, current))
+	current = step()
+	assert.True(t, applied("prod-1"))
+	assert.False(t, applied("prod-2"))
+	assert.Equal(t, int32(2), current.Status.Rollout.Updated)
+
+	current = step()
+	assert.True(t, applied("prod-2"))
+	assert.Equal(t, int32(3), current.Status.Rollout.Updated)
+	cond = meta.FindStatusCondition(current.Status.Conditions, akuityv1alpha1.ClassConditionRolloutProgressing)
+	require.NotNil(t, cond)
+	assert.Equal(t, reasonRolloutComplete, cond.Reason)
+
+	// The next generation starts over and forgets the old resume
+	current.Generation = 3
+	require.NoError(t, c.Update(ctx, current))
+	current = step()
+	assert.NotContains(t, current.Annotations, annotationResume)
+	assert.True(t, current.Status.Rollout.Paused)
+	assert.Equal(t, int32(1), current.Status.Rollout.Updated)
+}
+
+func TestFindClassForBinding(t *testing.T) {
+	binding := newRolloutBinding("team-a", 1)
+	requests := findClassForBinding(context.Background(), &binding)
+	require.Len(t, requests, 1)
+	assert.Equal(t, types.NamespacedName{Name: "test-class"}, requests[0].NamespacedName)
+}
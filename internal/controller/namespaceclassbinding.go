@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
-	"time"
 
 	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+	"golang.org/x/sync/errgroup"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -30,13 +32,7 @@ func (r *NamespaceClassBindingReconciler) deleteOldResources(ctx context.Context
 
 	// Delete each resource tracked in the status
 	for _, res := range binding.Status.AppliedResources {
-		obj := &unstructured.Unstructured{}
-		obj.SetAPIVersion(res.APIVersion)
-		obj.SetKind(res.Kind)
-		obj.SetName(res.Name)
-		obj.SetNamespace(binding.Namespace)
-
-		if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+		if err := r.deleteTrackedResource(ctx, binding, res); err != nil {
 			return fmt.Errorf("failed to delete %s/%s: %w", res.Kind, res.Name, err)
 		}
 	}
@@ -51,11 +47,17 @@ func (r *NamespaceClassBindingReconciler) handleNamespaceClassDeleted(ctx contex
 	logger.Info("referenced NamespaceClass not found, cleaning up resources and deleting binding",
 		"className", binding.Spec.ClassName)
 
+	r.parsedClasses().forget(binding.Spec.ClassName)
+
 	// Clean up all resources managed by this binding
 	if err := r.deleteOldResources(ctx, binding); err != nil {
 		logger.Error(err, "failed to delete resources for missing NamespaceClass")
 		return ctrl.Result{}, err
 	}
+	if err := r.clearNamespaceMetadata(ctx, binding); err != nil {
+		logger.Error(err, "failed to remove namespace metadata for missing NamespaceClass")
+		return ctrl.Result{}, err
+	}
 
 	// Delete the binding since the class no longer exists
 	if err := r.Delete(ctx, binding); err != nil && !errors.IsNotFound(err) {
@@ -75,33 +77,105 @@ func (r *NamespaceClassBindingReconciler) handleNamespaceClassDeleted(ctx contex
 func (r *NamespaceClassBindingReconciler) handleNamespaceClassUpdate(ctx context.Context, req ctrl.Request,
 	binding *akuityv1alpha1.NamespaceClassBinding, class *akuityv1alpha1.NamespaceClass) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
+	// Nothing is written when the rendered set and the source content are what was last applied
+	resources, err := r.classResources(ctx, class)
+	if err != nil {
+		return r.handleApplyFailure(ctx, req, binding, class, nil, err)
+	}
+	renderedHash, err := renderedSetHash(resources, binding.Namespace, class.Spec.NamespaceMetadata)
+	if err != nil {
+		return r.handleApplyFailure(ctx, req, binding, class, nil, err)
+	}
+	if renderedUnchanged(binding, class, renderedHash) {
+		logger.V(1).Info("rendered resources unchanged, skipping apply", "generation", class.Generation)
+		if err := r.patchBindingStatus(ctx, req.NamespacedName, func(b *akuityv1alpha1.NamespaceClassBinding) {
+			b.Status.ObservedClassGeneration = class.Generation
+			b.Status.ObservedRevision = revisionName(class.Name, class.Generation)
+		}); err != nil {
+			logger.Error(err, "failed to update binding status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
 	logger.Info("applying resources", "generation", class.Generation)
 
+	// Check running pods before tightening the Pod Security level of the namespace
+	violations, _, err := r.podSecurityPreflight(ctx, binding, class)
+	if err != nil {
+		return r.handleApplyFailure(ctx, req, binding, class, nil, err)
+	}
+	if len(violations) > 0 {
+		summary := podSecuritySummary(violations)
+		r.Recorder.Event(binding, corev1.EventTypeWarning, reasonPodSecurityViolations, summary)
+		if class.Spec.PodSecurityPreflight == akuityv1alpha1.PodSecurityPreflightBlock {
+			if err := r.patchBindingStatus(ctx, req.NamespacedName, func(b *akuityv1alpha1.NamespaceClassBinding) {
+				setPodSecurityStatus(b, violations)
+			}); err != nil {
+				logger.Error(err, "failed to update binding status")
+				return ctrl.Result{}, err
+			}
+			// Held as a transient failure so the pods are checked again with backoff
+			return r.handleApplyFailure(ctx, req, binding, class, nil,
+				fmt.Errorf("holding class change: %s", summary))
+		}
+	}
+
 	// Prune resources that are no longer in the desired state
 	if err := r.pruneRemovedResources(ctx, binding, class); err != nil {
-		return ctrl.Result{}, err
+		return r.handleApplyFailure(ctx, req, binding, class, nil, err)
+	}
+
+	// Label and annotate the namespace itself
+	if err := r.applyNamespaceMetadata(ctx, binding, class); err != nil {
+		return r.handleApplyFailure(ctx, req, binding, class, nil, err)
 	}
 
 	// Apply all resources from the NamespaceClass
-	appliedResources, err := r.applyResources(ctx, binding, class.Spec.Resources)
+	appliedResources, err := r.applyResources(ctx, binding, class)
 	if err != nil {
-		logger.Error(err, "failed to apply resources")
-		return ctrl.Result{}, err
+		return r.handleApplyFailure(ctx, req, binding, class, appliedResources, err)
 	}
 
 	// Update the binding status
 	if err := r.patchBindingStatus(ctx, req.NamespacedName, func(b *akuityv1alpha1.NamespaceClassBinding) {
 		b.Status.ObservedClassName = class.Name
 		b.Status.ObservedClassGeneration = class.Generation
+		b.Status.ObservedRevision = revisionName(class.Name, class.Generation)
+		b.Status.ObservedSourcesHash = sourcesHash(class)
 		b.Status.AppliedResources = appliedResources
+		b.Status.RenderedHash = renderedHash
+		b.Status.FailedAttempts = 0
+		b.Status.NextRetryTime = nil
+		setRecreateConditions(b, appliedResources)
+		setPodSecurityStatus(b, violations)
 	}); err != nil {
 		logger.Error(err, "failed to update binding status")
 		return ctrl.Result{}, err
 	}
 
+	pending := countPendingResources(appliedResources)
+	if pending > 0 {
+		r.Recorder.Event(binding, corev1.EventTypeWarning, "ResourcesPending",
+			fmt.Sprintf("%d resources from class %s are pending until their CRDs are installed", pending,
+				binding.Spec.ClassName))
+	}
+
+	recreating := 0
+	for _, res := range appliedResources {
+		if res.Phase == akuityv1alpha1.ResourcePhaseRecreating {
+			recreating++
+		}
+	}
+
 	r.Recorder.Event(binding, corev1.EventTypeNormal, "ReconcileSucceeded",
-		fmt.Sprintf("Successfully applied %d resources from class %s", len(appliedResources),
-			binding.Spec.ClassName))
+		fmt.Sprintf("Successfully applied %d resources from class %s",
+			len(appliedResources)-pending-recreating, binding.Spec.ClassName))
+
+	// Come back for recreations instead of blocking a worker until the old objects are gone
+	if recreating > 0 {
+		return ctrl.Result{RequeueAfter: recreatePollInterval}, nil
+	}
 
 	return ctrl.Result{}, nil
 }
@@ -110,34 +184,37 @@ func (r *NamespaceClassBindingReconciler) handleNamespaceClassUpdate(ctx context
 func (r *NamespaceClassBindingReconciler) needsUpdate(binding *akuityv1alpha1.NamespaceClassBinding,
 	class *akuityv1alpha1.NamespaceClass) bool {
 	return binding.Status.ObservedClassGeneration != class.Generation ||
-		binding.Status.ObservedClassName != binding.Spec.ClassName
+		binding.Status.ObservedClassName != binding.Spec.ClassName ||
+		binding.Status.ObservedSourcesHash != sourcesHash(class) ||
+		hasPendingResources(binding) ||
+		hasRecreatingResources(binding) ||
+		binding.Status.NextRetryTime != nil
 }
 
 // pruneRemovedResources removes resources that are no longer in the desired state
 func (r *NamespaceClassBindingReconciler) pruneRemovedResources(ctx context.Context,
 	binding *akuityv1alpha1.NamespaceClassBinding, class *akuityv1alpha1.NamespaceClass) error {
+	resources, err := r.classResources(ctx, class)
+	if err != nil {
+		return err
+	}
+
 	// Build desired resource index
-	desired := make(map[string]struct{})
-	for _, raw := range class.Spec.Resources {
-		apiVersion, kind, name, err := extractMetaOnly(raw)
-		if err != nil || apiVersion == "" || kind == "" || name == "" {
-			return fmt.Errorf("invalid resource in NamespaceClass %q: %v", class.Name, err)
+	desired := make(map[string]struct{}, len(resources))
+	for _, res := range resources {
+		namespace := renderString(res.Namespace, binding.Namespace)
+		if namespace == binding.Namespace {
+			namespace = ""
 		}
-		key := getKey(apiVersion, kind, name)
+		key := getKey(res.APIVersion, res.Kind, namespace, renderString(res.Name, binding.Namespace))
 		desired[key] = struct{}{}
 	}
 
 	// Remove resources that are no longer desired
 	for _, prev := range binding.Status.AppliedResources {
-		key := getKey(prev.APIVersion, prev.Kind, prev.Name)
+		key := getKey(prev.APIVersion, prev.Kind, prev.Namespace, prev.Name)
 		if _, ok := desired[key]; !ok {
-			u := &unstructured.Unstructured{}
-			u.SetAPIVersion(prev.APIVersion)
-			u.SetKind(prev.Kind)
-			u.SetName(prev.Name)
-			u.SetNamespace(binding.Namespace)
-
-			if err := r.Delete(ctx, u); err != nil && !errors.IsNotFound(err) {
+			if err := r.deleteTrackedResource(ctx, binding, prev); err != nil {
 				return fmt.Errorf("failed to delete old resource %s/%s: %w", prev.Kind, prev.Name, err)
 			}
 		}
@@ -163,71 +240,192 @@ func (r *NamespaceClassBindingReconciler) patchBindingStatus(ctx context.Context
 	})
 }
 
-// applyResources applies all resources from the NamespaceClass (raw list) to the namespace
+// classResources returns the parsed resources of the class, shared between all of its bindings
+func (r *NamespaceClassBindingReconciler) classResources(ctx context.Context,
+	class *akuityv1alpha1.NamespaceClass) ([]parsedResource, error) {
+	return r.parsedClasses().get(ctx, class)
+}
+
+// parsedClasses returns the shared class cache, creating it on first use
+func (r *NamespaceClassBindingReconciler) parsedClasses() *classCache {
+	r.classesOnce.Do(func() {
+		r.classes = newClassCache(r.Renderer)
+	})
+	return r.classes
+}
+
+// applyResources applies all resources from the NamespaceClass to the namespace. On failure it still
+// returns what is tracked so far, including previously tracked resources it didn't get to.
 func (r *NamespaceClassBindingReconciler) applyResources(
 	ctx context.Context,
 	binding *akuityv1alpha1.NamespaceClassBinding,
-	raws []runtime.RawExtension,
+	class *akuityv1alpha1.NamespaceClass,
 ) ([]akuityv1alpha1.AppliedResource, error) {
-	logger := log.FromContext(ctx)
-	applied := make([]akuityv1alpha1.AppliedResource, 0, len(raws))
+	resources, err := r.classResources(ctx, class)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, raw := range raws {
-		apiVersion, kind, name, err := extractMetaOnly(raw)
-		if err != nil {
-			// malformed entry; surface the error
-			return nil, fmt.Errorf("extract meta: %w", err)
-		}
+	previous := make(map[string]akuityv1alpha1.AppliedResource, len(binding.Status.AppliedResources))
+	for _, prev := range binding.Status.AppliedResources {
+		previous[getKey(prev.APIVersion, prev.Kind, prev.Namespace, prev.Name)] = prev
+	}
 
-		// skip empty items quietly
-		if apiVersion == "" || kind == "" || name == "" {
-			continue
+	// Results are stored by class index so status doesn't depend on completion order
+	entries := make([]akuityv1alpha1.AppliedResource, len(resources))
+	errs := make([]error, len(resources))
+	done := make([]bool, len(resources))
+
+	var failed error
+	for _, wave := range applyOrder(resources) {
+		var g errgroup.Group
+		g.SetLimit(r.maxParallelApplies())
+		for _, i := range wave {
+			g.Go(func() error {
+				entries[i], errs[i] = r.applyResource(ctx, binding, class, resources[i], previous)
+				done[i] = errs[i] == nil
+				return nil
+			})
 		}
+		_ = g.Wait()
 
-		// Parse the full object into Unstructured to preserve arbitrary fields
-		u := &unstructured.Unstructured{}
-		if len(raw.Raw) > 0 {
-			if err := u.UnmarshalJSON(raw.Raw); err != nil {
-				return nil, fmt.Errorf("unmarshal raw object %s %s: %w", kind, name, err)
-			}
-		} else if raw.Object != nil {
-			m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(raw.Object)
-			if err != nil {
-				return nil, fmt.Errorf("to-unstructured %s %s: %w", kind, name, err)
+		// Report the first failure in class order and don't start dependent waves
+		for _, i := range wave {
+			if errs[i] != nil {
+				failed = errs[i]
+				break
 			}
-			u.Object = m
-		} else {
-			// nothing to do because there's no data
-			continue
 		}
+		if failed != nil {
+			break
+		}
+	}
+
+	applied := make([]akuityv1alpha1.AppliedResource, 0, len(resources))
+	for i, entry := range entries {
+		if done[i] {
+			applied = append(applied, entry)
+		}
+	}
+
+	if failed != nil {
+		return mergeTracked(applied, binding.Status.AppliedResources), failed
+	}
+	return applied, nil
+}
+
+// applyResource renders a parsed class resource for the binding's namespace, applies it and returns
+// its tracking entry
+func (r *NamespaceClassBindingReconciler) applyResource(
+	ctx context.Context,
+	binding *akuityv1alpha1.NamespaceClassBinding,
+	class *akuityv1alpha1.NamespaceClass,
+	res parsedResource,
+	previous map[string]akuityv1alpha1.AppliedResource,
+) (akuityv1alpha1.AppliedResource, error) {
+	logger := log.FromContext(ctx)
+	apiVersion, kind := res.APIVersion, res.Kind
+
+	// Ensure GVK & name are set correctly, then fill in namespace placeholders
+	u := res.Object.DeepCopy()
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetName(res.Name)
+	renderPlaceholders(u.Object, binding.Namespace)
+	name := u.GetName()
+
+	clusterScoped, err := r.isClusterScoped(u)
+	if meta.IsNoMatchError(err) {
+		// The kind's CRD isn't installed yet; apply the rest and retry once it is established
+		logger.Info("kind not installed, leaving resource pending", "apiVersion", apiVersion,
+			"kind", kind, "name", name)
+		pending := akuityv1alpha1.AppliedResource{
+			APIVersion: apiVersion,
+			Kind:       kind,
+			Name:       name,
+			Phase:      akuityv1alpha1.ResourcePhasePending,
+			Reason:     reasonCRDNotInstalled,
+		}
+		if ns := u.GetNamespace(); ns != binding.Namespace {
+			pending.Namespace = ns
+		}
+		return pending, nil
+	}
+	if err != nil {
+		return akuityv1alpha1.AppliedResource{}, fmt.Errorf("resolve scope of %s/%s: %w", kind, name, err)
+	}
 
-		// Ensure GVK & name/namespace are set correctly
-		u.SetAPIVersion(apiVersion)
-		u.SetKind(kind)
-		u.SetName(name)
+	// Objects outside the binding's namespace can't have it as owner, so track them by label
+	var targetNamespace string
+	switch {
+	case clusterScoped:
+		if err := r.prepareClusterScoped(ctx, binding, class, u); err != nil {
+			return akuityv1alpha1.AppliedResource{}, err
+		}
+	case u.GetNamespace() != "" && u.GetNamespace() != binding.Namespace:
+		if err := r.prepareCrossNamespace(ctx, binding, class, u); err != nil {
+			return akuityv1alpha1.AppliedResource{}, err
+		}
+		targetNamespace = u.GetNamespace()
+	default:
 		u.SetNamespace(binding.Namespace)
 
 		// Make the Binding the controller owner (anchor → children)
 		if err := controllerutil.SetControllerReference(binding, u, r.Scheme); err != nil {
-			return nil, fmt.Errorf("set ownerRef for %s/%s: %w", kind, name, err)
+			return akuityv1alpha1.AppliedResource{}, fmt.Errorf("set ownerRef for %s/%s: %w", kind, name, err)
 		}
+	}
 
-		// Apply via Server-Side Apply (idempotent)
-		if err := r.applyResourceSSA(ctx, u); err != nil {
-			return nil, fmt.Errorf("apply %s/%s: %w", kind, name, err)
-		}
+	// Track applied resource (UID omitted unless you re-GET)
+	entry := akuityv1alpha1.AppliedResource{
+		APIVersion:    apiVersion,
+		Kind:          kind,
+		Name:          name,
+		Namespace:     targetNamespace,
+		ClusterScoped: clusterScoped,
+		Phase:         akuityv1alpha1.ResourcePhaseApplied,
+	}
 
-		// Track applied resource (UID omitted unless you re-GET)
-		applied = append(applied, akuityv1alpha1.AppliedResource{
-			APIVersion: apiVersion,
-			Kind:       kind,
-			Name:       name,
-		})
+	hash, err := objectHash(u)
+	if err != nil {
+		return akuityv1alpha1.AppliedResource{}, fmt.Errorf("hash %s/%s: %w", kind, name, err)
+	}
+	entry.Hash = hash
+
+	// Skip the write when neither the rendered content nor the live object changed
+	prev, ok := previous[getKey(apiVersion, kind, targetNamespace, name)]
+	if ok && r.applyUnchanged(ctx, prev, hash, u) {
+		logger.V(1).Info("resource unchanged, skipping apply", "kind", kind,
+			"namespace", u.GetNamespace(), "name", name)
+		entry.ResourceVersion = prev.ResourceVersion
+		return entry, nil
+	}
 
-		logger.Info("applied resource", "apiVersion", apiVersion, "kind", kind, "name", name)
+	// A recreation in flight can only apply once the old object is gone
+	if ok && prev.Phase == akuityv1alpha1.ResourcePhaseRecreating && prev.RecreateStartedAt != nil {
+		ready, err := r.recreateReady(ctx, u)
+		if err != nil {
+			return akuityv1alpha1.AppliedResource{}, fmt.Errorf("check recreation of %s/%s: %w", kind, name, err)
+		}
+		if !ready {
+			return r.recreatingEntry(entry, *prev.RecreateStartedAt), nil
+		}
 	}
 
-	return applied, nil
+	// Apply via Server-Side Apply (idempotent)
+	if err := r.applyResourceSSA(ctx, u); err != nil {
+		if isRecreateStarted(err) {
+			return r.recreatingEntry(entry, metav1.Now()), nil
+		}
+		return akuityv1alpha1.AppliedResource{}, fmt.Errorf("apply %s/%s: %w", kind, name, err)
+	}
+
+	entry.ResourceVersion = u.GetResourceVersion()
+
+	logger.Info("applied resource", "apiVersion", apiVersion, "kind", kind,
+		"namespace", u.GetNamespace(), "name", name)
+
+	return entry, nil
 }
 
 // applyResourceSSA performs Server-Side Apply with graduated conflict resolution
@@ -246,9 +444,9 @@ func (r *NamespaceClassBindingReconciler) applyResourceSSA(ctx context.Context,
 		)
 	}
 
-	// Third try: Handle immutable field errors by recreating
+	// Third try: Handle immutable field errors by deleting now and recreating on a later reconcile
 	if r.isImmutableFieldError(err) {
-		return r.recreateResource(ctx, u)
+		return r.startRecreate(ctx, u)
 	}
 
 	// Return original error for all other cases
@@ -274,33 +472,6 @@ func (r *NamespaceClassBindingReconciler) isImmutableFieldError(err error) bool
 		contains(msg, "field is immutable")
 }
 
-// recreateResource safely deletes and recreates a resource for immutable field changes
-func (r *NamespaceClassBindingReconciler) recreateResource(ctx context.Context, u *unstructured.Unstructured) error {
-	logger := log.FromContext(ctx)
-
-	// Check if we're the controller owner before deleting
-	if !r.isControllerOwned(ctx, u) {
-		return fmt.Errorf("cannot recreate resource %s/%s: not owned by this controller",
-			u.GetKind(), u.GetName())
-	}
-
-	logger.Info("recreating resource due to immutable field changes",
-		"kind", u.GetKind(), "name", u.GetName())
-
-	// Delete the existing resource
-	if err := r.Delete(ctx, u); err != nil && !errors.IsNotFound(err) {
-		return fmt.Errorf("failed to delete resource for recreation: %w", err)
-	}
-
-	// Wait for deletion to complete (with timeout)
-	if err := r.waitForDeletion(ctx, u); err != nil {
-		return fmt.Errorf("failed waiting for resource deletion: %w", err)
-	}
-
-	// Recreate the resource
-	return r.Patch(ctx, u, client.Apply, client.FieldOwner(bindingControllerName))
-}
-
 // isControllerOwned checks if the resource is owned by this controller
 func (r *NamespaceClassBindingReconciler) isControllerOwned(ctx context.Context, u *unstructured.Unstructured) bool {
 	// Get the current resource to check ownership
@@ -313,6 +484,13 @@ func (r *NamespaceClassBindingReconciler) isControllerOwned(ctx context.Context,
 		return false // If we can't get it, assume we don't own it
 	}
 
+	// Cluster-scoped resources are tracked by label instead of owner reference
+	if binding := u.GetLabels()[labelBindingNamespace]; binding != "" &&
+		current.GetLabels()[labelBindingNamespace] == binding &&
+		current.GetLabels()[labelBindingName] == u.GetLabels()[labelBindingName] {
+		return true
+	}
+
 	// Check if we're in the owner references
 	for _, owner := range current.GetOwnerReferences() {
 		if owner.APIVersion == "akuity.io/v1alpha1" &&
@@ -324,44 +502,20 @@ func (r *NamespaceClassBindingReconciler) isControllerOwned(ctx context.Context,
 	return false
 }
 
-// waitForDeletion waits for a resource to be fully deleted
-func (r *NamespaceClassBindingReconciler) waitForDeletion(ctx context.Context, u *unstructured.Unstructured) error {
-	key := client.ObjectKeyFromObject(u)
-	check := &unstructured.Unstructured{}
-	check.SetAPIVersion(u.GetAPIVersion())
-	check.SetKind(u.GetKind())
-
-	// Wait up to 30 seconds for deletion
-	for i := 0; i < 30; i++ {
-		if err := r.Get(ctx, key, check); errors.IsNotFound(err) {
-			return nil // Resource is gone
-		}
-
-		// Wait 1 second before checking again
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(time.Second):
-			continue
-		}
-	}
-
-	return fmt.Errorf("timed out waiting for resource deletion")
-}
-
 // metaOnly is used for extracting basic metadata from raw resources
 type metaOnly struct {
 	APIVersion string `json:"apiVersion"`
 	Kind       string `json:"kind"`
 	Metadata   struct {
-		Name string `json:"name"`
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
 	} `json:"metadata"`
 }
 
-// extractMetaOnly extracts apiVersion, kind, and name from a raw resource
-func extractMetaOnly(raw runtime.RawExtension) (apiVersion, kind, name string, err error) {
+// extractMetaOnly extracts apiVersion, kind, namespace, and name from a raw resource
+func extractMetaOnly(raw runtime.RawExtension) (apiVersion, kind, namespace, name string, err error) {
 	if len(raw.Raw) == 0 && raw.Object == nil {
-		return "", "", "", nil
+		return "", "", "", "", nil
 	}
 
 	var m metaOnly
@@ -372,20 +526,20 @@ func extractMetaOnly(raw runtime.RawExtension) (apiVersion, kind, name string, e
 		// Convert the embedded object to JSON, then unmarshal
 		b, e := json.Marshal(raw.Object)
 		if e != nil {
-			return "", "", "", e
+			return "", "", "", "", e
 		}
 		err = json.Unmarshal(b, &m)
 	}
 
 	if err != nil {
-		return "", "", "", err
+		return "", "", "", "", err
 	}
-	return m.APIVersion, m.Kind, m.Metadata.Name, nil
+	return m.APIVersion, m.Kind, m.Metadata.Namespace, m.Metadata.Name, nil
 }
 
 // getKey creates a unique key for a resource
-func getKey(apiVersion, kind, name string) string {
-	return apiVersion + "|" + kind + "|" + name
+func getKey(apiVersion, kind, namespace, name string) string {
+	return apiVersion + "|" + kind + "|" + namespace + "|" + name
 }
 
 // contains checks if substr is in s, ignoring case
@@ -18,8 +18,15 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 
 	"k8s.io/apimachinery/pkg/runtime"
@@ -30,7 +37,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
 )
@@ -48,14 +57,63 @@ type NamespaceClassBindingReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+
+	// APIReader reads objects straight from the API server that aren't worth caching, such as the
+	// pods checked before a class tightens Pod Security. Defaults to the manager's API reader, or the
+	// client outside a manager.
+	APIReader client.Reader
+
+	// RecreateTimeout bounds how long a resource deleted for recreation may take to go away
+	// before the binding reports Degraded. Defaults to 30 seconds.
+	RecreateTimeout time.Duration
+
+	// MaxParallelApplies limits how many resources of one binding are applied concurrently. Kinds other
+	// resources depend on are still applied first. Defaults to 8; 1 applies serially.
+	MaxParallelApplies int
+
+	// Git fetches the Git sources of classes; classes with Git sources can't be applied without it
+	Git *GitRepositories
+
+	// OCI pulls the OCI artifact sources of classes; classes with OCI sources can't be applied without it
+	OCI *OCIArtifacts
+
+	// Helm downloads the repository and OCI charts of Helm sources and keeps the rendered charts.
+	// ConfigMap charts don't need it, but are rendered on every reconcile without it.
+	Helm *HelmCharts
+
+	// Kustomize keeps the builds of Kustomize sources; without it they are built on every reconcile
+	Kustomize *KustomizeBuilds
+
+	// SecretNamespace is the only namespace the credentials Secrets of class sources are read from,
+	// usually the operator's own. Without it sources can't use credentials.
+	SecretNamespace string
+
+	// SourceCache serves the ConfigMaps and Secrets class sources read, see NewSourceCache. Defaults to
+	// the manager's cache, or the client outside a manager.
+	SourceCache cache.Cache
+
+	// Renderer turns class resources into the objects applied to namespaces. Defaults to the
+	// resources as written; classes with functions can't be applied without a KRMRenderer.
+	Renderer Renderer
+
+	// finalizerMu serializes adding the cleanup finalizer from concurrent applies
+	finalizerMu sync.Mutex
+
+	// classes caches parsed class content per class generation
+	classes     *classCache
+	classesOnce sync.Once
 }
 
 // +kubebuilder:rbac:groups=akuity.io,resources=namespaceclassbindings,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=akuity.io,resources=namespaceclassbindings/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=akuity.io,resources=namespaceclassbindings/finalizers,verbs=update
 // +kubebuilder:rbac:groups=akuity.io,resources=namespaceclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=akuity.io,resources=namespaceclassrevisions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=akuity.io,resources=namespaceclassfragments,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
 // +kubebuilder:rbac:groups="*",resources="*",verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile handles the reconciliation of a NamespaceClassBinding
@@ -74,6 +132,11 @@ func (r *NamespaceClassBindingReconciler) Reconcile(ctx context.Context, req ctr
 		return ctrl.Result{}, err
 	}
 
+	// Release resources that aren't garbage collected through ownerReferences
+	if !binding.DeletionTimestamp.IsZero() {
+		return r.finalizeBinding(ctx, binding)
+	}
+
 	// Fetch the referenced NamespaceClass
 	class := &akuityv1alpha1.NamespaceClass{}
 	if err := r.Get(ctx, types.NamespacedName{Name: binding.Spec.ClassName}, class); err != nil {
@@ -85,8 +148,43 @@ func (r *NamespaceClassBindingReconciler) Reconcile(ctx context.Context, req ctr
 		return ctrl.Result{}, err
 	}
 
+	// Pinned bindings and aborted rollouts are applied from a revision of the class
+	target, err := r.classTarget(ctx, binding, class)
+	if err != nil {
+		if pinned := pinnedRevision(binding); pinned != "" && (errors.IsNotFound(err) || isPermanentFailure(err)) {
+			// The revision watch brings the binding back once the revision exists
+			return r.handleApplyFailure(ctx, req, binding, class, nil,
+				permanent(fmt.Errorf("pinned revision %s is unavailable: %w", pinned, err)))
+		}
+		logger.Error(err, "unable to resolve class content", "className", binding.Spec.ClassName)
+		return ctrl.Result{}, err
+	}
+	fromRevision := target != class
+
+	// Resources kept in ConfigMaps and fragments are read on every reconcile, since they change
+	// without the class generation changing
+	class, err = resolveSources(ctx, sourceReader(r, r.SourceCache, r.SecretNamespace),
+		sourceFetchers{git: r.Git, oci: r.OCI, helm: r.Helm, kustomize: r.Kustomize}, target)
+	if err != nil {
+		if isPermanentFailure(err) {
+			return r.handleApplyFailure(ctx, req, binding, target, nil, err)
+		}
+		logger.Error(err, "unable to read class sources", "className", binding.Spec.ClassName)
+		return ctrl.Result{}, err
+	}
+
+	// Hold off retrying a transient failure until its backoff has passed, unless the class changed
+	if wait := retryDelay(binding, class); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
 	// Check if we need to update based on generation or class name change
 	if r.needsUpdate(binding, class) {
+		// A class change waits for the class rollout to admit this binding; revisions don't wait
+		if !fromRevision && !rolloutAdmitted(binding, class) {
+			logger.V(1).Info("waiting for rollout of class generation", "generation", class.Generation)
+			return ctrl.Result{}, nil
+		}
 		return r.handleNamespaceClassUpdate(ctx, req, binding, class)
 	}
 
@@ -97,6 +195,12 @@ func (r *NamespaceClassBindingReconciler) Reconcile(ctx context.Context, req ctr
 // SetupWithManager sets up the controller with the Manager.
 func (r *NamespaceClassBindingReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Recorder = mgr.GetEventRecorderFor(bindingControllerName)
+	if r.APIReader == nil {
+		r.APIReader = mgr.GetAPIReader()
+	}
+	if r.SourceCache == nil {
+		r.SourceCache = mgr.GetCache()
+	}
 
 	// Index bindings by class name for efficient lookups
 	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &akuityv1alpha1.NamespaceClassBinding{},
@@ -107,7 +211,19 @@ func (r *NamespaceClassBindingReconciler) SetupWithManager(mgr ctrl.Manager) err
 		return err
 	}
 
-	// Trigger reconciliation of bindings when their referenced class changes
+	// Index classes by their sources so source changes reach the bindings of the class
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &akuityv1alpha1.NamespaceClass{},
+		classSourcesField, indexClassSources); err != nil {
+		return err
+	}
+
+	// Index bindings by the kinds they are waiting on so new CRDs can wake them up
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &akuityv1alpha1.NamespaceClassBinding{},
+		pendingKindsField, indexPendingKinds); err != nil {
+		return err
+	}
+
+	// Trigger reconciliation of bindings when their referenced class changes or a missing CRD appears
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: 8,
@@ -116,6 +232,24 @@ func (r *NamespaceClassBindingReconciler) SetupWithManager(mgr ctrl.Manager) err
 		Watches(
 			&akuityv1alpha1.NamespaceClass{},
 			handler.EnqueueRequestsFromMapFunc(r.findBindingsForClass),
+			// Rollout status updates on the class don't concern its bindings, new source revisions do
+			builder.WithPredicates(predicate.Or[client.Object](predicate.GenerationChangedPredicate{},
+				sourceRevisionsChanged)),
+		).
+		WatchesRawSource(source.Kind[client.Object](r.SourceCache, &corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.findBindingsForSource("ConfigMap")))).
+		Watches(
+			&akuityv1alpha1.NamespaceClassFragment{},
+			handler.EnqueueRequestsFromMapFunc(r.findBindingsForSource("NamespaceClassFragment")),
+		).
+		Watches(
+			&akuityv1alpha1.NamespaceClassRevision{},
+			handler.EnqueueRequestsFromMapFunc(r.findBindingsForRevision),
+		).
+		Watches(
+			&apiextensionsv1.CustomResourceDefinition{},
+			handler.EnqueueRequestsFromMapFunc(r.findBindingsForCRD),
+			builder.WithPredicates(crdEstablishedPredicate),
 		).
 		Complete(r)
 }
@@ -130,8 +264,13 @@ func (r *NamespaceClassBindingReconciler) findBindingsForClass(ctx context.Conte
 		return nil
 	}
 
-	requests := make([]reconcile.Request, len(bindings.Items))
-	for i, binding := range bindings.Items {
+	return bindingRequests(bindings.Items)
+}
+
+// bindingRequests builds reconcile requests for the given bindings
+func bindingRequests(bindings []akuityv1alpha1.NamespaceClassBinding) []reconcile.Request {
+	requests := make([]reconcile.Request, len(bindings))
+	for i, binding := range bindings {
 		requests[i] = reconcile.Request{
 			NamespacedName: types.NamespacedName{
 				Name:      binding.Name,
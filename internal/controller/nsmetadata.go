@@ -0,0 +1,201 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// namespaceMetadataFieldManager owns the labels and annotations classes set on bound namespaces
+	namespaceMetadataFieldManager = "namespaceclass-namespace-metadata"
+
+	// reservedKeyPrefix marks the namespace labels and annotations the operator itself reacts to
+	reservedKeyPrefix = "namespaceclass.akuity.io/"
+
+	// reasonNamespaceMetadataConflict is the event reason of namespace metadata left to another manager
+	reasonNamespaceMetadataConflict = "NamespaceMetadataConflict"
+)
+
+// desiredNamespaceMetadata renders the namespace metadata of the class for the binding's namespace
+func desiredNamespaceMetadata(binding *akuityv1alpha1.NamespaceClassBinding,
+	class *akuityv1alpha1.NamespaceClass) (labels, annotations map[string]string, err error) {
+	metadata := class.Spec.NamespaceMetadata
+	if metadata == nil {
+		return nil, nil, nil
+	}
+
+	render := func(in map[string]string) (map[string]string, error) {
+		out := make(map[string]string, len(in))
+		for key, value := range in {
+			// Setting the class label from a class would make NamespaceReconciler switch classes in a loop
+			if strings.HasPrefix(key, reservedKeyPrefix) {
+				return nil, permanent(fmt.Errorf("namespaceMetadata of NamespaceClass %q may not set %s; "+
+					"keys under %s are reserved", class.Name, key, reservedKeyPrefix))
+			}
+			out[key] = renderString(value, binding.Namespace)
+		}
+		return out, nil
+	}
+	if labels, err = render(metadata.Labels); err != nil {
+		return nil, nil, err
+	}
+	if annotations, err = render(metadata.Annotations); err != nil {
+		return nil, nil, err
+	}
+	return labels, annotations, nil
+}
+
+// applyNamespaceMetadata applies the namespace metadata of the class to the bound namespace. Keys the
+// class no longer sets are removed by server-side apply, since the field manager stops claiming them.
+// Keys someone else already set to another value are left alone, so they aren't taken over and later
+// removed with the class.
+func (r *NamespaceClassBindingReconciler) applyNamespaceMetadata(ctx context.Context,
+	binding *akuityv1alpha1.NamespaceClassBinding, class *akuityv1alpha1.NamespaceClass) error {
+	labels, annotations, err := desiredNamespaceMetadata(binding, class)
+	if err != nil {
+		return err
+	}
+	if len(labels)+len(annotations) == 0 {
+		return r.clearNamespaceMetadata(ctx, binding)
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: binding.Namespace}, namespace); err != nil {
+		return fmt.Errorf("get namespace %s: %w", binding.Namespace, err)
+	}
+	ownedLabels, ownedAnnotations := ownedNamespaceMetadata(namespace)
+	skipped := append(
+		dropForeignKeys(labels, namespace.Labels, ownedLabels, "label"),
+		dropForeignKeys(annotations, namespace.Annotations, ownedAnnotations, "annotation")...)
+	if len(skipped) > 0 {
+		r.Recorder.Event(binding, corev1.EventTypeWarning, reasonNamespaceMetadataConflict,
+			fmt.Sprintf("left namespace metadata set by others as is: %s", strings.Join(skipped, ", ")))
+	}
+
+	// The metadata has to be removed again when the namespace is unbound
+	if err := r.ensureCleanupFinalizer(ctx, binding); err != nil {
+		return err
+	}
+
+	if err := r.Patch(ctx, namespaceMetadataObject(binding.Namespace, labels, annotations), client.Apply,
+		client.FieldOwner(namespaceMetadataFieldManager)); err != nil {
+		return fmt.Errorf("apply namespace metadata: %w", err)
+	}
+	return nil
+}
+
+// dropForeignKeys removes the keys from desired that the namespace already has with another value and
+// that the class didn't set, and returns them described as kind key
+func dropForeignKeys(desired, current map[string]string, owned map[string]bool, kind string) []string {
+	var skipped []string
+	for key, value := range desired {
+		if existing, ok := current[key]; ok && existing != value && !owned[key] {
+			delete(desired, key)
+			skipped = append(skipped, kind+" "+key)
+		}
+	}
+	sort.Strings(skipped)
+	return skipped
+}
+
+// ownedNamespaceMetadata returns the label and annotation keys of the namespace the class metadata field
+// manager owns
+func ownedNamespaceMetadata(namespace *corev1.Namespace) (labels, annotations map[string]bool) {
+	labels, annotations = map[string]bool{}, map[string]bool{}
+	for _, entry := range namespace.ManagedFields {
+		if entry.Manager != namespaceMetadataFieldManager || entry.FieldsV1 == nil {
+			continue
+		}
+		var fields struct {
+			Metadata struct {
+				Labels      map[string]json.RawMessage `json:"f:labels"`
+				Annotations map[string]json.RawMessage `json:"f:annotations"`
+			} `json:"f:metadata"`
+		}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		for key := range fields.Metadata.Labels {
+			labels[strings.TrimPrefix(key, "f:")] = true
+		}
+		for key := range fields.Metadata.Annotations {
+			annotations[strings.TrimPrefix(key, "f:")] = true
+		}
+	}
+	return labels, annotations
+}
+
+// clearNamespaceMetadata removes the labels and annotations classes set on the bound namespace
+func (r *NamespaceClassBindingReconciler) clearNamespaceMetadata(ctx context.Context,
+	binding *akuityv1alpha1.NamespaceClassBinding) error {
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: binding.Namespace}, namespace); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("get namespace %s: %w", binding.Namespace, err)
+	}
+	if !namespace.DeletionTimestamp.IsZero() || !managesNamespaceMetadata(namespace) {
+		return nil
+	}
+
+	// Applying nothing releases, and so removes, every key the field manager owned
+	if err := r.Patch(ctx, namespaceMetadataObject(binding.Namespace, nil, nil), client.Apply,
+		client.FieldOwner(namespaceMetadataFieldManager), client.ForceOwnership); err != nil {
+		return fmt.Errorf("clear namespace metadata: %w", err)
+	}
+	return nil
+}
+
+// managesNamespaceMetadata reports whether the namespace may carry metadata applied from a class. A
+// namespace without managed fields at all isn't tracked, so it can't be ruled out.
+func managesNamespaceMetadata(namespace *corev1.Namespace) bool {
+	if len(namespace.ManagedFields) == 0 {
+		return true
+	}
+	for _, entry := range namespace.ManagedFields {
+		if entry.Manager == namespaceMetadataFieldManager && entry.Operation == metav1.ManagedFieldsOperationApply {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceMetadataObject builds the apply configuration of a namespace's class metadata
+func namespaceMetadataObject(name string, labels, annotations map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("Namespace")
+	u.SetName(name)
+	u.SetLabels(labels)
+	u.SetAnnotations(annotations)
+	return u
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+func TestNamespaceClassBindingReconciler_NamespaceMetadata(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-ns", Namespace: "test-ns"}}
+
+	class := &akuityv1alpha1.NamespaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-class", Generation: 1},
+		Spec: akuityv1alpha1.NamespaceClassSpec{
+			NamespaceMetadata: &akuityv1alpha1.NamespaceMetadata{
+				Labels:      map[string]string{"team": "$(NAMESPACE)", "istio-injection": "enabled"},
+				Annotations: map[string]string{"cost-center": "1234"},
+			},
+		},
+	}
+	reconciler, c := newBindingTestReconciler(scheme,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   "test-ns",
+			Labels: map[string]string{"owner": "someone"},
+		}},
+		&akuityv1alpha1.NamespaceClassBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-ns", Namespace: "test-ns"},
+			Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+		},
+		class,
+	)
+	namespaceMetadata := func() *corev1.Namespace {
+		namespace := &corev1.Namespace{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "test-ns"}, namespace))
+		return namespace
+	}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	namespace := namespaceMetadata()
+	assert.Equal(t, "test-ns", namespace.Labels["team"])
+	assert.Equal(t, "enabled", namespace.Labels["istio-injection"])
+	assert.Equal(t, "1234", namespace.Annotations["cost-center"])
+	assert.Equal(t, "someone", namespace.Labels["owner"])
+	binding := &akuityv1alpha1.NamespaceClassBinding{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+	assert.True(t, controllerutil.ContainsFinalizer(binding, finalizerCleanup))
+
+	// Keys the class stops setting are removed
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(class), class))
+	class.Generation = 2
+	delete(class.Spec.NamespaceMetadata.Labels, "istio-injection")
+	require.NoError(t, c.Update(ctx, class))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	namespace = namespaceMetadata()
+	assert.NotContains(t, namespace.Labels, "istio-injection")
+	assert.Equal(t, "test-ns", namespace.Labels["team"])
+	assert.Equal(t, "someone", namespace.Labels["owner"])
+
+	// Reserved keys would make the namespace controller react to the class
+	class.Generation = 3
+	class.Spec.NamespaceMetadata.Labels[labelNamespaceClass] = "other-class"
+	require.NoError(t, c.Update(ctx, class))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Contains(t, degradedMessage(t, c, req.NamespacedName), "reserved")
+	assert.NotContains(t, namespaceMetadata().Labels, labelNamespaceClass)
+
+	// Unbinding removes the metadata and leaves everything else alone
+	require.NoError(t, c.Delete(ctx, binding))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	namespace = namespaceMetadata()
+	assert.NotContains(t, namespace.Labels, "team")
+	assert.NotContains(t, namespace.Annotations, "cost-center")
+	assert.Equal(t, "someone", namespace.Labels["owner"])
+}
+
+func TestNamespaceClassBindingReconciler_NamespaceMetadataSetByOthers(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-ns", Namespace: "test-ns"}}
+
+	class := &akuityv1alpha1.NamespaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-class", Generation: 1},
+		Spec: akuityv1alpha1.NamespaceClassSpec{
+			NamespaceMetadata: &akuityv1alpha1.NamespaceMetadata{
+				Labels: map[string]string{"team": "a", "istio-injection": "enabled"},
+			},
+		},
+	}
+	// Ownership is read from the managed fields of the namespace
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(newTestRESTMapper()).
+		WithStatusSubresource(&akuityv1alpha1.NamespaceClassBinding{}).
+		WithReturnManagedFields().
+		WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:   "test-ns",
+				Labels: map[string]string{"istio-injection": "disabled"},
+			}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-ns", Namespace: "test-ns"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			},
+			class,
+		).
+		Build()
+	reconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+	labels := func() map[string]string {
+		namespace := &corev1.Namespace{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "test-ns"}, namespace))
+		return namespace.Labels
+	}
+
+	// A key someone else set to another value isn't taken over
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "disabled", labels()["istio-injection"])
+	assert.Equal(t, "a", labels()["team"])
+
+	// and survives the class no longer setting it
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(class), class))
+	class.Generation = 2
+	delete(class.Spec.NamespaceMetadata.Labels, "istio-injection")
+	require.NoError(t, c.Update(ctx, class))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "disabled", labels()["istio-injection"])
+
+	// Keys the class set itself keep following the class
+	class.Generation = 3
+	class.Spec.NamespaceMetadata.Labels["team"] = "b"
+	require.NoError(t, c.Update(ctx, class))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "b", labels()["team"])
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// ociScheme prefixes the URL of OCI sources
+	ociScheme = "oci://"
+
+	// maxCachedOCIRenders bounds how many rendered artifact paths are kept
+	maxCachedOCIRenders = 32
+
+	// maxOCILayerSize bounds the size of a layer, before and after decompression, so a large
+	// artifact can't exhaust the operator's memory
+	maxOCILayerSize = 64 << 20
+
+	// ociRemoteTimeout bounds a single resolve or pull of an artifact, including reading its layers
+	ociRemoteTimeout = 2 * time.Minute
+)
+
+// OCIArtifacts pulls the OCI artifacts of class sources and keeps the resources and files read from
+// them by digest. One instance is shared by the reconcilers so every digest is pulled once.
+type OCIArtifacts struct {
+	mu       sync.Mutex
+	rendered map[string][]runtime.RawExtension
+	trees    map[string]map[string][]byte
+}
+
+// NewOCIArtifacts returns an empty set of OCI artifacts
+func NewOCIArtifacts() *OCIArtifacts {
+	return &OCIArtifacts{
+		rendered: map[string][]runtime.RawExtension{},
+		trees:    map[string]map[string][]byte{},
+	}
+}
+
+// resolve returns the digest the artifact URL of the source points at
+func (o *OCIArtifacts) resolve(ctx context.Context, source *akuityv1alpha1.OCISource,
+	auth authn.Authenticator) (string, error) {
+	ref, err := ociReference(source)
+	if err != nil {
+		return "", err
+	}
+	// A registry that stops responding would otherwise hold a worker forever
+	ctx, cancel := context.WithTimeout(ctx, ociRemoteTimeout)
+	defer cancel()
+	desc, err := remote.Head(ref, remote.WithContext(ctx), remote.WithAuth(auth))
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", ref, err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// resources returns the resources of the artifact with the given digest, pulling it if it isn't
+// cached yet. Pulling by digest verifies the manifest and every layer against their digests.
+func (o *OCIArtifacts) resources(ctx context.Context, source *akuityv1alpha1.OCISource, digest string,
+	auth authn.Authenticator) ([]runtime.RawExtension, error) {
+	ref, err := ociReference(source)
+	if err != nil {
+		return nil, err
+	}
+	pinned := ref.Context().Digest(digest)
+	key := pinned.String() + "\x00" + source.Path
+
+	o.mu.Lock()
+	resources, ok := o.rendered[key]
+	o.mu.Unlock()
+	if ok {
+		return resources, nil
+	}
+
+	// Layers are fetched lazily with this context, so the deadline covers reading them too
+	ctx, cancel := context.WithTimeout(ctx, ociRemoteTimeout)
+	defer cancel()
+	image, err := remote.Image(pinned, remote.WithContext(ctx), remote.WithAuth(auth))
+	if err != nil {
+		return nil, fmt.Errorf("pull %s: %w", pinned, err)
+	}
+	if resources, err = artifactResources(image, source.Path); err != nil {
+		return nil, fmt.Errorf("artifact %s: %w", digest, err)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.rendered) >= maxCachedOCIRenders {
+		o.rendered = map[string][]runtime.RawExtension{}
+	}
+	o.rendered[key] = resources
+	return resources, nil
+}
+
+// files returns every file below the source path in the tar layers of the artifact with the given
+// digest, by its path relative to the source path. Layers that aren't tar archives are skipped.
+func (o *OCIArtifacts) files(ctx context.Context, source *akuityv1alpha1.OCISource, digest string,
+	auth authn.Authenticator) (map[string][]byte, error) {
+	ref, err := ociReference(source)
+	if err != nil {
+		return nil, err
+	}
+	pinned := ref.Context().Digest(digest)
+	key := pinned.String() + "\x00" + source.Path
+
+	o.mu.Lock()
+	files, ok := o.trees[key]
+	o.mu.Unlock()
+	if ok {
+		return files, nil
+	}
+
+	// Layers are fetched lazily with this context, so the deadline covers reading them too
+	ctx, cancel := context.WithTimeout(ctx, ociRemoteTimeout)
+	defer cancel()
+	image, err := remote.Image(pinned, remote.WithContext(ctx), remote.WithAuth(auth))
+	if err != nil {
+		return nil, fmt.Errorf("pull %s: %w", pinned, err)
+	}
+	layers, err := image.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("artifact %s: %w", digest, err)
+	}
+	dir := strings.Trim(path.Clean("/"+source.Path), "/")
+	files = map[string][]byte{}
+	for i, layer := range layers {
+		content, err := readLayer(layer)
+		if err != nil {
+			return nil, fmt.Errorf("artifact %s: layer %d: %w", digest, i, err)
+		}
+		if !isTar(content) {
+			continue
+		}
+		layerFiles, err := tarFiles(content, dir, func(string) bool { return true })
+		if err != nil {
+			return nil, fmt.Errorf("artifact %s: layer %d: %w", digest, i, err)
+		}
+		for name, data := range layerFiles {
+			files[name] = data
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.trees) >= maxCachedOCIRenders {
+		o.trees = map[string]map[string][]byte{}
+	}
+	o.trees[key] = files
+	return files, nil
+}
+
+// ociReference parses the URL of an OCI source
+func ociReference(source *akuityv1alpha1.OCISource) (name.Reference, error) {
+	if !strings.HasPrefix(source.URL, ociScheme) {
+		return nil, permanent(fmt.Errorf("OCI URL %s doesn't start with %s", source.URL, ociScheme))
+	}
+	var opts []name.Option
+	if source.Insecure {
+		opts = append(opts, name.Insecure)
+	}
+	ref, err := name.ParseReference(strings.TrimPrefix(source.URL, ociScheme), opts...)
+	if err != nil {
+		return nil, permanent(fmt.Errorf("OCI URL %s: %w", source.URL, err))
+	}
+	return ref, nil
+}
+
+// artifactResources reads the resources of every layer, in manifest order. Tar layers contribute
+// the .yaml, .yml and .json files below dir in path order; other layers are read as YAML.
+func artifactResources(image v1.Image, dir string) ([]runtime.RawExtension, error) {
+	layers, err := image.Layers()
+	if err != nil {
+		return nil, err
+	}
+	dir = strings.Trim(path.Clean("/"+dir), "/")
+
+	var resources []runtime.RawExtension
+	for i, layer := range layers {
+		content, err := readLayer(layer)
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %w", i, err)
+		}
+		var docs []runtime.RawExtension
+		if isTar(content) {
+			var files map[string][]byte
+			if files, err = tarFiles(content, dir, isManifestFile); err == nil {
+				docs, err = manifestResources(files)
+			}
+		} else {
+			docs, err = splitYAMLDocuments(content)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %w", i, err)
+		}
+		resources = append(resources, docs...)
+	}
+	return resources, nil
+}
+
+// readLayer reads a layer, decompressing it if it is gzipped. The registry client verifies the
+// layer digest once the compressed content is read to the end.
+func readLayer(layer v1.Layer) ([]byte, error) {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+	content, err := readLimited(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+		return content, nil
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = gz.Close() }()
+	return readLimited(gz)
+}
+
+// readLimited reads r to the end, failing once it passes maxOCILayerSize
+func readLimited(r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxOCILayerSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxOCILayerSize {
+		return nil, permanent(fmt.Errorf("layer is larger than %d bytes", maxOCILayerSize))
+	}
+	return content, nil
+}
+
+// isTar reports whether content starts with a POSIX tar header
+func isTar(content []byte) bool {
+	return len(content) >= 262 && string(content[257:262]) == "ustar"
+}
+
+// tarFiles reads the regular files of a tar archive below dir that keep accepts, by their path
+// relative to dir
+func tarFiles(content []byte, dir string, keep func(name string) bool) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	reader := tar.NewReader(bytes.NewReader(content))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		file := strings.TrimPrefix(path.Clean("/"+header.Name), "/")
+		if dir != "" {
+			if !strings.HasPrefix(file, dir+"/") {
+				continue
+			}
+			file = strings.TrimPrefix(file, dir+"/")
+		}
+		if !keep(file) {
+			continue
+		}
+		if files[file], err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("read %s: %w", file, err)
+		}
+	}
+}
+
+// ociAuth builds the registry credentials of an OCI source from its Secret
+func ociAuth(ctx context.Context, c client.Reader, source *akuityv1alpha1.OCISource) (authn.Authenticator, error) {
+	if source.SecretRef == nil {
+		return authn.Anonymous, nil
+	}
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: source.SecretRef.Name, Namespace: source.SecretRef.Namespace}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return nil, fmt.Errorf("get Secret %s: %w", key, err)
+	}
+
+	dockerConfig, ok := secret.Data[corev1.DockerConfigJsonKey]
+	if !ok {
+		return &authn.Basic{Username: string(secret.Data["username"]), Password: string(secret.Data["password"])}, nil
+	}
+	var config struct {
+		Auths map[string]authn.AuthConfig `json:"auths"`
+	}
+	if err := json.Unmarshal(dockerConfig, &config); err != nil {
+		return nil, permanent(fmt.Errorf("secret %s: %s: %w", key, corev1.DockerConfigJsonKey, err))
+	}
+	ref, err := ociReference(source)
+	if err != nil {
+		return nil, err
+	}
+	registry := ref.Context().RegistryStr()
+	for server, auth := range config.Auths {
+		if registryHost(server) == registry {
+			return authn.FromConfig(auth), nil
+		}
+	}
+	return nil, permanent(fmt.Errorf("secret %s has no credentials for %s", key, registry))
+}
+
+// registryHost returns the registry of a Docker config entry, which may be a bare host or a URL
+func registryHost(server string) string {
+	if strings.Contains(server, "://") {
+		if u, err := url.Parse(server); err == nil {
+			server = u.Host
+		}
+	}
+	server = strings.SplitN(server, "/", 2)[0]
+	if server == "docker.io" {
+		// Docker Hub entries use either name; references normalize to the index host
+		return name.DefaultRegistry
+	}
+	return server
+}
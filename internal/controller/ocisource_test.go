@@ -0,0 +1,292 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// newTestRegistry serves an in-process registry and returns its host. With a username it only
+// serves requests with those basic auth credentials.
+func newTestRegistry(t *testing.T, username, password string) string {
+	handler := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); username != "" && (user != username || pass != password) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+// tarGzLayer builds a gzipped tar layer of files, in the order given
+func tarGzLayer(t *testing.T, files ...string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for i := 0; i < len(files); i += 2 {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: files[i], Mode: 0o644, Size: int64(len(files[i+1])), Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(files[i+1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+// pushArtifact pushes an artifact with the given layers to ref and returns its digest
+func pushArtifact(t *testing.T, ref string, auth authn.Authenticator, layers ...[]byte) string {
+	image := empty.Image
+	for _, content := range layers {
+		var err error
+		image, err = mutate.AppendLayers(image, static.NewLayer(content, types.MediaType("application/vnd.test.content.v1")))
+		require.NoError(t, err)
+	}
+	parsed, err := name.ParseReference(ref)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(parsed, image, remote.WithAuth(auth)))
+	digest, err := image.Digest()
+	require.NoError(t, err)
+	return digest.String()
+}
+
+func TestOCIArtifacts(t *testing.T) {
+	ctx := context.Background()
+	host := newTestRegistry(t, "", "")
+	digest := pushArtifact(t, host+"/classes:v1", authn.Anonymous,
+		tarGzLayer(t,
+			"./classes/b.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n",
+			"classes/a.yml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n",
+			"classes/README.md", "not a resource",
+			"other/c.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: c\n"),
+		[]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: raw\n"))
+	artifacts := NewOCIArtifacts()
+	names := func(source *akuityv1alpha1.OCISource, digest string) []string {
+		resources, err := artifacts.resources(ctx, source, digest, authn.Anonymous)
+		require.NoError(t, err)
+		parsed := parseClass(context.Background(), nil, &akuityv1alpha1.NamespaceClass{Spec: akuityv1alpha1.NamespaceClassSpec{Resources: resources}})
+		require.NoError(t, parsed.err)
+		var names []string
+		for _, res := range parsed.resources {
+			names = append(names, res.Name)
+		}
+		return names
+	}
+
+	// The tag resolves to the pushed digest
+	source := &akuityv1alpha1.OCISource{URL: "oci://" + host + "/classes:v1"}
+	resolved, err := artifacts.resolve(ctx, source, authn.Anonymous)
+	require.NoError(t, err)
+	assert.Equal(t, digest, resolved)
+
+	// Tar layers contribute the files below the path in path order, other layers are read as YAML
+	assert.Equal(t, []string{"a", "b", "c", "raw"}, names(source, digest))
+	source.Path = "classes"
+	assert.Equal(t, []string{"a", "b", "raw"}, names(source, digest))
+
+	// A digest URL resolves to itself
+	pinned := &akuityv1alpha1.OCISource{URL: "oci://" + host + "/classes@" + digest}
+	resolved, err = artifacts.resolve(ctx, pinned, authn.Anonymous)
+	require.NoError(t, err)
+	assert.Equal(t, digest, resolved)
+
+	// Digests the registry doesn't have fail
+	_, err = artifacts.resources(ctx, &akuityv1alpha1.OCISource{URL: "oci://" + host + "/classes:v1"},
+		"sha256:"+strings.Repeat("0", 64), authn.Anonymous)
+	require.Error(t, err)
+
+	// URLs are oci:// references
+	_, err = artifacts.resolve(ctx, &akuityv1alpha1.OCISource{URL: "https://" + host + "/classes"}, authn.Anonymous)
+	require.Error(t, err)
+	assert.True(t, isPermanentFailure(err))
+}
+
+func TestOCIAuth(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "docker", Namespace: "system"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(
+				`{"auths":{"https://registry.example.com/v1/":{"auth":"dXNlcjpzZWNyZXQ="},"docker.io":{"username":"hub","password":"p"}}}`)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "basic", Namespace: "system"},
+			Data:       map[string][]byte{"username": []byte("robot"), "password": []byte("token")},
+		},
+	).Build()
+	credentials := func(url, secret string) (*authn.AuthConfig, error) {
+		auth, err := ociAuth(ctx, c, &akuityv1alpha1.OCISource{URL: url,
+			SecretRef: &akuityv1alpha1.SecretReference{Name: secret, Namespace: "system"}})
+		if err != nil {
+			return nil, err
+		}
+		return auth.Authorization()
+	}
+
+	config, err := credentials("oci://registry.example.com/classes:v1", "docker")
+	require.NoError(t, err)
+	assert.Equal(t, "user", config.Username)
+	assert.Equal(t, "secret", config.Password)
+
+	config, err = credentials("oci://docker.io/example/classes", "docker")
+	require.NoError(t, err)
+	assert.Equal(t, "hub", config.Username)
+
+	_, err = credentials("oci://ghcr.io/example/classes", "docker")
+	require.Error(t, err)
+	assert.True(t, isPermanentFailure(err))
+
+	config, err = credentials("oci://ghcr.io/example/classes", "basic")
+	require.NoError(t, err)
+	assert.Equal(t, "robot", config.Username)
+	assert.Equal(t, "token", config.Password)
+
+	auth, err := ociAuth(ctx, c, &akuityv1alpha1.OCISource{URL: "oci://ghcr.io/example/classes"})
+	require.NoError(t, err)
+	assert.Equal(t, authn.Anonymous, auth)
+}
+
+func TestNamespaceClassReconciler_OCISource(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	host := newTestRegistry(t, "robot", "token")
+	robot := &authn.Basic{Username: "robot", Password: "token"}
+	limits := func(cpu string) []byte {
+		return []byte(fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: limits\ndata:\n  cpu: %q\n", cpu))
+	}
+	first := pushArtifact(t, host+"/classes:stable", robot, limits("1"))
+
+	class := settingsClass(1, "1")
+	class.Spec.Sources = []akuityv1alpha1.ResourceSource{{OCI: &akuityv1alpha1.OCISource{
+		URL:       "oci://" + host + "/classes:stable",
+		SecretRef: &akuityv1alpha1.SecretReference{Name: "registry", Namespace: "system"},
+	}}}
+	c := newRolloutTestClientBuilder(scheme).
+		WithObjects(class, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "system"},
+				Type:       corev1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(
+					fmt.Sprintf(`{"auths":{%q:{"username":"robot","password":"token"}}}`, host))},
+			}).
+		Build()
+	artifacts := NewOCIArtifacts()
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10),
+		OCI: artifacts, SecretNamespace: "system"}
+	bindingReconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme,
+		Recorder: record.NewFakeRecorder(10), OCI: artifacts, SecretNamespace: "system"}
+	classReq := ctrl.Request{NamespacedName: k8stypes.NamespacedName{Name: "test-class"}}
+	bindingReq := ctrl.Request{NamespacedName: k8stypes.NamespacedName{Name: "team-a", Namespace: "team-a"}}
+	cpu := func() string {
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "limits", Namespace: "team-a"}, cm))
+		return cm.Data["cpu"]
+	}
+
+	// The class records the digest of the tag and bindings apply it
+	result, err := classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+	assert.Equal(t, defaultSourceInterval, result.RequeueAfter.Round(time.Minute))
+	require.NoError(t, c.Get(ctx, classReq.NamespacedName, class))
+	require.Len(t, class.Status.Sources, 1)
+	assert.Equal(t, first, class.Status.Sources[0].Revision)
+	assert.True(t, meta.IsStatusConditionTrue(class.Status.Conditions, akuityv1alpha1.ClassConditionSourcesReady))
+
+	_, err = bindingReconciler.Reconcile(ctx, bindingReq)
+	require.NoError(t, err)
+	assert.Equal(t, "1", cpu())
+
+	// Moving the tag is picked up on refresh
+	second := pushArtifact(t, host+"/classes:stable", robot, limits("2"))
+	class.Annotations = map[string]string{annotationRefresh: "1"}
+	require.NoError(t, c.Update(ctx, class))
+	_, err = classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, classReq.NamespacedName, class))
+	assert.Equal(t, second, class.Status.Sources[0].Revision)
+
+	_, err = bindingReconciler.Reconcile(ctx, bindingReq)
+	require.NoError(t, err)
+	assert.Equal(t, "2", cpu())
+
+	// A tag the registry doesn't have is reported on the class
+	class.Spec.Sources[0].OCI.URL = "oci://" + host + "/classes:missing"
+	class.Generation = 2
+	require.NoError(t, c.Update(ctx, class))
+	_, err = classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, classReq.NamespacedName, class))
+	cond := meta.FindStatusCondition(class.Status.Conditions, akuityv1alpha1.ClassConditionSourcesReady)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+
+	// Credentials are only read from the secret namespace
+	class.Spec.Sources[0].OCI.URL = "oci://" + host + "/classes:stable"
+	class.Spec.Sources[0].OCI.SecretRef.Namespace = "team-a"
+	class.Generation = 3
+	require.NoError(t, c.Update(ctx, class))
+	_, err = classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, classReq.NamespacedName, class))
+	cond = meta.FindStatusCondition(class.Status.Conditions, akuityv1alpha1.ClassConditionSourcesReady)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Contains(t, cond.Message, "only read from namespace system")
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sort"
+)
+
+const (
+	// defaultMaxParallelApplies is how many resources of one binding are applied at once when no limit
+	// is configured
+	defaultMaxParallelApplies = 8
+
+	// defaultApplyWave is the wave of every kind not listed in applyWaves
+	defaultApplyWave = 3
+)
+
+// applyWaves orders kinds that other resources depend on ahead of them. Resources in the same wave are
+// applied concurrently; a wave only starts once the previous one has been applied.
+var applyWaves = map[string]int{
+	"CustomResourceDefinition": 0,
+	"Namespace":                0,
+
+	"PriorityClass":         1,
+	"StorageClass":          1,
+	"ServiceAccount":        1,
+	"Secret":                1,
+	"ConfigMap":             1,
+	"LimitRange":            1,
+	"ResourceQuota":         1,
+	"PersistentVolumeClaim": 1,
+	"ClusterRole":           1,
+	"Role":                  1,
+
+	"ClusterRoleBinding": 2,
+	"RoleBinding":        2,
+}
+
+// applyWave returns the wave a kind is applied in
+func applyWave(kind string) int {
+	if wave, ok := applyWaves[kind]; ok {
+		return wave
+	}
+	return defaultApplyWave
+}
+
+// applyOrder groups resource indexes into waves, in wave order and keeping class order within a wave
+func applyOrder(resources []parsedResource) [][]int {
+	byWave := map[int][]int{}
+	for i, res := range resources {
+		wave := applyWave(res.Kind)
+		byWave[wave] = append(byWave[wave], i)
+	}
+
+	waves := make([]int, 0, len(byWave))
+	for wave := range byWave {
+		waves = append(waves, wave)
+	}
+	sort.Ints(waves)
+
+	order := make([][]int, 0, len(waves))
+	for _, wave := range waves {
+		order = append(order, byWave[wave])
+	}
+	return order
+}
+
+// maxParallelApplies returns the configured per-binding apply limit or the default
+func (r *NamespaceClassBindingReconciler) maxParallelApplies() int {
+	if r.MaxParallelApplies > 0 {
+		return r.MaxParallelApplies
+	}
+	return defaultMaxParallelApplies
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+func TestApplyOrder(t *testing.T) {
+	resources := []parsedResource{
+		{Kind: "Deployment", Name: "app"},
+		{Kind: "RoleBinding", Name: "edit"},
+		{Kind: "ServiceAccount", Name: "app"},
+		{Kind: "Role", Name: "edit"},
+		{Kind: "Service", Name: "app"},
+		{Kind: "Namespace", Name: "team"},
+	}
+
+	assert.Equal(t, [][]int{{5}, {2, 3}, {1}, {0, 4}}, applyOrder(resources))
+}
+
+func TestNamespaceClassBindingReconciler_ParallelApply(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-ns", Namespace: "test-ns"}}
+
+	// Later resources complete first, so status order can't come from completion order
+	const count = 12
+	class := &akuityv1alpha1.NamespaceClass{ObjectMeta: metav1.ObjectMeta{Name: "test-class", Generation: 1}}
+	for i := 0; i < count; i++ {
+		class.Spec.Resources = append(class.Spec.Resources, runtime.RawExtension{Raw: []byte(fmt.Sprintf(
+			`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "config-%02d"}}`, i))})
+	}
+	binding := &akuityv1alpha1.NamespaceClassBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-ns", Namespace: "test-ns"},
+		Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+	}
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(newTestRESTMapper()).
+		WithStatusSubresource(&akuityv1alpha1.NamespaceClassBinding{}).
+		WithObjects(binding, class).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+				opts ...client.PatchOption) error {
+				if patch != client.Apply {
+					return c.Patch(ctx, obj, patch, opts...)
+				}
+				mu.Lock()
+				inFlight++
+				maxInFlight = max(maxInFlight, inFlight)
+				mu.Unlock()
+
+				var index int
+				_, _ = fmt.Sscanf(obj.GetName(), "config-%d", &index)
+				time.Sleep(time.Duration(count-index) * time.Millisecond)
+
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	reconciler := &NamespaceClassBindingReconciler{
+		Client:             c,
+		Scheme:             scheme,
+		Recorder:           record.NewFakeRecorder(10),
+		MaxParallelApplies: 4,
+	}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	assert.LessOrEqual(t, maxInFlight, 4)
+	assert.Greater(t, maxInFlight, 1, "expected resources to be applied concurrently")
+
+	require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+	require.Len(t, binding.Status.AppliedResources, count)
+	for i, res := range binding.Status.AppliedResources {
+		assert.Equal(t, fmt.Sprintf("config-%02d", i), res.Name)
+	}
+}
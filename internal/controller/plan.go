@@ -0,0 +1,314 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// annotationApprovedPlan is set on a class to the hash of the plan that may be rolled out
+	annotationApprovedPlan = "namespaceclass.akuity.io/approved-plan"
+
+	// reasonAwaitingApproval marks a rollout held until its plan is approved
+	reasonAwaitingApproval = "AwaitingApproval"
+
+	// planHashLength is how many hex digits of the content hash identify a plan
+	planHashLength = 16
+)
+
+// planApproved reports whether the class carries the approval for the plan
+func planApproved(class *akuityv1alpha1.NamespaceClass, plan *akuityv1alpha1.NamespaceClassPlan) bool {
+	return class.Annotations[annotationApprovedPlan] == plan.Spec.Hash
+}
+
+// approvalStatus returns the approval state of the plan of the current content of the resolved class.
+// Content that can't be parsed can't be planned, so it returns no status and the change stays held.
+func (r *NamespaceClassReconciler) approvalStatus(ctx context.Context, class *akuityv1alpha1.NamespaceClass,
+	bindings []akuityv1alpha1.NamespaceClassBinding) (*akuityv1alpha1.PlanStatus, error) {
+	plan, err := r.ensurePlan(ctx, class, bindings)
+	if err != nil {
+		if isPermanentFailure(err) {
+			log.FromContext(ctx).Error(err, "class change can't be planned")
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &akuityv1alpha1.PlanStatus{
+		Name:     plan.Name,
+		Hash:     plan.Spec.Hash,
+		Approved: planApproved(class, plan),
+	}, nil
+}
+
+// ensurePlan returns the plan of the current content of the resolved class, creating it from the state
+// of the bindings the first time the generation and source content are seen. Plans of other content
+// are deleted, so new source content needs a new approval.
+func (r *NamespaceClassReconciler) ensurePlan(ctx context.Context, class *akuityv1alpha1.NamespaceClass,
+	bindings []akuityv1alpha1.NamespaceClassBinding) (*akuityv1alpha1.NamespaceClassPlan, error) {
+	if err := r.prunePlans(ctx, class, class.Generation, sourcesHash(class)); err != nil {
+		return nil, err
+	}
+
+	// Plans use the same naming as revisions; a plan of other source content may still be cached
+	name := revisionName(class.Name, class.Generation)
+	plan := &akuityv1alpha1.NamespaceClassPlan{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, plan); err == nil {
+		if plan.Spec.SourcesHash == sourcesHash(class) {
+			return plan, nil
+		}
+	} else if !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("get plan %s: %w", name, err)
+	}
+
+	namespaces, err := r.namespacePlans(ctx, class, bindings)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := planHash(class.Generation, sourcesHash(class), namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	plan = &akuityv1alpha1.NamespaceClassPlan{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{labelRevisionClass: class.Name},
+		},
+		Spec: akuityv1alpha1.NamespaceClassPlanSpec{
+			ClassName:   class.Name,
+			Generation:  class.Generation,
+			Hash:        hash,
+			Namespaces:  namespaces,
+			SourcesHash: sourcesHash(class),
+		},
+	}
+	if err := controllerutil.SetControllerReference(class, plan, r.Scheme); err != nil {
+		return nil, fmt.Errorf("set ownerRef for plan %s: %w", name, err)
+	}
+	if err := r.Create(ctx, plan); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("create plan %s: %w", name, err)
+		}
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, plan); err != nil {
+			return nil, fmt.Errorf("get plan %s: %w", name, err)
+		}
+	}
+	return plan, nil
+}
+
+// prunePlans deletes the plans of the class other than the one for generation and the source content
+// hashed to sourcesHash; generation 0 deletes them all
+func (r *NamespaceClassReconciler) prunePlans(ctx context.Context, class *akuityv1alpha1.NamespaceClass,
+	generation int64, sourcesHash string) error {
+	var plans akuityv1alpha1.NamespaceClassPlanList
+	if err := r.List(ctx, &plans, client.MatchingLabels{labelRevisionClass: class.Name}); err != nil {
+		return fmt.Errorf("list plans: %w", err)
+	}
+	for i := range plans.Items {
+		plan := &plans.Items[i]
+		if plan.Spec.Generation == generation && plan.Spec.SourcesHash == sourcesHash {
+			continue
+		}
+		if err := r.Delete(ctx, plan); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("delete plan %s: %w", plan.Name, err)
+		}
+	}
+	return nil
+}
+
+// namespacePlans lists what applying the current content of the resolved class would change in every
+// bound namespace, comparing against the revision each binding applied last
+func (r *NamespaceClassReconciler) namespacePlans(ctx context.Context, class *akuityv1alpha1.NamespaceClass,
+	bindings []akuityv1alpha1.NamespaceClassBinding) ([]akuityv1alpha1.NamespacePlan, error) {
+	parsed := parseClass(ctx, r.Renderer, class)
+	if parsed.err != nil {
+		return nil, parsed.err
+	}
+
+	reader := sourceReader(r, r.SourceCache, r.SecretNamespace)
+	fetchers := sourceFetchers{git: r.Git, oci: r.OCI, helm: r.Helm, kustomize: r.Kustomize}
+	// Most bindings observed the same few revisions, so each is parsed once
+	revisions := map[string][]parsedResource{}
+	namespaces := make([]akuityv1alpha1.NamespacePlan, 0, len(bindings))
+	for i := range bindings {
+		binding := &bindings[i]
+
+		var from []parsedResource
+		fromKnown := false
+		if binding.Status.ObservedClassName == class.Name {
+			revision, err := observedRevision(ctx, reader, binding)
+			if err != nil {
+				return nil, err
+			}
+			if revision != nil {
+				if from, fromKnown = revisions[revision.Name]; !fromKnown {
+					if from, err = revisionResources(ctx, reader, fetchers, r.Renderer, revision); err != nil {
+						return nil, fmt.Errorf("parse revision %s: %w", revision.Name, err)
+					}
+					revisions[revision.Name], fromKnown = from, true
+				}
+			}
+		}
+
+		namespaces = append(namespaces, akuityv1alpha1.NamespacePlan{
+			Namespace: binding.Namespace,
+			Changes: planChanges(binding.Status.AppliedResources,
+				renderResources(from, binding.Namespace), renderResources(parsed.resources, binding.Namespace),
+				fromKnown),
+		})
+	}
+
+	sort.Slice(namespaces, func(i, j int) bool { return namespaces[i].Namespace < namespaces[j].Namespace })
+	return namespaces, nil
+}
+
+// observedResources returns the class resources the binding applied last, from the revision it observed.
+// ok is false when that content is no longer known because the revision was pruned, or because the
+// binding applied other source content than the revision recorded.
+func observedResources(ctx context.Context, c client.Reader, fetchers sourceFetchers, renderer Renderer,
+	binding *akuityv1alpha1.NamespaceClassBinding) (resources []parsedResource, ok bool, err error) {
+	revision, err := observedRevision(ctx, c, binding)
+	if err != nil || revision == nil {
+		return nil, false, err
+	}
+	if resources, err = revisionResources(ctx, c, fetchers, renderer, revision); err != nil {
+		return nil, false, fmt.Errorf("parse revision %s: %w", revision.Name, err)
+	}
+	return resources, true, nil
+}
+
+// observedRevision returns the revision the binding applied last, or nil when its content is no
+// longer known
+func observedRevision(ctx context.Context, c client.Reader,
+	binding *akuityv1alpha1.NamespaceClassBinding) (*akuityv1alpha1.NamespaceClassRevision, error) {
+	name := binding.Status.ObservedRevision
+	if name == "" {
+		return nil, nil
+	}
+
+	revision := &akuityv1alpha1.NamespaceClassRevision{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name}, revision); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get revision %s: %w", name, err)
+	}
+	if revision.Spec.ClassName != binding.Status.ObservedClassName ||
+		(revision.Spec.SourcesHash != "" && revision.Spec.SourcesHash != binding.Status.ObservedSourcesHash) {
+		return nil, nil
+	}
+	return revision, nil
+}
+
+// renderResources renders class resources for a bound namespace. Resources in the bound namespace
+// itself get an empty namespace, as in the binding status.
+func renderResources(resources []parsedResource, namespace string) []parsedResource {
+	rendered := make([]parsedResource, 0, len(resources))
+	for _, res := range resources {
+		u := res.Object.DeepCopy()
+		renderPlaceholders(u.Object, namespace)
+		target := renderString(res.Namespace, namespace)
+		if target == namespace {
+			target = ""
+		}
+		rendered = append(rendered, parsedResource{
+			APIVersion: res.APIVersion,
+			Kind:       res.Kind,
+			Namespace:  target,
+			Name:       renderString(res.Name, namespace),
+			Object:     u,
+		})
+	}
+	return rendered
+}
+
+// planChanges lists the resources of to that would be created or updated and the applied resources
+// that would be pruned. Updates list their changed fields when the content applied last is known.
+func planChanges(applied []akuityv1alpha1.AppliedResource, from, to []parsedResource,
+	fromKnown bool) []akuityv1alpha1.ResourceChange {
+	existing := make(map[string]bool, len(applied))
+	for _, res := range applied {
+		// Pending resources were never created
+		if res.Phase != akuityv1alpha1.ResourcePhasePending {
+			existing[getKey(res.APIVersion, res.Kind, res.Namespace, res.Name)] = true
+		}
+	}
+	old := make(map[string]parsedResource, len(from))
+	for _, res := range from {
+		old[getKey(res.APIVersion, res.Kind, res.Namespace, res.Name)] = res
+	}
+
+	var changes []akuityv1alpha1.ResourceChange
+	desired := make(map[string]bool, len(to))
+	for _, res := range to {
+		key := getKey(res.APIVersion, res.Kind, res.Namespace, res.Name)
+		desired[key] = true
+		prev, ok := old[key]
+		switch {
+		case !existing[key]:
+			changes = append(changes, resourceChange(res, akuityv1alpha1.ResourceChangeAdded, nil))
+		case !fromKnown || !ok:
+			changes = append(changes, resourceChange(res, akuityv1alpha1.ResourceChangeChanged, nil))
+		case !equality.Semantic.DeepEqual(prev.Object.Object, res.Object.Object):
+			fields := changedFields("", prev.Object.Object, res.Object.Object)
+			changes = append(changes, resourceChange(res, akuityv1alpha1.ResourceChangeChanged, fields))
+		}
+	}
+	for _, res := range applied {
+		key := getKey(res.APIVersion, res.Kind, res.Namespace, res.Name)
+		if existing[key] && !desired[key] {
+			changes = append(changes, akuityv1alpha1.ResourceChange{
+				APIVersion: res.APIVersion,
+				Kind:       res.Kind,
+				Name:       res.Name,
+				Namespace:  res.Namespace,
+				Type:       akuityv1alpha1.ResourceChangeRemoved,
+			})
+		}
+	}
+	return changes
+}
+
+// planHash returns the hash identifying the changes planned for a class generation and source content
+func planHash(generation int64, sourcesHash string, namespaces []akuityv1alpha1.NamespacePlan) (string, error) {
+	b, err := json.Marshal(struct {
+		Generation  int64                          `json:"generation"`
+		SourcesHash string                         `json:"sourcesHash,omitempty"`
+		Namespaces  []akuityv1alpha1.NamespacePlan `json:"namespaces"`
+	}{generation, sourcesHash, namespaces})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:planHashLength], nil
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+func TestPlanChanges(t *testing.T) {
+	from := parseClass(context.Background(), nil, settingsClass(1, "1"))
+	to := parseClass(context.Background(), nil, settingsClass(2, "2", runtime.RawExtension{
+		Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "$(NAMESPACE)-extra"}}`),
+	}))
+	require.NoError(t, from.err)
+	require.NoError(t, to.err)
+	applied := []akuityv1alpha1.AppliedResource{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "settings"},
+		{APIVersion: "v1", Kind: "Secret", Name: "leftover"},
+	}
+
+	changes := planChanges(applied, renderResources(from.resources, "team-a"),
+		renderResources(to.resources, "team-a"), true)
+	require.Len(t, changes, 3)
+	assert.Equal(t, akuityv1alpha1.ResourceChangeChanged, changes[0].Type)
+	assert.Equal(t, []string{"data.version"}, changes[0].Fields)
+	assert.Equal(t, akuityv1alpha1.ResourceChangeAdded, changes[1].Type)
+	assert.Equal(t, "team-a-extra", changes[1].Name)
+	assert.Equal(t, akuityv1alpha1.ResourceChangeRemoved, changes[2].Type)
+	assert.Equal(t, "leftover", changes[2].Name)
+
+	// Without the previous content an applied resource may have changed in any field
+	changes = planChanges(applied[:1], nil, renderResources(from.resources, "team-a"), false)
+	require.Len(t, changes, 1)
+	assert.Equal(t, akuityv1alpha1.ResourceChangeChanged, changes[0].Type)
+	assert.Empty(t, changes[0].Fields)
+}
+
+// countingRenderer renders classes as they are written and counts how often it was asked to
+type countingRenderer struct {
+	renders int
+}
+
+func (r *countingRenderer) Render(ctx context.Context,
+	class *akuityv1alpha1.NamespaceClass) ([]*unstructured.Unstructured, error) {
+	r.renders++
+	return manifestRenderer{}.Render(ctx, class)
+}
+
+func TestNamespaceClassReconciler_NamespacePlansParseRevisionsOnce(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+
+	observed := settingsClass(1, "1")
+	revision := &akuityv1alpha1.NamespaceClassRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-class-1"},
+		Spec:       akuityv1alpha1.NamespaceClassRevisionSpec{ClassName: "test-class", Generation: 1, Class: observed.Spec},
+	}
+	var bindings []akuityv1alpha1.NamespaceClassBinding
+	for _, name := range []string{"team-a", "team-b", "team-c"} {
+		bindings = append(bindings, akuityv1alpha1.NamespaceClassBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: name},
+			Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			Status: akuityv1alpha1.NamespaceClassBindingStatus{
+				ObservedClassName: "test-class",
+				ObservedRevision:  "test-class-1",
+				AppliedResources:  []akuityv1alpha1.AppliedResource{{APIVersion: "v1", Kind: "ConfigMap", Name: "settings"}},
+			},
+		})
+	}
+	c := newRolloutTestClientBuilder(scheme).WithObjects(revision).Build()
+	renderer := &countingRenderer{}
+	r := &NamespaceClassReconciler{Client: c, Scheme: scheme, Renderer: renderer}
+
+	namespaces, err := r.namespacePlans(ctx, settingsClass(2, "2"), bindings)
+	require.NoError(t, err)
+	require.Len(t, namespaces, 3)
+	for _, namespace := range namespaces {
+		require.Len(t, namespace.Changes, 1)
+		assert.Equal(t, []string{"data.version"}, namespace.Changes[0].Fields)
+	}
+	// The class once, and the revision every binding observed once
+	assert.Equal(t, 2, renderer.renders)
+}
+
+func TestNamespaceClassReconciler_RequireApproval(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+
+	class := settingsClass(1, "1")
+	class.Spec.RequireApproval = true
+	objs := []client.Object{class}
+	for _, name := range []string{"team-a", "team-b"} {
+		objs = append(objs,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: name},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			},
+		)
+	}
+	c := newRolloutTestClientBuilder(scheme).WithObjects(objs...).Build()
+	recorder := record.NewFakeRecorder(10)
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: recorder}
+	bindingReconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(30)}
+	version := func(namespace string) string {
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: namespace}, cm))
+		return cm.Data["version"]
+	}
+
+	// Newly bound namespaces apply the class without approval
+	current := reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a", "team-b")
+	assert.Equal(t, "1", version("team-a"))
+	assert.Equal(t, int32(2), current.Status.Rollout.Updated)
+
+	// A change is planned per namespace and held
+	current.Generation = 2
+	current.Spec = settingsClass(2, "2").Spec
+	current.Spec.RequireApproval = true
+	require.NoError(t, c.Update(ctx, current))
+	current = reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a", "team-b")
+	assert.Equal(t, "1", version("team-a"))
+	cond := meta.FindStatusCondition(current.Status.Conditions, akuityv1alpha1.ClassConditionRolloutProgressing)
+	require.NotNil(t, cond)
+	assert.Equal(t, reasonAwaitingApproval, cond.Reason)
+	select {
+	case event := <-recorder.Events:
+		assert.Contains(t, event, reasonAwaitingApproval)
+	default:
+		t.Error("expected an AwaitingApproval event")
+	}
+
+	plan := &akuityv1alpha1.NamespaceClassPlan{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "test-class-2"}, plan))
+	require.Len(t, plan.Spec.Namespaces, 2)
+	assert.Equal(t, "team-a", plan.Spec.Namespaces[0].Namespace)
+	require.Len(t, plan.Spec.Namespaces[0].Changes, 1)
+	assert.Equal(t, []string{"data.version"}, plan.Spec.Namespaces[0].Changes[0].Fields)
+	require.NotNil(t, current.Status.Plan)
+	assert.Equal(t, plan.Spec.Hash, current.Status.Plan.Hash)
+	assert.False(t, current.Status.Plan.Approved)
+
+	// Only the hash of the current plan approves it
+	current.Annotations = map[string]string{annotationApprovedPlan: "0123456789abcdef"}
+	require.NoError(t, c.Update(ctx, current))
+	current = reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a", "team-b")
+	assert.Equal(t, "1", version("team-a"))
+
+	current.Annotations[annotationApprovedPlan] = plan.Spec.Hash
+	require.NoError(t, c.Update(ctx, current))
+	current = reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a", "team-b")
+	assert.True(t, current.Status.Plan.Approved)
+	assert.Equal(t, "2", version("team-a"))
+	assert.Equal(t, "2", version("team-b"))
+
+	// Plans of earlier generations go away
+	var plans akuityv1alpha1.NamespaceClassPlanList
+	require.NoError(t, c.List(ctx, &plans))
+	require.Len(t, plans.Items, 1)
+	assert.Equal(t, "test-class-2", plans.Items[0].Name)
+}
+
+func TestNamespaceClassReconciler_RequireApprovalSourceChange(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "resources", Namespace: "platform"},
+		Data: map[string]string{"resources.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: limits\n" +
+			"data:\n  cpu: \"1\"\n"},
+	}
+	class := settingsClass(1, "1")
+	class.Spec.RequireApproval = true
+	class.Spec.Sources = []akuityv1alpha1.ResourceSource{
+		{ConfigMap: &akuityv1alpha1.ConfigMapSource{Name: "resources", Namespace: "platform"}},
+	}
+	c := newRolloutTestClientBuilder(scheme).
+		WithObjects(class, source, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			}).
+		Build()
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+	bindingReconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(30)}
+	cpu := func() string {
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "limits", Namespace: "team-a"}, cm))
+		return cm.Data["cpu"]
+	}
+	setCPU := func(value string) {
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(source), source))
+		source.Data["resources.yaml"] = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: limits\n" +
+			"data:\n  cpu: \"" + value + "\"\n"
+		require.NoError(t, c.Update(ctx, source))
+	}
+
+	current := reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a")
+	assert.Equal(t, "1", cpu())
+
+	// New source content is planned and held like a new generation
+	setCPU("2")
+	current = reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a")
+	assert.Equal(t, "1", cpu())
+	require.NotNil(t, current.Status.Plan)
+	assert.False(t, current.Status.Plan.Approved)
+	approved := current.Status.Plan.Hash
+
+	// Content that changes again needs a new approval
+	setCPU("3")
+	current.Annotations = map[string]string{annotationApprovedPlan: approved}
+	require.NoError(t, c.Update(ctx, current))
+	current = reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a")
+	assert.Equal(t, "1", cpu())
+	require.NotNil(t, current.Status.Plan)
+	assert.NotEqual(t, approved, current.Status.Plan.Hash)
+	assert.False(t, current.Status.Plan.Approved)
+	plan := &akuityv1alpha1.NamespaceClassPlan{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "test-class-1"}, plan))
+	assert.Equal(t, current.Status.Rollout.SourcesHash, plan.Spec.SourcesHash)
+
+	// The plan diffs the source resources against the content the revision recorded
+	require.Len(t, plan.Spec.Namespaces, 1)
+	assert.Equal(t, []akuityv1alpha1.ResourceChange{{
+		APIVersion: "v1", Kind: "ConfigMap", Name: "limits",
+		Type: akuityv1alpha1.ResourceChangeChanged, Fields: []string{"data.cpu"},
+	}}, plan.Spec.Namespaces[0].Changes)
+
+	current.Annotations[annotationApprovedPlan] = current.Status.Plan.Hash
+	require.NoError(t, c.Update(ctx, current))
+	reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a")
+	assert.Equal(t, "3", cpu())
+}
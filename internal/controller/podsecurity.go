@@ -0,0 +1,172 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	psaapi "k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// reasonPodSecurityViolations marks running pods that don't meet the Pod Security level of the class
+	reasonPodSecurityViolations = "PodSecurityViolations"
+)
+
+// podSecurityEvaluator evaluates pods with the upstream Pod Security checks
+var podSecurityEvaluator = sync.OnceValues(func() (policy.Evaluator, error) {
+	return policy.NewEvaluator(policy.DefaultChecks())
+})
+
+// podSecurityDefaults is the policy of namespaces without Pod Security labels
+var podSecurityDefaults = psaapi.Policy{
+	Enforce: psaapi.LevelVersion{Level: psaapi.LevelPrivileged, Version: psaapi.LatestVersion()},
+	Audit:   psaapi.LevelVersion{Level: psaapi.LevelPrivileged, Version: psaapi.LatestVersion()},
+	Warn:    psaapi.LevelVersion{Level: psaapi.LevelPrivileged, Version: psaapi.LatestVersion()},
+}
+
+// podSecurityPreflight checks the running pods of the binding's namespace against the Pod Security level
+// the class would enforce there. tightened is false, and no pods are checked, when the class doesn't make
+// the enforced level stricter than it is now.
+func (r *NamespaceClassBindingReconciler) podSecurityPreflight(ctx context.Context,
+	binding *akuityv1alpha1.NamespaceClassBinding,
+	class *akuityv1alpha1.NamespaceClass) (violations []akuityv1alpha1.PodSecurityViolation, tightened bool, err error) {
+	resources, err := r.classResources(ctx, class)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The class sets Pod Security labels through its namespace metadata or a Namespace resource for the
+	// bound namespace
+	classLabels := map[string]string{}
+	for _, res := range resources {
+		if res.APIVersion == "v1" && res.Kind == "Namespace" && renderString(res.Name, binding.Namespace) == binding.Namespace {
+			maps.Copy(classLabels, res.Object.GetLabels())
+		}
+	}
+	metadataLabels, _, err := desiredNamespaceMetadata(binding, class)
+	if err != nil {
+		return nil, false, err
+	}
+	maps.Copy(classLabels, metadataLabels)
+	if len(classLabels) == 0 {
+		return nil, false, nil
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: binding.Namespace}, namespace); err != nil {
+		return nil, false, fmt.Errorf("get namespace %s: %w", binding.Namespace, err)
+	}
+	current, _ := psaapi.PolicyToEvaluate(namespace.Labels, podSecurityDefaults)
+
+	targetLabels := maps.Clone(namespace.Labels)
+	if targetLabels == nil {
+		targetLabels = map[string]string{}
+	}
+	for key, value := range classLabels {
+		targetLabels[key] = renderString(value, binding.Namespace)
+	}
+	// Invalid labels make the API server enforce restricted, which the returned policy reflects
+	target, _ := psaapi.PolicyToEvaluate(targetLabels, podSecurityDefaults)
+
+	cmp := psaapi.CompareLevels(target.Enforce.Level, current.Enforce.Level)
+	if cmp < 0 || (cmp == 0 && target.Enforce.Equivalent(&current.Enforce)) {
+		return nil, false, nil
+	}
+
+	evaluator, err := podSecurityEvaluator()
+	if err != nil {
+		return nil, false, err
+	}
+	// Pods are listed uncached; a pod informer would hold every pod in the cluster just for this check
+	var pods corev1.PodList
+	if err := r.apiReader().List(ctx, &pods, client.InNamespace(binding.Namespace)); err != nil {
+		return nil, false, fmt.Errorf("list pods: %w", err)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		// Finished pods don't restart
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		result := policy.AggregateCheckResults(evaluator.EvaluatePod(target.Enforce, &pod.ObjectMeta, &pod.Spec))
+		if result.Allowed {
+			continue
+		}
+		reasons := make([]string, len(result.ForbiddenReasons))
+		for j, reason := range result.ForbiddenReasons {
+			reasons[j] = reason
+			if detail := result.ForbiddenDetails[j]; detail != "" {
+				reasons[j] = fmt.Sprintf("%s (%s)", reason, detail)
+			}
+		}
+		violations = append(violations, akuityv1alpha1.PodSecurityViolation{
+			Pod:     pod.Name,
+			Level:   target.Enforce.String(),
+			Reasons: reasons,
+		})
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].Pod < violations[j].Pod })
+	return violations, true, nil
+}
+
+// apiReader returns the reader for objects that aren't cached
+func (r *NamespaceClassBindingReconciler) apiReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// setPodSecurityStatus records the outcome of a Pod Security pre-flight on the binding
+func setPodSecurityStatus(b *akuityv1alpha1.NamespaceClassBinding, violations []akuityv1alpha1.PodSecurityViolation) {
+	b.Status.PodSecurityViolations = violations
+	if len(violations) == 0 {
+		meta.RemoveStatusCondition(&b.Status.Conditions, akuityv1alpha1.BindingConditionPodSecurityViolations)
+		return
+	}
+	meta.SetStatusCondition(&b.Status.Conditions, metav1.Condition{
+		Type:               akuityv1alpha1.BindingConditionPodSecurityViolations,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonPodSecurityViolations,
+		Message:            podSecuritySummary(violations),
+		ObservedGeneration: b.Generation,
+	})
+}
+
+// podSecuritySummary describes the violating pods in one line
+func podSecuritySummary(violations []akuityv1alpha1.PodSecurityViolation) string {
+	pods := make([]string, len(violations))
+	for i, violation := range violations {
+		pods[i] = violation.Pod
+	}
+	return fmt.Sprintf("%d pods don't meet Pod Security level %s: %s", len(violations), violations[0].Level,
+		strings.Join(pods, ", "))
+}
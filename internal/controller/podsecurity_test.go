@@ -0,0 +1,156 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+func TestNamespaceClassBindingReconciler_PodSecurityPreflight(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-ns", Namespace: "test-ns"}}
+
+	newObjects := func(mode akuityv1alpha1.PodSecurityPreflightMode) []client.Object {
+		container := func(securityContext *corev1.SecurityContext) []corev1.Container {
+			return []corev1.Container{{Name: "app", Image: "app", SecurityContext: securityContext}}
+		}
+		privileged := container(&corev1.SecurityContext{Privileged: ptr.To(true)})
+		return []client.Object{
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-ns", Namespace: "test-ns"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			},
+			&akuityv1alpha1.NamespaceClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-class", Generation: 1},
+				Spec: akuityv1alpha1.NamespaceClassSpec{
+					AllowClusterScoped:   true,
+					PodSecurityPreflight: mode,
+					Resources: []runtime.RawExtension{
+						{Raw: []byte(`{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "$(NAMESPACE)",
+							"labels": {"pod-security.kubernetes.io/enforce": "restricted"}}}`)},
+						{Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings"}}`)},
+					},
+				},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "test-ns"},
+				Spec:       corev1.PodSpec{Containers: privileged},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "finished", Namespace: "test-ns"},
+				Spec:       corev1.PodSpec{Containers: privileged},
+				Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "compliant", Namespace: "test-ns"},
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsNonRoot:   ptr.To(true),
+						SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+					},
+					Containers: container(&corev1.SecurityContext{
+						AllowPrivilegeEscalation: ptr.To(false),
+						Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+					}),
+				},
+			},
+		}
+	}
+
+	t.Run("reports pods that don't meet the stricter level", func(t *testing.T) {
+		ctx := context.Background()
+		reconciler, c := newBindingTestReconciler(scheme, newObjects(akuityv1alpha1.PodSecurityPreflightReport)...)
+		reader := &podListReader{Reader: c}
+		reconciler.APIReader = reader
+
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, 1, reader.lists, "pods are listed through the API reader")
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: "test-ns"}, &corev1.ConfigMap{}))
+
+		binding := &akuityv1alpha1.NamespaceClassBinding{}
+		require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+		require.Len(t, binding.Status.PodSecurityViolations, 1)
+		violation := binding.Status.PodSecurityViolations[0]
+		assert.Equal(t, "legacy", violation.Pod)
+		assert.Equal(t, "restricted:latest", violation.Level)
+		assert.Contains(t, violation.Reasons[0], "privileged")
+		assert.True(t, meta.IsStatusConditionTrue(binding.Status.Conditions,
+			akuityv1alpha1.BindingConditionPodSecurityViolations))
+		assert.False(t, meta.IsStatusConditionTrue(binding.Status.Conditions, akuityv1alpha1.BindingConditionDegraded))
+	})
+
+	t.Run("blocks the change until the pods comply", func(t *testing.T) {
+		ctx := context.Background()
+		reconciler, c := newBindingTestReconciler(scheme, newObjects(akuityv1alpha1.PodSecurityPreflightBlock)...)
+
+		result, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.NotZero(t, result.RequeueAfter)
+		err = c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: "test-ns"}, &corev1.ConfigMap{})
+		assert.True(t, errors.IsNotFound(err))
+		assert.Contains(t, degradedMessage(t, c, req.NamespacedName), "legacy")
+
+		binding := &akuityv1alpha1.NamespaceClassBinding{}
+		require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+		require.Len(t, binding.Status.PodSecurityViolations, 1)
+
+		// Once the pod is gone the retry applies the class and clears the violations
+		require.NoError(t, c.Delete(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "test-ns"}}))
+		past := metav1.NewTime(time.Now().Add(-time.Minute))
+		binding.Status.NextRetryTime = &past
+		require.NoError(t, c.Status().Update(ctx, binding))
+
+		_, err = reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: "test-ns"}, &corev1.ConfigMap{}))
+		require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+		assert.Empty(t, binding.Status.PodSecurityViolations)
+		assert.Nil(t, meta.FindStatusCondition(binding.Status.Conditions,
+			akuityv1alpha1.BindingConditionPodSecurityViolations))
+	})
+}
+
+// podListReader counts the pod lists read through it
+type podListReader struct {
+	client.Reader
+	lists int
+}
+
+func (r *podListReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if _, ok := list.(*corev1.PodList); ok {
+		r.lists++
+	}
+	return r.Reader.List(ctx, list, opts...)
+}
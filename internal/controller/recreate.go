@@ -0,0 +1,169 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// defaultRecreateTimeout is how long a recreation may wait for the old object when none is configured
+	defaultRecreateTimeout = 30 * time.Second
+
+	// recreatePollInterval is how soon a binding is reconciled again while a recreation is in flight
+	recreatePollInterval = 2 * time.Second
+
+	// reasonImmutableFieldChanged marks resources deleted so an immutable field change can be applied
+	reasonImmutableFieldChanged = "ImmutableFieldChanged"
+
+	// reasonRecreateTimeout marks recreations whose old object outlived the recreate timeout
+	reasonRecreateTimeout = "RecreateTimeout"
+
+	// reasonRecreatingResources is the Progressing reason while recreations are in flight
+	reasonRecreatingResources = "RecreatingResources"
+
+	// reasonReconciled is the condition reason once every resource is applied
+	reasonReconciled = "Reconciled"
+)
+
+// errRecreateStarted is returned by applyResourceSSA after it deleted a resource for recreation
+var errRecreateStarted = stderrors.New("resource deleted for recreation")
+
+// isRecreateStarted reports whether err means a recreation was started
+func isRecreateStarted(err error) bool {
+	return stderrors.Is(err, errRecreateStarted)
+}
+
+// recreateTimeout returns the configured recreate timeout or the default
+func (r *NamespaceClassBindingReconciler) recreateTimeout() time.Duration {
+	if r.RecreateTimeout > 0 {
+		return r.RecreateTimeout
+	}
+	return defaultRecreateTimeout
+}
+
+// startRecreate deletes a resource whose immutable fields changed so a later reconcile can recreate it
+func (r *NamespaceClassBindingReconciler) startRecreate(ctx context.Context, u *unstructured.Unstructured) error {
+	logger := log.FromContext(ctx)
+
+	// Check if we're the controller owner before deleting
+	if !r.isControllerOwned(ctx, u) {
+		return fmt.Errorf("cannot recreate resource %s/%s: not owned by this controller",
+			u.GetKind(), u.GetName())
+	}
+
+	logger.Info("deleting resource to recreate it due to immutable field changes",
+		"kind", u.GetKind(), "name", u.GetName())
+
+	if err := r.Delete(ctx, u); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete resource for recreation: %w", err)
+	}
+
+	return errRecreateStarted
+}
+
+// recreateReady reports whether the old object of a recreation is gone
+func (r *NamespaceClassBindingReconciler) recreateReady(ctx context.Context, u *unstructured.Unstructured) (bool, error) {
+	check := &unstructured.Unstructured{}
+	check.SetGroupVersionKind(u.GroupVersionKind())
+
+	if err := r.Get(ctx, client.ObjectKeyFromObject(u), check); err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+// recreatingEntry marks entry as Recreating since startedAt, flagging it once the timeout has passed
+func (r *NamespaceClassBindingReconciler) recreatingEntry(entry akuityv1alpha1.AppliedResource,
+	startedAt metav1.Time) akuityv1alpha1.AppliedResource {
+	entry.Phase = akuityv1alpha1.ResourcePhaseRecreating
+	entry.RecreateStartedAt = &startedAt
+	entry.Reason = reasonImmutableFieldChanged
+	if time.Since(startedAt.Time) > r.recreateTimeout() {
+		entry.Reason = reasonRecreateTimeout
+	}
+	return entry
+}
+
+// hasRecreatingResources reports whether the binding is waiting on recreations
+func hasRecreatingResources(binding *akuityv1alpha1.NamespaceClassBinding) bool {
+	for _, res := range binding.Status.AppliedResources {
+		if res.Phase == akuityv1alpha1.ResourcePhaseRecreating {
+			return true
+		}
+	}
+	return false
+}
+
+// setRecreateConditions reports in-flight and timed out recreations as binding conditions
+func setRecreateConditions(binding *akuityv1alpha1.NamespaceClassBinding,
+	resources []akuityv1alpha1.AppliedResource) {
+	var recreating, timedOut []string
+	for _, res := range resources {
+		if res.Phase != akuityv1alpha1.ResourcePhaseRecreating {
+			continue
+		}
+		recreating = append(recreating, res.Kind+"/"+res.Name)
+		if res.Reason == reasonRecreateTimeout {
+			timedOut = append(timedOut, res.Kind+"/"+res.Name)
+		}
+	}
+
+	progressing := metav1.Condition{
+		Type:               akuityv1alpha1.BindingConditionProgressing,
+		Status:             metav1.ConditionFalse,
+		Reason:             reasonReconciled,
+		Message:            "All resources are applied",
+		ObservedGeneration: binding.Generation,
+	}
+	if len(recreating) > 0 {
+		progressing.Status = metav1.ConditionTrue
+		progressing.Reason = reasonRecreatingResources
+		progressing.Message = "Waiting for deletion before recreating " + strings.Join(recreating, ", ")
+	}
+	meta.SetStatusCondition(&binding.Status.Conditions, progressing)
+
+	degraded := metav1.Condition{
+		Type:               akuityv1alpha1.BindingConditionDegraded,
+		Status:             metav1.ConditionFalse,
+		Reason:             reasonReconciled,
+		Message:            "All resources are applied",
+		ObservedGeneration: binding.Generation,
+	}
+	if len(timedOut) > 0 {
+		degraded.Status = metav1.ConditionTrue
+		degraded.Reason = reasonRecreateTimeout
+		degraded.Message = "Timed out waiting for deletion of " + strings.Join(timedOut, ", ")
+	}
+	meta.SetStatusCondition(&binding.Status.Conditions, degraded)
+}
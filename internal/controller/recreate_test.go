@@ -0,0 +1,158 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+func TestNamespaceClassBindingReconciler_Recreate(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-ns", Namespace: "test-ns"}}
+	immutable := errors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "settings", field.ErrorList{
+		field.Invalid(field.NewPath("data"), nil, "field is immutable"),
+	})
+
+	newObjects := func(finalizers ...string) []client.Object {
+		binding := &akuityv1alpha1.NamespaceClassBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-ns", Namespace: "test-ns", UID: "binding-uid"},
+			Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+		}
+		return []client.Object{
+			binding,
+			&akuityv1alpha1.NamespaceClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-class", Generation: 1},
+				Spec: akuityv1alpha1.NamespaceClassSpec{
+					Resources: []runtime.RawExtension{{Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap",
+						"metadata": {"name": "settings"}, "data": {"mode": "new"}}`)}},
+				},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "settings",
+					Namespace:  "test-ns",
+					Finalizers: finalizers,
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: "akuity.io/v1alpha1",
+						Kind:       "NamespaceClassBinding",
+						Name:       "test-ns",
+						UID:        "binding-uid",
+						Controller: &[]bool{true}[0],
+					}},
+				},
+				Data: map[string]string{"mode": "old"},
+			},
+		}
+	}
+
+	// newReconciler rejects the first apply of the ConfigMap as an immutable field change
+	newReconciler := func(objs ...client.Object) (*NamespaceClassBindingReconciler, client.Client) {
+		rejected := false
+		c := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithRESTMapper(newTestRESTMapper()).
+			WithStatusSubresource(&akuityv1alpha1.NamespaceClassBinding{}).
+			WithObjects(objs...).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+					opts ...client.PatchOption) error {
+					if obj.GetName() == "settings" && !rejected {
+						rejected = true
+						return immutable
+					}
+					return c.Patch(ctx, obj, patch, opts...)
+				},
+			}).
+			Build()
+		return &NamespaceClassBindingReconciler{
+			Client:   c,
+			Scheme:   scheme,
+			Recorder: record.NewFakeRecorder(10),
+		}, c
+	}
+
+	t.Run("deletes, requeues and recreates on a later reconcile", func(t *testing.T) {
+		ctx := context.Background()
+		reconciler, c := newReconciler(newObjects()...)
+
+		result, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, recreatePollInterval, result.RequeueAfter)
+
+		binding := &akuityv1alpha1.NamespaceClassBinding{}
+		require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+		require.Len(t, binding.Status.AppliedResources, 1)
+		assert.Equal(t, akuityv1alpha1.ResourcePhaseRecreating, binding.Status.AppliedResources[0].Phase)
+		assert.True(t, meta.IsStatusConditionTrue(binding.Status.Conditions,
+			akuityv1alpha1.BindingConditionProgressing))
+
+		result, err = reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, ctrl.Result{}, result)
+
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: "test-ns"}, cm))
+		assert.Equal(t, "new", cm.Data["mode"])
+
+		require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+		assert.Equal(t, akuityv1alpha1.ResourcePhaseApplied, binding.Status.AppliedResources[0].Phase)
+		assert.True(t, meta.IsStatusConditionFalse(binding.Status.Conditions,
+			akuityv1alpha1.BindingConditionProgressing))
+	})
+
+	t.Run("reports Degraded when the old object outlives the timeout", func(t *testing.T) {
+		ctx := context.Background()
+		reconciler, c := newReconciler(newObjects("example.com/stuck")...)
+		reconciler.RecreateTimeout = time.Nanosecond
+
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		result, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, recreatePollInterval, result.RequeueAfter)
+
+		binding := &akuityv1alpha1.NamespaceClassBinding{}
+		require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+		assert.Equal(t, reasonRecreateTimeout, binding.Status.AppliedResources[0].Reason)
+
+		degraded := meta.FindStatusCondition(binding.Status.Conditions, akuityv1alpha1.BindingConditionDegraded)
+		require.NotNil(t, degraded)
+		assert.Equal(t, metav1.ConditionTrue, degraded.Status)
+		assert.Equal(t, reasonRecreateTimeout, degraded.Reason)
+		assert.Contains(t, degraded.Message, "ConfigMap/settings")
+	})
+}
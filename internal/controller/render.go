@@ -0,0 +1,54 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+)
+
+// placeholderNamespace is replaced with the name of the bound namespace in every string value
+const placeholderNamespace = "$(NAMESPACE)"
+
+// renderString substitutes namespace placeholders in s
+func renderString(s, namespace string) string {
+	return strings.ReplaceAll(s, placeholderNamespace, namespace)
+}
+
+// renderPlaceholders substitutes namespace placeholders in all string values of an unstructured object
+func renderPlaceholders(obj map[string]interface{}, namespace string) {
+	for k, v := range obj {
+		obj[k] = renderValue(v, namespace)
+	}
+}
+
+// renderValue substitutes namespace placeholders in a single unstructured value
+func renderValue(v interface{}, namespace string) interface{} {
+	switch t := v.(type) {
+	case string:
+		return renderString(t, namespace)
+	case map[string]interface{}:
+		renderPlaceholders(t, namespace)
+		return t
+	case []interface{}:
+		for i, e := range t {
+			t[i] = renderValue(e, namespace)
+		}
+		return t
+	default:
+		return v
+	}
+}
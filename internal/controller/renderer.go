@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// errMissingObjectMeta rejects resources that can't be identified
+var errMissingObjectMeta = stderrors.New("invalid resource: apiVersion, kind and metadata.name are required")
+
+// Renderer turns the resources of a class, with its sources resolved, into the objects applied to
+// bound namespaces. Objects may still hold the $(NAMESPACE) placeholder; it is filled in for every
+// namespace afterwards. Errors that only a class change can fix should be marked permanent.
+type Renderer interface {
+	Render(ctx context.Context, class *akuityv1alpha1.NamespaceClass) ([]*unstructured.Unstructured, error)
+}
+
+// manifestRenderer renders the resources of a class as they are written. It is used when no
+// renderer is configured and can't run functions.
+type manifestRenderer struct{}
+
+func (manifestRenderer) Render(_ context.Context,
+	class *akuityv1alpha1.NamespaceClass) ([]*unstructured.Unstructured, error) {
+	if len(class.Spec.Functions) > 0 {
+		return nil, permanent(stderrors.New("KRM functions are not enabled"))
+	}
+	return manifestObjects(class.Spec.Resources)
+}
+
+// KRMRenderer renders classes by running their functions over their resources. Only the functions
+// it was configured with at startup can run.
+type KRMRenderer struct {
+	// Functions are the allowlisted functions by the name classes refer to them with
+	Functions map[string]*ExecFunction
+}
+
+func (k *KRMRenderer) Render(ctx context.Context,
+	class *akuityv1alpha1.NamespaceClass) ([]*unstructured.Unstructured, error) {
+	objects, err := manifestObjects(class.Spec.Resources)
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range class.Spec.Functions {
+		exec, ok := k.Functions[fn.Name]
+		if !ok {
+			return nil, permanent(fmt.Errorf("function %q is not allowlisted", fn.Name))
+		}
+		if objects, err = exec.Run(ctx, objects, fn.Config); err != nil {
+			return nil, fmt.Errorf("function %q: %w", fn.Name, err)
+		}
+	}
+	return objects, nil
+}
+
+// classRenderer returns the configured renderer, or renders resources as written without one
+func classRenderer(renderer Renderer) Renderer {
+	if renderer == nil {
+		return manifestRenderer{}
+	}
+	return renderer
+}
+
+// manifestObjects parses raw class resources into Unstructured, preserving arbitrary fields
+func manifestObjects(resources []runtime.RawExtension) ([]*unstructured.Unstructured, error) {
+	objects := make([]*unstructured.Unstructured, 0, len(resources))
+	for _, raw := range resources {
+		apiVersion, kind, _, name, err := extractMetaOnly(raw)
+		if err != nil {
+			return nil, permanent(fmt.Errorf("invalid resource: extract meta: %w", err))
+		}
+		if apiVersion == "" || kind == "" || name == "" {
+			return nil, permanent(errMissingObjectMeta)
+		}
+
+		u := &unstructured.Unstructured{}
+		if len(raw.Raw) > 0 {
+			if err := u.UnmarshalJSON(raw.Raw); err != nil {
+				return nil, permanent(fmt.Errorf("unmarshal raw object %s %s: %w", kind, name, err))
+			}
+		} else {
+			m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(raw.Object)
+			if err != nil {
+				return nil, permanent(fmt.Errorf("to-unstructured %s %s: %w", kind, name, err))
+			}
+			u.Object = m
+		}
+		objects = append(objects, u)
+	}
+	return objects, nil
+}
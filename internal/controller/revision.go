@@ -0,0 +1,394 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// labelRevisionClass labels a NamespaceClassRevision with the name of its class
+	labelRevisionClass = "namespaceclass.akuity.io/class"
+
+	// annotationCompareTo is set on a revision to compare it with another revision of its class
+	annotationCompareTo = "namespaceclass.akuity.io/compare-to"
+
+	// revisionLatest is the binding revision that follows the current class generation
+	revisionLatest = "latest"
+
+	// defaultRevisionHistoryLimit is how many revisions are kept when the class doesn't say
+	defaultRevisionHistoryLimit = 10
+)
+
+// revisionName returns the name of the revision of a class generation
+func revisionName(className string, generation int64) string {
+	return fmt.Sprintf("%s-%d", className, generation)
+}
+
+// pinnedRevision returns the revision the binding is pinned to, or "" when it follows the class
+func pinnedRevision(binding *akuityv1alpha1.NamespaceClassBinding) string {
+	if binding.Spec.Revision == revisionLatest {
+		return ""
+	}
+	return binding.Spec.Revision
+}
+
+// followingBindings returns the bindings that follow the current class generation
+func followingBindings(bindings []akuityv1alpha1.NamespaceClassBinding) []akuityv1alpha1.NamespaceClassBinding {
+	following := make([]akuityv1alpha1.NamespaceClassBinding, 0, len(bindings))
+	for _, binding := range bindings {
+		if pinnedRevision(&binding) == "" {
+			following = append(following, binding)
+		}
+	}
+	return following
+}
+
+// ensureRevision snapshots the current generation of the class into its revision, along with the
+// revisions its fetched sources resolved to and the resources its sources hold, taken from the class
+// resolved with its sources. It waits until every fetched source has resolved.
+func (r *NamespaceClassReconciler) ensureRevision(ctx context.Context, class,
+	resolved *akuityv1alpha1.NamespaceClass) error {
+	sources, ok := resolvedSources(class)
+	if !ok {
+		return nil
+	}
+
+	name := revisionName(class.Name, class.Generation)
+	revision := &akuityv1alpha1.NamespaceClassRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{labelRevisionClass: class.Name},
+		},
+		Spec: akuityv1alpha1.NamespaceClassRevisionSpec{
+			ClassName:  class.Name,
+			Generation: class.Generation,
+			Class:      *class.Spec.DeepCopy(),
+			Sources:    sources,
+		},
+	}
+	if len(class.Spec.Sources) > 0 {
+		for _, raw := range resolved.Spec.Resources[len(class.Spec.Resources):] {
+			revision.Spec.SourceResources = append(revision.Spec.SourceResources, *raw.DeepCopy())
+		}
+		revision.Spec.SourcesHash = sourcesHash(resolved)
+	}
+	if err := controllerutil.SetControllerReference(class, revision, r.Scheme); err != nil {
+		return fmt.Errorf("set ownerRef for revision %s: %w", name, err)
+	}
+	err := r.Create(ctx, revision)
+	if err == nil {
+		return nil
+	}
+	if !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("create revision %s: %w", name, err)
+	}
+
+	// A revision left behind by an earlier class of the same name is replaced, so pins and
+	// rollbacks never apply another class's content
+	existing := &akuityv1alpha1.NamespaceClassRevision{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, existing); err != nil {
+		return fmt.Errorf("get revision %s: %w", name, err)
+	}
+	if owner := metav1.GetControllerOf(existing); owner != nil && owner.UID == class.UID {
+		return nil
+	}
+	if err := r.Delete(ctx, existing, client.Preconditions{UID: &existing.UID}); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("delete stale revision %s: %w", name, err)
+	}
+	if err := r.Create(ctx, revision); err != nil {
+		return fmt.Errorf("create revision %s: %w", name, err)
+	}
+	return nil
+}
+
+// resolvedSources returns the revisions the fetched sources of the class resolved to, without their
+// fetch state. ok is false while a fetched source hasn't resolved for its current URL and ref.
+func resolvedSources(class *akuityv1alpha1.NamespaceClass) (sources []akuityv1alpha1.SourceStatus, ok bool) {
+	for i, source := range class.Spec.Sources {
+		url, ref, _, fetched := fetchedSourceTarget(source)
+		if !fetched {
+			continue
+		}
+		status := fetchedSource(class, i)
+		if status == nil || status.URL != url || status.Ref != ref || status.Revision == "" {
+			return nil, false
+		}
+		sources = append(sources, akuityv1alpha1.SourceStatus{
+			Index:    status.Index,
+			URL:      status.URL,
+			Ref:      status.Ref,
+			Revision: status.Revision,
+		})
+	}
+	return sources, true
+}
+
+// pruneRevisions deletes the oldest revisions beyond the class's history limit, never deleting the
+// revisions in keep
+func (r *NamespaceClassReconciler) pruneRevisions(ctx context.Context, class *akuityv1alpha1.NamespaceClass,
+	revisions []akuityv1alpha1.NamespaceClassRevision, keep map[string]bool) error {
+	limit := defaultRevisionHistoryLimit
+	if class.Spec.RevisionHistoryLimit != nil {
+		limit = int(*class.Spec.RevisionHistoryLimit)
+	}
+
+	// Newest first
+	sorted := append([]akuityv1alpha1.NamespaceClassRevision{}, revisions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Spec.Generation > sorted[j].Spec.Generation })
+
+	for i := range sorted {
+		revision := &sorted[i]
+		if i < limit || keep[revision.Name] {
+			continue
+		}
+		if err := r.Delete(ctx, revision); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("delete revision %s: %w", revision.Name, err)
+		}
+	}
+	return nil
+}
+
+// compareRevisions records the comparison asked for by the compare-to annotation of each revision
+func (r *NamespaceClassReconciler) compareRevisions(ctx context.Context,
+	revisions []akuityv1alpha1.NamespaceClassRevision) error {
+	byName := make(map[string]*akuityv1alpha1.NamespaceClassRevision, len(revisions))
+	for i := range revisions {
+		byName[revisions[i].Name] = &revisions[i]
+	}
+
+	for i := range revisions {
+		revision := &revisions[i]
+		other, ok := revision.Annotations[annotationCompareTo]
+		if !ok || (revision.Status.Comparison != nil && revision.Status.Comparison.Revision == other) {
+			continue
+		}
+		from, ok := byName[other]
+		if !ok {
+			// Only revisions of the same class can be compared
+			continue
+		}
+
+		changes, err := diffRevisions(ctx, sourceReader(r, r.SourceCache, r.SecretNamespace),
+			sourceFetchers{git: r.Git, oci: r.OCI, helm: r.Helm, kustomize: r.Kustomize}, r.Renderer, from, revision)
+		if err != nil {
+			return fmt.Errorf("compare revision %s to %s: %w", revision.Name, other, err)
+		}
+		base := revision.DeepCopy()
+		revision.Status.Comparison = &akuityv1alpha1.RevisionComparison{Revision: other, Changes: changes}
+		if err := r.Status().Patch(ctx, revision, client.MergeFrom(base)); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("update comparison of revision %s: %w", revision.Name, err)
+		}
+	}
+	return nil
+}
+
+// diffRevisions lists the resources added, removed and changed going from one revision to another
+func diffRevisions(ctx context.Context, c client.Reader, fetchers sourceFetchers, renderer Renderer,
+	from, to *akuityv1alpha1.NamespaceClassRevision) ([]akuityv1alpha1.ResourceChange, error) {
+	fromResources, err := revisionResources(ctx, c, fetchers, renderer, from)
+	if err != nil {
+		return nil, err
+	}
+	toResources, err := revisionResources(ctx, c, fetchers, renderer, to)
+	if err != nil {
+		return nil, err
+	}
+	return diffResources(fromResources, toResources), nil
+}
+
+// revisionResources parses the resources of a revision, including those of its sources
+func revisionResources(ctx context.Context, c client.Reader, fetchers sourceFetchers, renderer Renderer,
+	revision *akuityv1alpha1.NamespaceClassRevision) ([]parsedResource, error) {
+	class, err := resolveSources(ctx, c, fetchers, revisionClass(revision))
+	if err != nil {
+		return nil, err
+	}
+	parsed := parseClass(ctx, renderer, class)
+	return parsed.resources, parsed.err
+}
+
+// revisionClass returns the class content of a revision. The resources of its sources are inlined from
+// the snapshot; revisions taken before snapshots keep their sources, to be read at the revisions
+// recorded in them.
+func revisionClass(revision *akuityv1alpha1.NamespaceClassRevision) *akuityv1alpha1.NamespaceClass {
+	revision = revision.DeepCopy()
+	class := &akuityv1alpha1.NamespaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: revision.Spec.ClassName, Generation: revision.Spec.Generation},
+		Spec:       revision.Spec.Class,
+	}
+	if revision.Spec.SourcesHash == "" {
+		class.Status.Sources = revision.Spec.Sources
+		return class
+	}
+	class.Spec.Resources = append(class.Spec.Resources, revision.Spec.SourceResources...)
+	class.Spec.Sources = nil
+	class.Annotations = map[string]string{annotationSourcesHash: revision.Spec.SourcesHash}
+	return class
+}
+
+// diffResources lists the resources added, removed and changed going from one resource set to another,
+// in the order of the new set followed by removed resources
+func diffResources(from, to []parsedResource) []akuityv1alpha1.ResourceChange {
+	old := make(map[string]parsedResource, len(from))
+	for _, res := range from {
+		old[getKey(res.APIVersion, res.Kind, res.Namespace, res.Name)] = res
+	}
+
+	var changes []akuityv1alpha1.ResourceChange
+	seen := make(map[string]bool, len(to))
+	for _, res := range to {
+		key := getKey(res.APIVersion, res.Kind, res.Namespace, res.Name)
+		seen[key] = true
+		prev, ok := old[key]
+		switch {
+		case !ok:
+			changes = append(changes, resourceChange(res, akuityv1alpha1.ResourceChangeAdded, nil))
+		case !equality.Semantic.DeepEqual(prev.Object.Object, res.Object.Object):
+			fields := changedFields("", prev.Object.Object, res.Object.Object)
+			changes = append(changes, resourceChange(res, akuityv1alpha1.ResourceChangeChanged, fields))
+		}
+	}
+	for _, res := range from {
+		if !seen[getKey(res.APIVersion, res.Kind, res.Namespace, res.Name)] {
+			changes = append(changes, resourceChange(res, akuityv1alpha1.ResourceChangeRemoved, nil))
+		}
+	}
+	return changes
+}
+
+// resourceChange builds the change entry of a resource
+func resourceChange(res parsedResource, changeType akuityv1alpha1.ResourceChangeType,
+	fields []string) akuityv1alpha1.ResourceChange {
+	return akuityv1alpha1.ResourceChange{
+		APIVersion: res.APIVersion,
+		Kind:       res.Kind,
+		Name:       res.Name,
+		Namespace:  res.Namespace,
+		Type:       changeType,
+		Fields:     fields,
+	}
+}
+
+// changedFields returns the sorted paths of the fields that differ between two objects. Lists are
+// compared as a whole.
+func changedFields(prefix string, from, to map[string]interface{}) []string {
+	var fields []string
+	for key, value := range to {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		prev, ok := from[key]
+		if !ok {
+			fields = append(fields, path)
+			continue
+		}
+		prevMap, prevIsMap := prev.(map[string]interface{})
+		valueMap, valueIsMap := value.(map[string]interface{})
+		if prevIsMap && valueIsMap {
+			fields = append(fields, changedFields(path, prevMap, valueMap)...)
+		} else if !reflect.DeepEqual(prev, value) {
+			fields = append(fields, path)
+		}
+	}
+	for key := range from {
+		if _, ok := to[key]; !ok {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			fields = append(fields, path)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// revisionContent returns the class as it was at the named revision, with the source content recorded
+// in it
+func (r *NamespaceClassBindingReconciler) revisionContent(ctx context.Context, class *akuityv1alpha1.NamespaceClass,
+	name string) (*akuityv1alpha1.NamespaceClass, error) {
+	revision := &akuityv1alpha1.NamespaceClassRevision{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, revision); err != nil {
+		return nil, fmt.Errorf("get revision %s: %w", name, err)
+	}
+	if revision.Spec.ClassName != class.Name {
+		return nil, permanent(fmt.Errorf("revision %s belongs to NamespaceClass %q, not %q",
+			name, revision.Spec.ClassName, class.Name))
+	}
+
+	content := revisionClass(revision)
+	target := class.DeepCopy()
+	target.Generation = content.Generation
+	target.Spec = content.Spec
+	target.Status.Sources = content.Status.Sources
+	if hash := sourcesHash(content); hash != "" {
+		if target.Annotations == nil {
+			target.Annotations = map[string]string{}
+		}
+		target.Annotations[annotationSourcesHash] = hash
+	}
+	return target, nil
+}
+
+// classTarget returns the class content the binding should apply: the revision it is pinned to, the
+// revision an aborted rollout was rolled back to, or the class itself. Only the class itself is
+// subject to rollout throttling.
+func (r *NamespaceClassBindingReconciler) classTarget(ctx context.Context, binding *akuityv1alpha1.NamespaceClassBinding,
+	class *akuityv1alpha1.NamespaceClass) (*akuityv1alpha1.NamespaceClass, error) {
+	if pinned := pinnedRevision(binding); pinned != "" {
+		return r.revisionContent(ctx, class, pinned)
+	}
+	if rollbackActive(class) {
+		return r.revisionContent(ctx, class, class.Status.Rollout.RollbackRevision)
+	}
+	return class, nil
+}
+
+// findBindingsForRevision returns reconcile requests for the bindings of the revision's class, so
+// bindings pinned to a revision that didn't exist yet pick it up
+func (r *NamespaceClassBindingReconciler) findBindingsForRevision(ctx context.Context,
+	obj client.Object) []reconcile.Request {
+	revision := obj.(*akuityv1alpha1.NamespaceClassRevision)
+
+	var bindings akuityv1alpha1.NamespaceClassBindingList
+	if err := r.List(ctx, &bindings, client.MatchingFields{"spec.className": revision.Spec.ClassName}); err != nil {
+		return nil
+	}
+
+	var pinned []akuityv1alpha1.NamespaceClassBinding
+	for _, binding := range bindings.Items {
+		if pinnedRevision(&binding) == revision.Name {
+			pinned = append(pinned, binding)
+		}
+	}
+	return bindingRequests(pinned)
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// settingsClass returns test-class at the given generation with a ConfigMap holding version
+func settingsClass(generation int64, version string, extra ...runtime.RawExtension) *akuityv1alpha1.NamespaceClass {
+	return &akuityv1alpha1.NamespaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-class", Generation: generation},
+		Spec: akuityv1alpha1.NamespaceClassSpec{
+			Resources: append([]runtime.RawExtension{
+				{Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings"},
+					"data": {"version": "` + version + `", "mode": "strict"}}`)},
+			}, extra...),
+		},
+	}
+}
+
+func TestDiffResources(t *testing.T) {
+	from := parseClass(context.Background(), nil, settingsClass(1, "1", runtime.RawExtension{
+		Raw: []byte(`{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "token"}}`),
+	}))
+	to := parseClass(context.Background(), nil, settingsClass(2, "2", runtime.RawExtension{
+		Raw: []byte(`{"apiVersion": "v1", "kind": "ServiceAccount", "metadata": {"name": "runner"}}`),
+	}))
+	require.NoError(t, from.err)
+	require.NoError(t, to.err)
+
+	changes := diffResources(from.resources, to.resources)
+	require.Len(t, changes, 3)
+	assert.Equal(t, akuityv1alpha1.ResourceChangeChanged, changes[0].Type)
+	assert.Equal(t, "settings", changes[0].Name)
+	assert.Equal(t, []string{"data.version"}, changes[0].Fields)
+	assert.Equal(t, akuityv1alpha1.ResourceChangeAdded, changes[1].Type)
+	assert.Equal(t, "ServiceAccount", changes[1].Kind)
+	assert.Equal(t, akuityv1alpha1.ResourceChangeRemoved, changes[2].Type)
+	assert.Equal(t, "Secret", changes[2].Kind)
+
+	assert.Empty(t, diffResources(to.resources, to.resources))
+}
+
+func TestNamespaceClassReconciler_Revisions(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+
+	historyLimit := int32(2)
+	class := settingsClass(1, "1")
+	class.Spec.RevisionHistoryLimit = &historyLimit
+	pinned := &akuityv1alpha1.NamespaceClassBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+		Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class", Revision: "test-class-1"},
+	}
+	c := newRolloutTestClientBuilder(scheme).
+		WithObjects(class, pinned, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}).
+		Build()
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+	classReq := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-class"}}
+
+	// Every generation is recorded as a revision owned by the class
+	for generation := int64(1); generation <= 4; generation++ {
+		current := &akuityv1alpha1.NamespaceClass{}
+		require.NoError(t, c.Get(ctx, classReq.NamespacedName, current))
+		current.Generation = generation
+		current.Spec = settingsClass(generation, string(rune('0'+generation))).Spec
+		current.Spec.RevisionHistoryLimit = &historyLimit
+		require.NoError(t, c.Update(ctx, current))
+		_, err := classReconciler.Reconcile(ctx, classReq)
+		require.NoError(t, err)
+	}
+
+	// Beyond the limit only the revision a binding is pinned to survives
+	var revisions akuityv1alpha1.NamespaceClassRevisionList
+	require.NoError(t, c.List(ctx, &revisions, client.MatchingLabels{labelRevisionClass: "test-class"}))
+	var names []string
+	for _, revision := range revisions.Items {
+		names = append(names, revision.Name)
+		assert.Equal(t, "test-class", revision.OwnerReferences[0].Name)
+	}
+	assert.ElementsMatch(t, []string{"test-class-1", "test-class-3", "test-class-4"}, names)
+
+	// A revision compares itself to the revision named in its annotation
+	revision := &akuityv1alpha1.NamespaceClassRevision{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "test-class-4"}, revision))
+	revision.Annotations = map[string]string{annotationCompareTo: "test-class-1"}
+	require.NoError(t, c.Update(ctx, revision))
+	_, err := classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "test-class-4"}, revision))
+	require.NotNil(t, revision.Status.Comparison)
+	assert.Equal(t, "test-class-1", revision.Status.Comparison.Revision)
+	require.Len(t, revision.Status.Comparison.Changes, 1)
+	assert.Equal(t, []string{"data.version"}, revision.Status.Comparison.Changes[0].Fields)
+}
+
+func TestNamespaceClassBindingReconciler_PinnedRevision(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+
+	class := settingsClass(2, "2")
+	binding := &akuityv1alpha1.NamespaceClassBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+		Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class", Revision: "test-class-1"},
+	}
+	c := newRolloutTestClientBuilder(scheme).
+		WithObjects(class, binding, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}).
+		Build()
+	r := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "team-a", Namespace: "team-a"}}
+
+	// A missing revision degrades the binding until it exists
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+	assert.True(t, meta.IsStatusConditionTrue(binding.Status.Conditions, akuityv1alpha1.BindingConditionDegraded))
+
+	require.NoError(t, c.Create(ctx, &akuityv1alpha1.NamespaceClassRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-class-1", Labels: map[string]string{labelRevisionClass: "test-class"}},
+		Spec: akuityv1alpha1.NamespaceClassRevisionSpec{
+			ClassName:  "test-class",
+			Generation: 1,
+			Class:      settingsClass(1, "1").Spec,
+		},
+	}))
+
+	// The pinned revision is applied even though the class has moved on
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: "team-a"}, cm))
+	assert.Equal(t, "1", cm.Data["version"])
+	require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+	assert.Equal(t, "test-class-1", binding.Status.ObservedRevision)
+	assert.False(t, meta.IsStatusConditionTrue(binding.Status.Conditions, akuityv1alpha1.BindingConditionDegraded))
+
+	// Following the class again applies its current generation
+	binding.Spec.Revision = revisionLatest
+	require.NoError(t, c.Update(ctx, binding))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: "team-a"}, cm))
+	assert.Equal(t, "2", cm.Data["version"])
+	require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+	assert.Equal(t, "test-class-2", binding.Status.ObservedRevision)
+}
+
+func TestNamespaceClassReconciler_StaleRevision(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+
+	// A revision of an earlier class with the same name that garbage collection hasn't removed yet
+	class := settingsClass(1, "new")
+	class.UID = "new-uid"
+	stale := &akuityv1alpha1.NamespaceClassRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "test-class-1",
+			Labels: map[string]string{labelRevisionClass: "test-class"},
+			UID:    "stale-uid",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: akuityv1alpha1.GroupVersion.String(), Kind: "NamespaceClass", Name: "test-class",
+				UID: "old-uid", Controller: ptr.To(true),
+			}},
+		},
+		Spec: akuityv1alpha1.NamespaceClassRevisionSpec{
+			ClassName: "test-class", Generation: 1, Class: settingsClass(1, "old").Spec,
+		},
+	}
+	c := newRolloutTestClientBuilder(scheme).WithObjects(class, stale).Build()
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+
+	require.NoError(t, classReconciler.ensureRevision(ctx, class, class))
+
+	revision := &akuityv1alpha1.NamespaceClassRevision{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "test-class-1"}, revision))
+	assert.Equal(t, types.UID("new-uid"), metav1.GetControllerOf(revision).UID)
+	assert.Contains(t, string(revision.Spec.Class.Resources[0].Raw), `"version":"new"`)
+
+	// The revision of the class itself is left alone
+	require.NoError(t, classReconciler.ensureRevision(ctx, class, class))
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "test-class-1"}, revision))
+	assert.Equal(t, types.UID("new-uid"), metav1.GetControllerOf(revision).UID)
+}
+
+func TestNamespaceClassBindingReconciler_PinnedSourceRevision(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	origin := newTestGitRepo(t)
+	first := origin.commit(map[string]string{
+		"limits.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: limits\ndata:\n  cpu: \"1\"\n",
+	})
+
+	class := settingsClass(1, "1")
+	class.Spec.Sources = []akuityv1alpha1.ResourceSource{{Git: &akuityv1alpha1.GitSource{URL: origin.bare}}}
+	c := newRolloutTestClientBuilder(scheme).
+		WithObjects(class, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class", Revision: "test-class-1"},
+			}).
+		Build()
+	repos := NewGitRepositories()
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10),
+		Git: repos}
+	bindingReconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme,
+		Recorder: record.NewFakeRecorder(10), Git: repos}
+	classReq := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-class"}}
+
+	// The revision records the commit the source resolved to
+	_, err := classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+	revision := &akuityv1alpha1.NamespaceClassRevision{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "test-class-1"}, revision))
+	require.Len(t, revision.Spec.Sources, 1)
+	assert.Equal(t, first, revision.Spec.Sources[0].Revision)
+
+	// The source moves on without a new generation
+	origin.commit(map[string]string{
+		"limits.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: limits\ndata:\n  cpu: \"2\"\n",
+	})
+	require.NoError(t, c.Get(ctx, classReq.NamespacedName, class))
+	class.Annotations = map[string]string{annotationRefresh: "1"}
+	require.NoError(t, c.Update(ctx, class))
+	_, err = classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, classReq.NamespacedName, class))
+	require.NotEqual(t, first, class.Status.Sources[0].Revision)
+
+	// The pinned binding still applies the commit its revision shipped
+	_, err = bindingReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{
+		Name: "team-a", Namespace: "team-a"}})
+	require.NoError(t, err)
+	limits := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "limits", Namespace: "team-a"}, limits))
+	assert.Equal(t, "1", limits.Data["cpu"])
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// reasonRolloutAborted marks a rollout that was aborted and rolled back
+	reasonRolloutAborted = "RolloutAborted"
+)
+
+// rollbackActive reports whether the current generation of the class was rolled back
+func rollbackActive(class *akuityv1alpha1.NamespaceClass) bool {
+	rollout := class.Status.Rollout
+	return rollout != nil && rollout.Aborted && rollout.Generation == class.Generation && rollout.RollbackRevision != ""
+}
+
+// shouldAbort reports whether the rollout has reached the failure threshold of its auto rollback
+func shouldAbort(class *akuityv1alpha1.NamespaceClass, plan rolloutPlan) (bool, error) {
+	strategy := class.Spec.RolloutStrategy
+	if strategy == nil || strategy.AutoRollback == nil {
+		return false, nil
+	}
+	threshold, err := scaledRolloutValue(strategy.AutoRollback.FailureThreshold, 1, int(plan.status.Total), true)
+	if err != nil {
+		return false, fmt.Errorf("failureThreshold: %w", err)
+	}
+	return int(plan.status.Unavailable) >= max(threshold, 1), nil
+}
+
+// rollBack wakes up the bindings that took the aborted generation so they revert to the revision
+func (r *NamespaceClassReconciler) rollBack(ctx context.Context, class *akuityv1alpha1.NamespaceClass,
+	bindings []akuityv1alpha1.NamespaceClassBinding, revision *akuityv1alpha1.NamespaceClassRevision) error {
+	target := strconv.FormatInt(revision.Spec.Generation, 10)
+	for i := range bindings {
+		binding := &bindings[i]
+		if rolloutState(binding, class) == bindingWaiting || binding.Annotations[annotationRolloutGeneration] == target {
+			continue
+		}
+
+		base := binding.DeepCopy()
+		if binding.Annotations == nil {
+			binding.Annotations = map[string]string{}
+		}
+		binding.Annotations[annotationRolloutGeneration] = target
+		if err := r.Patch(ctx, binding, client.MergeFrom(base)); err != nil {
+			return fmt.Errorf("roll back binding %s: %w", binding.Namespace, err)
+		}
+	}
+	return nil
+}
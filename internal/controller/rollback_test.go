@@ -0,0 +1,160 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+func TestShouldAbort(t *testing.T) {
+	class := newRolloutClass()
+	plan := rolloutPlan{status: akuityv1alpha1.RolloutStatus{Total: 10, Unavailable: 2}}
+
+	abort, err := shouldAbort(class, plan)
+	require.NoError(t, err)
+	assert.False(t, abort, "classes without autoRollback never abort")
+
+	class.Spec.RolloutStrategy.AutoRollback = &akuityv1alpha1.AutoRollback{}
+	abort, err = shouldAbort(class, plan)
+	require.NoError(t, err)
+	assert.True(t, abort)
+
+	class.Spec.RolloutStrategy.AutoRollback.FailureThreshold = &intstr.IntOrString{Type: intstr.String, StrVal: "30%"}
+	abort, err = shouldAbort(class, plan)
+	require.NoError(t, err)
+	assert.False(t, abort)
+}
+
+func TestNamespaceClassReconciler_AutoRollback(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+
+	class := &akuityv1alpha1.NamespaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-class", Generation: 1},
+		Spec: akuityv1alpha1.NamespaceClassSpec{
+			Resources: []runtime.RawExtension{
+				{Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings"},
+					"data": {"version": "1"}}`)},
+			},
+			RolloutStrategy: &akuityv1alpha1.RolloutStrategy{
+				MaxConcurrent: &intstr.IntOrString{IntVal: 2},
+				AutoRollback:  &akuityv1alpha1.AutoRollback{},
+			},
+		},
+	}
+	objs := []client.Object{class}
+	for _, name := range []string{"team-a", "team-b"} {
+		objs = append(objs,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: name},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			},
+		)
+	}
+
+	// The API server rejects the ConfigMap the new generation adds
+	c := newRolloutTestClientBuilder(scheme).
+		WithObjects(objs...).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+				opts ...client.PatchOption) error {
+				if patch == client.Apply && obj.GetName() == "broken" {
+					return errors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "broken",
+						field.ErrorList{field.Invalid(field.NewPath("data"), "x", "rejected")})
+				}
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	recorder := record.NewFakeRecorder(10)
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: recorder}
+	bindingReconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(30)}
+	version := func(namespace string) string {
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: namespace}, cm))
+		return cm.Data["version"]
+	}
+
+	// The first generation rolls out cleanly and becomes the last-known-good revision
+	current := reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a", "team-b")
+	assert.Equal(t, "test-class-1", current.Status.LastKnownGoodRevision)
+	assert.Contains(t, <-recorder.Events, reasonRolloutComplete)
+
+	// The second generation breaks both namespaces and is rolled back
+	current.Generation = 2
+	current.Spec.Resources = []runtime.RawExtension{
+		{Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings"},
+			"data": {"version": "2"}}`)},
+		{Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "broken"}}`)},
+	}
+	require.NoError(t, c.Update(ctx, current))
+
+	current = reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a", "team-b")
+	assert.True(t, current.Status.Rollout.Aborted)
+	assert.Equal(t, "test-class-1", current.Status.Rollout.RollbackRevision)
+	cond := meta.FindStatusCondition(current.Status.Conditions, akuityv1alpha1.ClassConditionRolloutAborted)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	select {
+	case event := <-recorder.Events:
+		assert.Contains(t, event, reasonRolloutAborted)
+	default:
+		t.Error("expected a RolloutAborted event")
+	}
+
+	// The bindings revert to the revision
+	current = reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a", "team-b")
+	for _, name := range []string{"team-a", "team-b"} {
+		assert.Equal(t, "1", version(name))
+		binding := &akuityv1alpha1.NamespaceClassBinding{}
+		require.NoError(t, c.Get(ctx, types.NamespacedName{Name: name, Namespace: name}, binding))
+		assert.Equal(t, int64(1), binding.Status.ObservedClassGeneration)
+		assert.False(t, meta.IsStatusConditionTrue(binding.Status.Conditions, akuityv1alpha1.BindingConditionDegraded))
+	}
+	assert.True(t, current.Status.Rollout.Aborted)
+	assert.Equal(t, "test-class-1", current.Status.LastKnownGoodRevision)
+
+	// A fixed generation rolls out again and becomes the last-known-good revision
+	current.Generation = 3
+	current.Spec.Resources = current.Spec.Resources[:1]
+	require.NoError(t, c.Update(ctx, current))
+	current = reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a", "team-b")
+	current = reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a", "team-b")
+	assert.False(t, current.Status.Rollout.Aborted)
+	assert.Nil(t, meta.FindStatusCondition(current.Status.Conditions, akuityv1alpha1.ClassConditionRolloutAborted))
+	assert.Equal(t, "2", version("team-a"))
+	assert.Equal(t, "test-class-3", current.Status.LastKnownGoodRevision)
+}
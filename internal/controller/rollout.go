@@ -0,0 +1,329 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// annotationRolloutGeneration is set on a binding to the rollout key of the class content the rollout
+	// admitted it to
+	annotationRolloutGeneration = "namespaceclass.akuity.io/rollout-generation"
+
+	// annotationResume is set on a class to resume a rollout paused after the named batch
+	annotationResume = "namespaceclass.akuity.io/resume"
+
+	// remainingBatchName is the batch of namespaces that match none of the configured batches
+	remainingBatchName = "remaining"
+
+	// reasonRollingOut marks a rollout that is updating bindings
+	reasonRollingOut = "RollingOut"
+
+	// reasonRolloutPaused marks a rollout waiting to be resumed after a batch
+	reasonRolloutPaused = "RolloutPaused"
+
+	// reasonRolloutHalted marks a rollout stopped because too many bindings are Degraded
+	reasonRolloutHalted = "RolloutHalted"
+
+	// reasonRolloutComplete marks a generation that every binding has applied
+	reasonRolloutComplete = "RolloutComplete"
+)
+
+// rolloutKey identifies the class content a rollout admits bindings to: the generation and, for classes
+// with sources, the hash of the source content. The class has to be resolved with resolveSources.
+func rolloutKey(class *akuityv1alpha1.NamespaceClass) string {
+	key := strconv.FormatInt(class.Generation, 10)
+	if hash := sourcesHash(class); hash != "" {
+		key += "/" + hash
+	}
+	return key
+}
+
+// appliedContent reports whether the binding applied the current content of the resolved class
+func appliedContent(binding *akuityv1alpha1.NamespaceClassBinding, class *akuityv1alpha1.NamespaceClass) bool {
+	return binding.Status.ObservedClassName == class.Name &&
+		binding.Status.ObservedClassGeneration == class.Generation &&
+		binding.Status.ObservedSourcesHash == sourcesHash(class)
+}
+
+// rolloutAdmitted reports whether the binding may apply the current content of its resolved class.
+// Bindings that never applied this class aren't throttled or held for approval; they have nothing to
+// break.
+func rolloutAdmitted(binding *akuityv1alpha1.NamespaceClassBinding, class *akuityv1alpha1.NamespaceClass) bool {
+	if !rolloutGated(class) ||
+		binding.Status.ObservedClassName != class.Name ||
+		appliedContent(binding, class) {
+		return true
+	}
+	return binding.Annotations[annotationRolloutGeneration] == rolloutKey(class)
+}
+
+// bindingRolloutChanged passes binding updates that change where the binding stands in the rollout of
+// its class, so status patches that only touch resource tracking don't requeue the class
+var bindingRolloutChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldBinding, ok := e.ObjectOld.(*akuityv1alpha1.NamespaceClassBinding)
+		if !ok {
+			return false
+		}
+		newBinding, ok := e.ObjectNew.(*akuityv1alpha1.NamespaceClassBinding)
+		if !ok {
+			return false
+		}
+		return oldBinding.Generation != newBinding.Generation ||
+			oldBinding.Status.ObservedClassName != newBinding.Status.ObservedClassName ||
+			oldBinding.Status.ObservedClassGeneration != newBinding.Status.ObservedClassGeneration ||
+			oldBinding.Status.ObservedSourcesHash != newBinding.Status.ObservedSourcesHash ||
+			oldBinding.Annotations[annotationRolloutGeneration] != newBinding.Annotations[annotationRolloutGeneration] ||
+			meta.IsStatusConditionTrue(oldBinding.Status.Conditions, akuityv1alpha1.BindingConditionDegraded) !=
+				meta.IsStatusConditionTrue(newBinding.Status.Conditions, akuityv1alpha1.BindingConditionDegraded)
+	},
+}
+
+// rolloutGated reports whether bindings wait for the class controller to admit them to a new generation,
+// rather than applying it on their own
+func rolloutGated(class *akuityv1alpha1.NamespaceClass) bool {
+	return class.Spec.RolloutStrategy != nil || class.Spec.RequireApproval || len(class.Spec.CanaryNamespaces) > 0
+}
+
+// bindingRolloutState is where a binding stands in the rollout of a class generation
+type bindingRolloutState int
+
+const (
+	// bindingWaiting hasn't been admitted to the generation yet
+	bindingWaiting bindingRolloutState = iota
+	// bindingInFlight was admitted but hasn't applied the generation yet
+	bindingInFlight
+	// bindingUpdated applied the generation
+	bindingUpdated
+	// bindingUnavailable applied the generation but is Degraded
+	bindingUnavailable
+)
+
+// rolloutState returns where the binding stands in the rollout of the current content of the resolved
+// class
+func rolloutState(binding *akuityv1alpha1.NamespaceClassBinding,
+	class *akuityv1alpha1.NamespaceClass) bindingRolloutState {
+	if appliedContent(binding, class) {
+		if meta.IsStatusConditionTrue(binding.Status.Conditions, akuityv1alpha1.BindingConditionDegraded) {
+			return bindingUnavailable
+		}
+		return bindingUpdated
+	}
+	if rolloutAdmitted(binding, class) {
+		return bindingInFlight
+	}
+	return bindingWaiting
+}
+
+// rolloutPlan is the outcome of planning one step of a rollout
+type rolloutPlan struct {
+	// status is the progress to report on the class
+	status akuityv1alpha1.RolloutStatus
+	// admit lists the bindings to admit to the current generation now
+	admit []*akuityv1alpha1.NamespaceClassBinding
+	// halted is true when too many bindings are Degraded to admit more
+	halted bool
+	// awaitingApproval is true when the plan of the generation isn't approved yet
+	awaitingApproval bool
+	// invalid is true when the generation failed its dry run in the canary namespaces, or can't be
+	// validated there
+	invalid bool
+}
+
+// planRollout decides which bindings of the resolved class to admit next. namespaceLabels holds the
+// labels of each bound namespace, which place bindings into batches.
+func planRollout(class *akuityv1alpha1.NamespaceClass, bindings []akuityv1alpha1.NamespaceClassBinding,
+	namespaceLabels map[string]labels.Set) (rolloutPlan, error) {
+	strategy := class.Spec.RolloutStrategy
+	if strategy == nil {
+		strategy = &akuityv1alpha1.RolloutStrategy{}
+	}
+
+	plan := rolloutPlan{status: akuityv1alpha1.RolloutStatus{
+		Generation:  class.Generation,
+		SourcesHash: sourcesHash(class),
+		Total:       int32(len(bindings)),
+	}}
+
+	selectors := make([]labels.Selector, len(strategy.Batches))
+	for i, batch := range strategy.Batches {
+		selector, err := metav1.LabelSelectorAsSelector(&batch.NamespaceSelector)
+		if err != nil {
+			return plan, fmt.Errorf("batch %q: %w", batch.Name, err)
+		}
+		selectors[i] = selector
+	}
+
+	// Place every binding into the first batch its namespace matches, or the remaining batch
+	batches := make([][]*akuityv1alpha1.NamespaceClassBinding, len(strategy.Batches)+1)
+	inFlight := 0
+	for i := range bindings {
+		binding := &bindings[i]
+		switch rolloutState(binding, class) {
+		case bindingUpdated:
+			plan.status.Updated++
+		case bindingUnavailable:
+			plan.status.Unavailable++
+		case bindingInFlight:
+			inFlight++
+		}
+
+		batch := len(strategy.Batches)
+		for j, selector := range selectors {
+			if selector.Matches(namespaceLabels[binding.Namespace]) {
+				batch = j
+				break
+			}
+		}
+		batches[batch] = append(batches[batch], binding)
+	}
+
+	if class.Spec.RolloutStrategy == nil {
+		// Without a strategy every binding applies the change at once, after validation and approval
+		for i := range bindings {
+			if rolloutState(&bindings[i], class) == bindingWaiting {
+				plan.admit = append(plan.admit, &bindings[i])
+			}
+		}
+		return plan, nil
+	}
+
+	total := len(bindings)
+	maxConcurrent, err := scaledRolloutValue(strategy.MaxConcurrent, 1, total, true)
+	if err != nil {
+		return plan, fmt.Errorf("maxConcurrent: %w", err)
+	}
+	maxUnavailable, err := scaledRolloutValue(strategy.MaxUnavailable, 0, total, false)
+	if err != nil {
+		return plan, fmt.Errorf("maxUnavailable: %w", err)
+	}
+	plan.halted = int(plan.status.Unavailable) > maxUnavailable
+
+	// Batches before the resumed one don't pause again
+	resumed := -1
+	for i, batch := range strategy.Batches {
+		if batch.Name == class.Annotations[annotationResume] {
+			resumed = i
+		}
+	}
+
+	slots := max(maxConcurrent, 1) - inFlight
+	for i, members := range batches {
+		name := remainingBatchName
+		if i < len(strategy.Batches) {
+			name = strategy.Batches[i].Name
+		}
+
+		var waiting []*akuityv1alpha1.NamespaceClassBinding
+		busy := false
+		for _, binding := range members {
+			switch rolloutState(binding, class) {
+			case bindingWaiting:
+				waiting = append(waiting, binding)
+			case bindingInFlight:
+				busy = true
+			}
+		}
+
+		if len(waiting) == 0 && !busy {
+			// The batch is done; pause after it if asked to and it isn't resumed yet
+			if i < len(strategy.Batches) && strategy.Batches[i].PauseAfter && len(members) > 0 &&
+				i > resumed && plan.status.Updated+plan.status.Unavailable < plan.status.Total {
+				plan.status.CurrentBatch = name
+				plan.status.Paused = true
+				return plan, nil
+			}
+			continue
+		}
+
+		plan.status.CurrentBatch = name
+		if !plan.halted {
+			for _, binding := range waiting {
+				if slots <= 0 {
+					break
+				}
+				plan.admit = append(plan.admit, binding)
+				slots--
+			}
+		}
+		return plan, nil
+	}
+
+	return plan, nil
+}
+
+// scaledRolloutValue resolves an absolute or percentage rollout setting against the number of bindings
+func scaledRolloutValue(value *intstr.IntOrString, def, total int, roundUp bool) (int, error) {
+	if value == nil {
+		return def, nil
+	}
+	return intstr.GetScaledValueFromIntOrPercent(value, total, roundUp)
+}
+
+// rolloutCondition returns the RolloutProgressing condition for a planned rollout step
+func rolloutCondition(class *akuityv1alpha1.NamespaceClass, plan rolloutPlan) metav1.Condition {
+	status := plan.status
+	cond := metav1.Condition{
+		Type:               akuityv1alpha1.ClassConditionRolloutProgressing,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonRollingOut,
+		Message:            fmt.Sprintf("%d/%d bindings updated to generation %d", status.Updated, status.Total, status.Generation),
+		ObservedGeneration: class.Generation,
+	}
+
+	switch {
+	case status.Aborted:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = reasonRolloutAborted
+		cond.Message = fmt.Sprintf("%d bindings went Degraded on generation %d; rolled back to revision %s",
+			status.Unavailable, status.Generation, status.RollbackRevision)
+	case plan.invalid && status.Updated+status.Unavailable < status.Total:
+		cond.Reason = reasonValidationFailed
+		cond.Message = fmt.Sprintf("generation %d didn't pass validation in the canary namespaces; see the %s condition",
+			status.Generation, akuityv1alpha1.ClassConditionValidated)
+	case plan.awaitingApproval && status.Updated+status.Unavailable < status.Total:
+		cond.Reason = reasonAwaitingApproval
+		cond.Message = fmt.Sprintf("generation %d awaits approval; set the %s annotation to the hash of "+
+			"NamespaceClassPlan %s to roll it out", status.Generation, annotationApprovedPlan,
+			revisionName(class.Name, class.Generation))
+	case plan.halted:
+		cond.Reason = reasonRolloutHalted
+		cond.Message = fmt.Sprintf("%d bindings are Degraded on generation %d, more than maxUnavailable allows",
+			status.Unavailable, status.Generation)
+	case status.Paused:
+		cond.Reason = reasonRolloutPaused
+		cond.Message = fmt.Sprintf("paused after batch %q; set the %s annotation to %q to resume",
+			status.CurrentBatch, annotationResume, status.CurrentBatch)
+	case status.Updated+status.Unavailable == status.Total:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = reasonRolloutComplete
+	}
+	return cond
+}
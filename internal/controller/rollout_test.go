@@ -0,0 +1,190 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// newRolloutBinding returns a binding of test-class that last applied the given generation
+func newRolloutBinding(namespace string, generation int64) akuityv1alpha1.NamespaceClassBinding {
+	return akuityv1alpha1.NamespaceClassBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace},
+		Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+		Status: akuityv1alpha1.NamespaceClassBindingStatus{
+			ObservedClassName:       "test-class",
+			ObservedClassGeneration: generation,
+		},
+	}
+}
+
+// newRolloutClass returns generation 2 of test-class with a canary batch that pauses
+func newRolloutClass() *akuityv1alpha1.NamespaceClass {
+	return &akuityv1alpha1.NamespaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-class", Generation: 2},
+		Spec: akuityv1alpha1.NamespaceClassSpec{
+			RolloutStrategy: &akuityv1alpha1.RolloutStrategy{
+				Batches: []akuityv1alpha1.RolloutBatch{{
+					Name:              "canary",
+					NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "canary"}},
+					PauseAfter:        true,
+				}},
+			},
+		},
+	}
+}
+
+// admittedNames returns the namespaces of the bindings a plan admits
+func admittedNames(plan rolloutPlan) []string {
+	var names []string
+	for _, binding := range plan.admit {
+		names = append(names, binding.Namespace)
+	}
+	return names
+}
+
+func TestRolloutAdmitted(t *testing.T) {
+	class := newRolloutClass()
+
+	fresh := newRolloutBinding("a", 0)
+	fresh.Status.ObservedClassName = ""
+	assert.True(t, rolloutAdmitted(&fresh, class), "new bindings apply right away")
+
+	stale := newRolloutBinding("a", 1)
+	assert.False(t, rolloutAdmitted(&stale, class))
+
+	stale.Annotations = map[string]string{annotationRolloutGeneration: "2"}
+	assert.True(t, rolloutAdmitted(&stale, class))
+
+	// New source content of the same generation is gated too
+	class.Annotations = map[string]string{annotationSourcesHash: "new"}
+	stale.Status.ObservedClassGeneration = 2
+	stale.Status.ObservedSourcesHash = "old"
+	assert.False(t, rolloutAdmitted(&stale, class))
+	stale.Annotations[annotationRolloutGeneration] = "2/new"
+	assert.True(t, rolloutAdmitted(&stale, class))
+
+	class.Spec.RolloutStrategy = nil
+	stale.Annotations = nil
+	assert.True(t, rolloutAdmitted(&stale, class), "classes without a strategy aren't throttled")
+}
+
+func TestPlanRollout(t *testing.T) {
+	namespaceLabels := map[string]labels.Set{
+		"canary-1": {"tier": "canary"},
+		"canary-2": {"tier": "canary"},
+		"prod-1":   {"tier": "prod"},
+		"prod-2":   {"tier": "prod"},
+		"prod-3":   {"tier": "prod"},
+	}
+	names := []string{"canary-1", "canary-2", "prod-1", "prod-2", "prod-3"}
+	bindingsAt := func(generations ...int64) []akuityv1alpha1.NamespaceClassBinding {
+		bindings := make([]akuityv1alpha1.NamespaceClassBinding, len(names))
+		for i, name := range names {
+			bindings[i] = newRolloutBinding(name, generations[i])
+		}
+		return bindings
+	}
+
+	t.Run("starts with the canary batch", func(t *testing.T) {
+		plan, err := planRollout(newRolloutClass(), bindingsAt(1, 1, 1, 1, 1), namespaceLabels)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"canary-1"}, admittedNames(plan))
+		assert.Equal(t, akuityv1alpha1.RolloutStatus{Generation: 2, Total: 5, CurrentBatch: "canary"}, plan.status)
+	})
+
+	t.Run("waits for admitted bindings", func(t *testing.T) {
+		bindings := bindingsAt(1, 1, 1, 1, 1)
+		bindings[0].Annotations = map[string]string{annotationRolloutGeneration: "2"}
+		plan, err := planRollout(newRolloutClass(), bindings, namespaceLabels)
+		require.NoError(t, err)
+		assert.Empty(t, plan.admit)
+	})
+
+	t.Run("pauses after the canary batch", func(t *testing.T) {
+		plan, err := planRollout(newRolloutClass(), bindingsAt(2, 2, 1, 1, 1), namespaceLabels)
+		require.NoError(t, err)
+		assert.Empty(t, plan.admit)
+		assert.True(t, plan.status.Paused)
+		assert.Equal(t, "canary", plan.status.CurrentBatch)
+		assert.Equal(t, reasonRolloutPaused, rolloutCondition(newRolloutClass(), plan).Reason)
+	})
+
+	t.Run("resumes into the remaining namespaces", func(t *testing.T) {
+		class := newRolloutClass()
+		class.Annotations = map[string]string{annotationResume: "canary"}
+		class.Spec.RolloutStrategy.MaxConcurrent = &intstr.IntOrString{Type: intstr.String, StrVal: "50%"}
+		plan, err := planRollout(class, bindingsAt(2, 2, 1, 1, 1), namespaceLabels)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"prod-1", "prod-2", "prod-3"}, admittedNames(plan))
+		assert.Equal(t, remainingBatchName, plan.status.CurrentBatch)
+	})
+
+	t.Run("halts when too many bindings are degraded", func(t *testing.T) {
+		bindings := bindingsAt(2, 1, 1, 1, 1)
+		bindings[0].Status.Conditions = []metav1.Condition{{
+			Type: akuityv1alpha1.BindingConditionDegraded, Status: metav1.ConditionTrue, Reason: reasonApplyFailed,
+		}}
+		plan, err := planRollout(newRolloutClass(), bindings, namespaceLabels)
+		require.NoError(t, err)
+		assert.True(t, plan.halted)
+		assert.Empty(t, plan.admit)
+		assert.Equal(t, int32(1), plan.status.Unavailable)
+		assert.Equal(t, reasonRolloutHalted, rolloutCondition(newRolloutClass(), plan).Reason)
+	})
+
+	t.Run("reports completion", func(t *testing.T) {
+		plan, err := planRollout(newRolloutClass(), bindingsAt(2, 2, 2, 2, 2), namespaceLabels)
+		require.NoError(t, err)
+		assert.False(t, plan.status.Paused)
+		assert.Equal(t, int32(5), plan.status.Updated)
+		cond := rolloutCondition(newRolloutClass(), plan)
+		assert.Equal(t, metav1.ConditionFalse, cond.Status)
+		assert.Equal(t, reasonRolloutComplete, cond.Reason)
+	})
+}
+
+func TestBindingRolloutChanged(t *testing.T) {
+	old := newRolloutBinding("a", 1)
+
+	tracked := old.DeepCopy()
+	tracked.Status.AppliedResources = []akuityv1alpha1.AppliedResource{{APIVersion: "v1", Kind: "ConfigMap", Name: "a"}}
+	assert.False(t, bindingRolloutChanged.Update(event.UpdateEvent{ObjectOld: &old, ObjectNew: tracked}),
+		"resource tracking doesn't concern the rollout")
+
+	updated := old.DeepCopy()
+	updated.Status.ObservedClassGeneration = 2
+	assert.True(t, bindingRolloutChanged.Update(event.UpdateEvent{ObjectOld: &old, ObjectNew: updated}))
+
+	degraded := old.DeepCopy()
+	degraded.Status.Conditions = []metav1.Condition{{
+		Type: akuityv1alpha1.BindingConditionDegraded, Status: metav1.ConditionTrue, Reason: reasonApplyFailed,
+	}}
+	assert.True(t, bindingRolloutChanged.Update(event.UpdateEvent{ObjectOld: &old, ObjectNew: degraded}))
+
+	assert.True(t, bindingRolloutChanged.Create(event.CreateEvent{Object: &old}))
+	assert.True(t, bindingRolloutChanged.Delete(event.DeleteEvent{Object: &old}))
+}
@@ -0,0 +1,608 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// annotationSourcesHash carries the content hash of the sources on a class resolved in memory. It
+	// is never written to the API server.
+	annotationSourcesHash = "namespaceclass.akuity.io/sources-hash"
+
+	// classSourcesField indexes classes by the sources they read
+	classSourcesField = "spec.sources"
+
+	// LabelSource marks the ConfigMaps classes read resources, charts and kustomizations from. The
+	// manager only caches and watches ConfigMaps labeled with it set to "true".
+	LabelSource = "namespaceclass.akuity.io/source"
+
+	// annotationRefresh asks for the fetched sources of a class to be fetched right away whenever its
+	// value changes
+	annotationRefresh = "namespaceclass.akuity.io/refresh"
+
+	// defaultSourceInterval is how often fetched sources are polled when the source doesn't say
+	defaultSourceInterval = 5 * time.Minute
+
+	// reasonSourcesFetched marks a class whose fetched sources are all up to date
+	reasonSourcesFetched = "SourcesFetched"
+
+	// reasonSourceFetchFailed marks a class with a source that couldn't be fetched
+	reasonSourceFetchFailed = "SourceFetchFailed"
+
+	// reasonSourceUpdated is the event reason of a source that resolved to a new revision
+	reasonSourceUpdated = "SourceUpdated"
+)
+
+// sourceRevisionsChanged passes class updates that change the revision a fetched source resolved to,
+// so bindings apply the new revision
+var sourceRevisionsChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldClass, ok := e.ObjectOld.(*akuityv1alpha1.NamespaceClass)
+		if !ok {
+			return false
+		}
+		newClass, ok := e.ObjectNew.(*akuityv1alpha1.NamespaceClass)
+		if !ok {
+			return false
+		}
+		return !equality.Semantic.DeepEqual(sourceRevisions(oldClass), sourceRevisions(newClass))
+	},
+}
+
+// sourceRevisions maps the index of every fetched source to the revision it resolved to
+func sourceRevisions(class *akuityv1alpha1.NamespaceClass) map[int32]string {
+	revisions := make(map[int32]string, len(class.Status.Sources))
+	for _, status := range class.Status.Sources {
+		revisions[status.Index] = status.URL + "@" + status.Ref + "=" + status.Revision
+	}
+	return revisions
+}
+
+// sourceFetchers are the clients that read fetched sources; a nil client disables its kind of source.
+// Kustomize builds only are cached by kustomize, and are built uncached without it.
+type sourceFetchers struct {
+	git       *GitRepositories
+	oci       *OCIArtifacts
+	helm      *HelmCharts
+	kustomize *KustomizeBuilds
+}
+
+// sourcesHash returns the hash of the source content the class was resolved with, or "" if it has no
+// sources
+func sourcesHash(class *akuityv1alpha1.NamespaceClass) string {
+	return class.Annotations[annotationSourcesHash]
+}
+
+// resolveSources returns the class with the resources of its sources appended to its inline
+// resources. A class without sources is returned as is. A missing source is a permanent failure;
+// the source watches bring the bindings back once it exists. Fetched sources are read at the
+// revision recorded in the class status.
+func resolveSources(ctx context.Context, c client.Reader, fetchers sourceFetchers,
+	class *akuityv1alpha1.NamespaceClass) (*akuityv1alpha1.NamespaceClass, error) {
+	if len(class.Spec.Sources) == 0 {
+		return class, nil
+	}
+
+	resolved := class.DeepCopy()
+	h := sha256.New()
+	for i, source := range class.Spec.Sources {
+		resources, err := sourceResources(ctx, c, fetchers, class, i, source)
+		if err != nil {
+			if errors.IsNotFound(err) || isPermanentFailure(err) {
+				err = permanent(err)
+			}
+			return nil, fmt.Errorf("source %d of NamespaceClass %q: %w", i, class.Name, err)
+		}
+		for _, raw := range resources {
+			h.Write(raw.Raw)
+			h.Write([]byte{0})
+		}
+		resolved.Spec.Resources = append(resolved.Spec.Resources, resources...)
+	}
+
+	if resolved.Annotations == nil {
+		resolved.Annotations = map[string]string{}
+	}
+	resolved.Annotations[annotationSourcesHash] = hex.EncodeToString(h.Sum(nil))
+	return resolved, nil
+}
+
+// sourceResources reads the raw resources of the source at index in the class sources
+func sourceResources(ctx context.Context, c client.Reader, fetchers sourceFetchers,
+	class *akuityv1alpha1.NamespaceClass, index int, source akuityv1alpha1.ResourceSource) ([]runtime.RawExtension, error) {
+	switch {
+	case source.Git != nil:
+		return gitSourceResources(ctx, c, fetchers.git, class, index, source.Git)
+	case source.OCI != nil:
+		return ociSourceResources(ctx, c, fetchers.oci, class, index, source.OCI)
+	case source.Helm != nil:
+		return helmSourceResources(ctx, c, fetchers.helm, class, index, source.Helm)
+	case source.Kustomize != nil:
+		return kustomizeSourceResources(ctx, c, fetchers, class, index, source.Kustomize)
+	case source.ConfigMap != nil:
+		return configMapResources(ctx, c, source.ConfigMap)
+	case source.Fragment != nil:
+		fragment := &akuityv1alpha1.NamespaceClassFragment{}
+		if err := c.Get(ctx, types.NamespacedName{Name: source.Fragment.Name}, fragment); err != nil {
+			return nil, fmt.Errorf("get NamespaceClassFragment %s: %w", source.Fragment.Name, err)
+		}
+		return fragment.Spec.Resources, nil
+	default:
+		return nil, permanent(stderrors.New("no source set"))
+	}
+}
+
+// gitSourceResources reads the resources of a Git source at the commit recorded in the class status
+func gitSourceResources(ctx context.Context, c client.Reader, repos *GitRepositories,
+	class *akuityv1alpha1.NamespaceClass, index int, source *akuityv1alpha1.GitSource) ([]runtime.RawExtension, error) {
+	if repos == nil {
+		return nil, permanent(stderrors.New("git sources are not enabled"))
+	}
+	commit, err := fetchedRevision(class, index, source.URL, source.Ref)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := gitAuth(ctx, c, source)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := repos.resources(ctx, source.URL, commit, source.Path, auth)
+	if err != nil {
+		return nil, fmt.Errorf("git source %s: %w", source.URL, err)
+	}
+	return resources, nil
+}
+
+// ociSourceResources reads the resources of an OCI source from the artifact digest recorded in the
+// class status
+func ociSourceResources(ctx context.Context, c client.Reader, artifacts *OCIArtifacts,
+	class *akuityv1alpha1.NamespaceClass, index int, source *akuityv1alpha1.OCISource) ([]runtime.RawExtension, error) {
+	if artifacts == nil {
+		return nil, permanent(stderrors.New("OCI sources are not enabled"))
+	}
+	digest, err := fetchedRevision(class, index, source.URL, "")
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := ociAuth(ctx, c, source)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := artifacts.resources(ctx, source, digest, auth)
+	if err != nil {
+		return nil, fmt.Errorf("OCI source %s: %w", source.URL, err)
+	}
+	return resources, nil
+}
+
+// helmSourceResources renders the chart of a Helm source. Repository and OCI charts are read at the
+// version or digest recorded in the class status.
+func helmSourceResources(ctx context.Context, c client.Reader, charts *HelmCharts,
+	class *akuityv1alpha1.NamespaceClass, index int, source *akuityv1alpha1.HelmSource) ([]runtime.RawExtension, error) {
+	var archive []byte
+	var err error
+	if source.Chart.ConfigMap != nil {
+		archive, err = configMapChart(ctx, c, source.Chart.ConfigMap)
+	} else {
+		if charts == nil {
+			return nil, permanent(stderrors.New("helm sources are not enabled"))
+		}
+		url, ref := helmChartTarget(source.Chart)
+		var revision string
+		if revision, err = fetchedRevision(class, index, url, ref); err != nil {
+			return nil, err
+		}
+		archive, err = charts.archive(ctx, c, source.Chart, revision)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []runtime.RawExtension
+	if charts != nil {
+		resources, err = charts.render(archive, source)
+	} else {
+		resources, err = renderChart(archive, source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("helm source: %w", err)
+	}
+	return resources, nil
+}
+
+// fetchedRevision returns the revision the class status recorded for the source at index, as long as
+// it was fetched from the same URL and ref
+func fetchedRevision(class *akuityv1alpha1.NamespaceClass, index int, url, ref string) (string, error) {
+	status := fetchedSource(class, index)
+	if status == nil || status.URL != url || status.Ref != ref || status.Revision == "" {
+		// The class status update brings the bindings back once the source is fetched
+		return "", permanent(fmt.Errorf("source %s has not been fetched yet", url))
+	}
+	return status.Revision, nil
+}
+
+// fetchedSourceTarget returns where a fetched source points and how often it is polled. ok is false
+// for sources that are read directly.
+func fetchedSourceTarget(source akuityv1alpha1.ResourceSource) (url, ref string, interval time.Duration, ok bool) {
+	var every *metav1.Duration
+	switch git, oci := sourceGit(source), sourceOCI(source); {
+	case git != nil:
+		url, ref, every = git.URL, git.Ref, git.Interval
+	case oci != nil:
+		url, every = oci.URL, oci.Interval
+	case source.Helm != nil && source.Helm.Chart.ConfigMap == nil:
+		url, ref = helmChartTarget(source.Helm.Chart)
+		every = source.Helm.Interval
+	default:
+		return "", "", 0, false
+	}
+	interval = defaultSourceInterval
+	if every != nil && every.Duration > 0 {
+		interval = every.Duration
+	}
+	return url, ref, interval, true
+}
+
+// syncSources fetches the sources of the class that are due and records the revisions they resolved
+// to in the class status. A failed fetch is reported in the status and keeps the previous revision.
+// It returns how long until the next source is due, or 0 if the class has no fetched sources.
+func (r *NamespaceClassReconciler) syncSources(ctx context.Context,
+	class *akuityv1alpha1.NamespaceClass) (time.Duration, error) {
+	refresh := class.Annotations[annotationRefresh]
+	forced := refresh != class.Status.ObservedRefresh
+	now := time.Now()
+
+	var statuses []akuityv1alpha1.SourceStatus
+	var failures []string
+	var next time.Duration
+	for i, source := range class.Spec.Sources {
+		url, ref, interval, ok := fetchedSourceTarget(source)
+		if !ok {
+			continue
+		}
+
+		// A source that now points somewhere else starts over
+		status := akuityv1alpha1.SourceStatus{Index: int32(i), URL: url, Ref: ref}
+		if prev := fetchedSource(class, i); prev != nil && prev.URL == status.URL && prev.Ref == status.Ref {
+			status = *prev.DeepCopy()
+		}
+
+		if forced || status.LastFetchTime == nil || now.Sub(status.LastFetchTime.Time) >= interval {
+			revision, err := r.fetchSource(ctx, source)
+			fetched := metav1.NewTime(now)
+			status.LastFetchTime = &fetched
+			status.Message = ""
+			switch {
+			case err != nil:
+				status.Message = err.Error()
+				r.Recorder.Event(class, corev1.EventTypeWarning, reasonSourceFetchFailed,
+					fmt.Sprintf("source %d: %v", i, err))
+			case revision != status.Revision:
+				status.Revision = revision
+				r.Recorder.Event(class, corev1.EventTypeNormal, reasonSourceUpdated,
+					fmt.Sprintf("source %d %s is at %s", i, url, revision))
+			}
+		}
+
+		if status.Message != "" {
+			failures = append(failures, fmt.Sprintf("source %d: %s", i, status.Message))
+		}
+		if wait := interval - now.Sub(status.LastFetchTime.Time); next == 0 || wait < next {
+			next = max(wait, time.Second)
+		}
+		statuses = append(statuses, status)
+	}
+
+	if !forced && equality.Semantic.DeepEqual(statuses, class.Status.Sources) {
+		return next, nil
+	}
+	return next, r.patchClassStatus(ctx, class, func(c *akuityv1alpha1.NamespaceClass) {
+		c.Status.Sources = statuses
+		c.Status.ObservedRefresh = refresh
+		switch {
+		case len(statuses) == 0:
+			meta.RemoveStatusCondition(&c.Status.Conditions, akuityv1alpha1.ClassConditionSourcesReady)
+		case len(failures) > 0:
+			meta.SetStatusCondition(&c.Status.Conditions, metav1.Condition{
+				Type:               akuityv1alpha1.ClassConditionSourcesReady,
+				Status:             metav1.ConditionFalse,
+				Reason:             reasonSourceFetchFailed,
+				Message:            strings.Join(failures, "; "),
+				ObservedGeneration: c.Generation,
+			})
+		default:
+			meta.SetStatusCondition(&c.Status.Conditions, metav1.Condition{
+				Type:               akuityv1alpha1.ClassConditionSourcesReady,
+				Status:             metav1.ConditionTrue,
+				Reason:             reasonSourcesFetched,
+				Message:            fmt.Sprintf("%d sources fetched", len(statuses)),
+				ObservedGeneration: c.Generation,
+			})
+		}
+	})
+}
+
+// fetchSource fetches a source and returns the revision it resolves to
+func (r *NamespaceClassReconciler) fetchSource(ctx context.Context, source akuityv1alpha1.ResourceSource) (string, error) {
+	c := sourceReader(r, r.SourceCache, r.SecretNamespace)
+	switch git, oci := sourceGit(source), sourceOCI(source); {
+	case git != nil:
+		if r.Git == nil {
+			return "", stderrors.New("git sources are not enabled")
+		}
+		auth, err := gitAuth(ctx, c, git)
+		if err != nil {
+			return "", err
+		}
+		return r.Git.resolve(ctx, git.URL, git.Ref, auth)
+	case oci != nil:
+		if r.OCI == nil {
+			return "", stderrors.New("OCI sources are not enabled")
+		}
+		auth, err := ociAuth(ctx, c, oci)
+		if err != nil {
+			return "", err
+		}
+		return r.OCI.resolve(ctx, oci, auth)
+	default:
+		if r.Helm == nil {
+			return "", stderrors.New("helm sources are not enabled")
+		}
+		return r.Helm.resolve(ctx, c, source.Helm.Chart)
+	}
+}
+
+// sourceGit returns the Git repository a source reads, directly or as the files of a kustomization
+func sourceGit(source akuityv1alpha1.ResourceSource) *akuityv1alpha1.GitSource {
+	if source.Kustomize != nil {
+		return source.Kustomize.Git
+	}
+	return source.Git
+}
+
+// sourceOCI returns the OCI artifact a source reads, directly or as the files of a kustomization
+func sourceOCI(source akuityv1alpha1.ResourceSource) *akuityv1alpha1.OCISource {
+	if source.Kustomize != nil {
+		return source.Kustomize.OCI
+	}
+	return source.OCI
+}
+
+// fetchedSource returns the status of the source at index in the class sources, or nil if it wasn't
+// fetched
+func fetchedSource(class *akuityv1alpha1.NamespaceClass, index int) *akuityv1alpha1.SourceStatus {
+	for i := range class.Status.Sources {
+		if int(class.Status.Sources[i].Index) == index {
+			return &class.Status.Sources[i]
+		}
+	}
+	return nil
+}
+
+// configMapResources reads the resources in the data of a ConfigMap source
+func configMapResources(ctx context.Context, c client.Reader,
+	source *akuityv1alpha1.ConfigMapSource) ([]runtime.RawExtension, error) {
+	cm, err := sourceConfigMap(ctx, c, source.Namespace, source.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{source.Key}
+	if source.Key == "" {
+		keys = make([]string, 0, len(cm.Data))
+		for key := range cm.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+
+	var resources []runtime.RawExtension
+	for _, key := range keys {
+		data, ok := cm.Data[key]
+		if !ok {
+			return nil, permanent(fmt.Errorf("no key %s in ConfigMap %s/%s", key, source.Namespace, source.Name))
+		}
+		docs, err := splitYAMLDocuments([]byte(data))
+		if err != nil {
+			return nil, permanent(fmt.Errorf("key %s of ConfigMap %s/%s: %w", key, source.Namespace, source.Name, err))
+		}
+		resources = append(resources, docs...)
+	}
+	return resources, nil
+}
+
+// NewSourceCache returns the cache of the ConfigMaps and Secrets class sources read: ConfigMaps with
+// the source label and the Secrets of secretNamespace. It is separate from the manager's cache, so the
+// ConfigMaps and Secrets classes apply are still found there. Add it to the manager to start it.
+func NewSourceCache(config *rest.Config, scheme *runtime.Scheme, mapper meta.RESTMapper,
+	secretNamespace string) (cache.Cache, error) {
+	byObject := map[client.Object]cache.ByObject{
+		&corev1.ConfigMap{}: {Label: labels.SelectorFromSet(labels.Set{LabelSource: "true"})},
+	}
+	if secretNamespace != "" {
+		byObject[&corev1.Secret{}] = cache.ByObject{Namespaces: map[string]cache.Config{secretNamespace: {}}}
+	}
+	return cache.New(config, cache.Options{Scheme: scheme, Mapper: mapper, ByObject: byObject})
+}
+
+// scopedSourceReader reads ConfigMaps and Secrets from the source cache, and Secrets from one
+// namespace only, so classes can't have the operator read credentials from namespaces their authors
+// may have no access to
+type scopedSourceReader struct {
+	client.Reader
+	sources         client.Reader
+	secretNamespace string
+}
+
+// sourceReader returns the reader of class sources, which reads ConfigMaps and Secrets through
+// sources unless it is nil and credentials Secrets from secretNamespace only
+func sourceReader(c, sources client.Reader, secretNamespace string) client.Reader {
+	return scopedSourceReader{Reader: c, sources: sources, secretNamespace: secretNamespace}
+}
+
+// Get refuses Secrets outside the secret namespace
+func (r scopedSourceReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object,
+	opts ...client.GetOption) error {
+	_, isSecret := obj.(*corev1.Secret)
+	_, isConfigMap := obj.(*corev1.ConfigMap)
+	if isSecret && key.Namespace != r.secretNamespace {
+		if r.secretNamespace == "" {
+			return permanent(fmt.Errorf("secret %s can't be read: no namespace for source credentials is configured", key))
+		}
+		return permanent(fmt.Errorf("secret %s can't be read: source credentials are only read from namespace %s",
+			key, r.secretNamespace))
+	}
+	if (isSecret || isConfigMap) && r.sources != nil {
+		return r.sources.Get(ctx, key, obj, opts...)
+	}
+	return r.Reader.Get(ctx, key, obj, opts...)
+}
+
+// sourceConfigMap reads a ConfigMap a class source refers to. ConfigMaps without the source label
+// aren't cached, so they read as missing.
+func sourceConfigMap(ctx context.Context, c client.Reader, namespace, name string) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, cm); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("get ConfigMap %s/%s (source ConfigMaps need the %s=true label): %w",
+				namespace, name, LabelSource, err)
+		}
+		return nil, fmt.Errorf("get ConfigMap %s/%s: %w", namespace, name, err)
+	}
+	return cm, nil
+}
+
+// splitYAMLDocuments converts multi-document YAML into one raw JSON resource per document, skipping
+// empty documents
+func splitYAMLDocuments(data []byte) ([]runtime.RawExtension, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	var docs []runtime.RawExtension
+	for {
+		doc, err := reader.Read()
+		if stderrors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read YAML document: %w", err)
+		}
+		raw, err := utilyaml.ToJSON(doc)
+		if err != nil {
+			return nil, fmt.Errorf("parse YAML document %d: %w", len(docs)+1, err)
+		}
+		if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			continue
+		}
+		docs = append(docs, runtime.RawExtension{Raw: raw})
+	}
+}
+
+// sourceKey identifies a source object in the class sources index
+func sourceKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// indexClassSources indexes a class by the keys of the source objects it reads
+func indexClassSources(obj client.Object) []string {
+	class := obj.(*akuityv1alpha1.NamespaceClass)
+	var keys []string
+	for _, source := range class.Spec.Sources {
+		switch {
+		case source.ConfigMap != nil:
+			keys = append(keys, sourceKey("ConfigMap", source.ConfigMap.Namespace, source.ConfigMap.Name))
+		case source.Fragment != nil:
+			keys = append(keys, sourceKey("NamespaceClassFragment", "", source.Fragment.Name))
+		case source.Helm != nil && source.Helm.Chart.ConfigMap != nil:
+			chart := source.Helm.Chart.ConfigMap
+			keys = append(keys, sourceKey("ConfigMap", chart.Namespace, chart.Name))
+		case source.Kustomize != nil:
+			for _, cm := range source.Kustomize.ConfigMaps {
+				keys = append(keys, sourceKey("ConfigMap", cm.Namespace, cm.Name))
+			}
+		}
+	}
+	return keys
+}
+
+// findBindingsForSource returns reconcile requests for the bindings of every class reading the source
+// object, so they apply its new content
+func (r *NamespaceClassBindingReconciler) findBindingsForSource(kind string) func(context.Context,
+	client.Object) []reconcile.Request {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		var classes akuityv1alpha1.NamespaceClassList
+		if err := r.List(ctx, &classes,
+			client.MatchingFields{classSourcesField: sourceKey(kind, obj.GetNamespace(), obj.GetName())}); err != nil {
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for i := range classes.Items {
+			requests = append(requests, r.findBindingsForClass(ctx, &classes.Items[i])...)
+		}
+		return requests
+	}
+}
+
+// findClassesForSource returns reconcile requests for every class reading the source object, so its
+// new content is rolled out
+func (r *NamespaceClassReconciler) findClassesForSource(kind string) func(context.Context,
+	client.Object) []reconcile.Request {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		var classes akuityv1alpha1.NamespaceClassList
+		if err := r.List(ctx, &classes,
+			client.MatchingFields{classSourcesField: sourceKey(kind, obj.GetNamespace(), obj.GetName())}); err != nil {
+			return nil
+		}
+
+		requests := make([]reconcile.Request, len(classes.Items))
+		for i, class := range classes.Items {
+			requests[i] = reconcile.Request{NamespacedName: types.NamespacedName{Name: class.Name}}
+		}
+		return requests
+	}
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+func TestResolveSources(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+
+	c := newRolloutTestClientBuilder(scheme).WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "resources", Namespace: "platform"},
+			Data: map[string]string{
+				"b.yaml": "apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: runner\n",
+				"a.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: one\n---\n---\n" +
+					"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: two\n",
+			},
+		},
+		&akuityv1alpha1.NamespaceClassFragment{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared"},
+			Spec: akuityv1alpha1.NamespaceClassFragmentSpec{Resources: []runtime.RawExtension{
+				{Raw: []byte(`{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "token"}}`)},
+			}},
+		},
+	).Build()
+
+	class := settingsClass(1, "1")
+	class.Spec.Sources = []akuityv1alpha1.ResourceSource{
+		{ConfigMap: &akuityv1alpha1.ConfigMapSource{Name: "resources", Namespace: "platform"}},
+		{Fragment: &akuityv1alpha1.FragmentSource{Name: "shared"}},
+	}
+
+	// Inline resources come first, then the sources in order; ConfigMap keys are read in key order
+	resolved, err := resolveSources(ctx, c, sourceFetchers{}, class)
+	require.NoError(t, err)
+	parsed := parseClass(context.Background(), nil, resolved)
+	require.NoError(t, parsed.err)
+	var names []string
+	for _, res := range parsed.resources {
+		names = append(names, res.Name)
+	}
+	assert.Equal(t, []string{"settings", "one", "two", "runner", "token"}, names)
+	assert.NotEmpty(t, sourcesHash(resolved))
+	assert.Empty(t, sourcesHash(class))
+
+	// A single key can be selected
+	class.Spec.Sources[0].ConfigMap.Key = "b.yaml"
+	resolved, err = resolveSources(ctx, c, sourceFetchers{}, class)
+	require.NoError(t, err)
+	assert.Len(t, resolved.Spec.Resources, 3)
+
+	// Missing sources and keys are permanent failures
+	class.Spec.Sources[0].ConfigMap.Key = "c.yaml"
+	_, err = resolveSources(ctx, c, sourceFetchers{}, class)
+	assert.True(t, isPermanentFailure(err))
+	class.Spec.Sources[0].ConfigMap.Key = ""
+	class.Spec.Sources[1].Fragment.Name = "missing"
+	_, err = resolveSources(ctx, c, sourceFetchers{}, class)
+	assert.True(t, isPermanentFailure(err))
+}
+
+func TestSourceReader(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	c := newRolloutTestClientBuilder(scheme).WithObjects(
+		&akuityv1alpha1.NamespaceClassFragment{ObjectMeta: metav1.ObjectMeta{Name: "shared"}},
+	).Build()
+	sources := newRolloutTestClientBuilder(scheme).WithObjects(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "resources", Namespace: "platform"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "robot", Namespace: "system"}},
+	).Build()
+	reader := sourceReader(c, sources, "system")
+
+	// ConfigMaps and Secrets come from the source cache, everything else from the client
+	require.NoError(t, reader.Get(ctx, client.ObjectKey{Name: "resources", Namespace: "platform"}, &corev1.ConfigMap{}))
+	require.NoError(t, reader.Get(ctx, client.ObjectKey{Name: "robot", Namespace: "system"}, &corev1.Secret{}))
+	require.NoError(t, reader.Get(ctx, client.ObjectKey{Name: "shared"}, &akuityv1alpha1.NamespaceClassFragment{}))
+
+	// Secrets outside the secret namespace are refused for good
+	err := reader.Get(ctx, client.ObjectKey{Name: "robot", Namespace: "team-a"}, &corev1.Secret{})
+	assert.True(t, isPermanentFailure(err))
+	err = sourceReader(c, sources, "").Get(ctx, client.ObjectKey{Name: "robot", Namespace: "system"}, &corev1.Secret{})
+	assert.True(t, isPermanentFailure(err))
+}
+
+func TestNamespaceClassBindingReconciler_Sources(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "team-a", Namespace: "team-a"}}
+
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "resources", Namespace: "platform"},
+		Data: map[string]string{"resources.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: limits\n" +
+			"data:\n  cpu: \"1\"\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: token\n"},
+	}
+	class := settingsClass(1, "1")
+	class.Spec.Sources = []akuityv1alpha1.ResourceSource{
+		{ConfigMap: &akuityv1alpha1.ConfigMapSource{Name: "resources", Namespace: "platform"}},
+	}
+	c := newRolloutTestClientBuilder(scheme).
+		WithIndex(&akuityv1alpha1.NamespaceClass{}, classSourcesField, indexClassSources).
+		WithObjects(class, source, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			}).
+		Build()
+	r := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	limits := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "limits", Namespace: "team-a"}, limits))
+	assert.Equal(t, "1", limits.Data["cpu"])
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "token", Namespace: "team-a"}, &corev1.Secret{}))
+
+	// Changing the source wakes up the bindings of the class and applies it without a new generation
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(source), source))
+	source.Data["resources.yaml"] = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: limits\ndata:\n  cpu: \"2\"\n"
+	require.NoError(t, c.Update(ctx, source))
+	assert.Equal(t, []ctrl.Request{req}, r.findBindingsForSource("ConfigMap")(ctx, source))
+	assert.Empty(t, r.findBindingsForSource("ConfigMap")(ctx, limits))
+
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "limits", Namespace: "team-a"}, limits))
+	assert.Equal(t, "2", limits.Data["cpu"])
+	err = c.Get(ctx, client.ObjectKey{Name: "token", Namespace: "team-a"}, &corev1.Secret{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// A deleted source degrades the binding until it is back
+	require.NoError(t, c.Delete(ctx, source))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	message := degradedMessage(t, c, req.NamespacedName)
+	assert.Contains(t, message, "ConfigMap platform/resources")
+	assert.Contains(t, message, LabelSource, "unlabeled ConfigMaps read as missing, so the label is hinted at")
+}
+
+func TestNamespaceClassReconciler_SourceRollout(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	classReq := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-class"}}
+	bindingReq := ctrl.Request{NamespacedName: types.NamespacedName{Name: "team-a", Namespace: "team-a"}}
+
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "resources", Namespace: "platform"},
+		Data: map[string]string{"resources.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: limits\n" +
+			"data:\n  cpu: \"1\"\n"},
+	}
+	class := settingsClass(1, "1")
+	class.Spec.RolloutStrategy = &akuityv1alpha1.RolloutStrategy{}
+	class.Spec.Sources = []akuityv1alpha1.ResourceSource{
+		{ConfigMap: &akuityv1alpha1.ConfigMapSource{Name: "resources", Namespace: "platform"}},
+	}
+	c := newRolloutTestClientBuilder(scheme).
+		WithIndex(&akuityv1alpha1.NamespaceClass{}, classSourcesField, indexClassSources).
+		WithObjects(class, source, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			}).
+		Build()
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+	bindingReconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+	cpu := func() string {
+		limits := &corev1.ConfigMap{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "limits", Namespace: "team-a"}, limits))
+		return limits.Data["cpu"]
+	}
+
+	_, err := bindingReconciler.Reconcile(ctx, bindingReq)
+	require.NoError(t, err)
+	assert.Equal(t, "1", cpu())
+
+	// New source content waits for the rollout like a new generation
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(source), source))
+	source.Data["resources.yaml"] = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: limits\ndata:\n  cpu: \"2\"\n"
+	require.NoError(t, c.Update(ctx, source))
+	assert.Equal(t, []ctrl.Request{classReq}, classReconciler.findClassesForSource("ConfigMap")(ctx, source))
+	_, err = bindingReconciler.Reconcile(ctx, bindingReq)
+	require.NoError(t, err)
+	assert.Equal(t, "1", cpu())
+
+	// The class admits the binding to the generation together with the source content
+	_, err = classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+	binding := &akuityv1alpha1.NamespaceClassBinding{}
+	require.NoError(t, c.Get(ctx, bindingReq.NamespacedName, binding))
+	require.NoError(t, c.Get(ctx, classReq.NamespacedName, class))
+	require.NotNil(t, class.Status.Rollout)
+	assert.NotEmpty(t, class.Status.Rollout.SourcesHash)
+	assert.Equal(t, "1/"+class.Status.Rollout.SourcesHash, binding.Annotations[annotationRolloutGeneration])
+	_, err = bindingReconciler.Reconcile(ctx, bindingReq)
+	require.NoError(t, err)
+	assert.Equal(t, "2", cpu())
+
+	// The revision keeps the source content it was taken with, so a binding pinned to it doesn't
+	// follow later edits of the source
+	revision := &akuityv1alpha1.NamespaceClassRevision{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "test-class-1"}, revision))
+	assert.Equal(t, class.Status.Rollout.SourcesHash, revision.Spec.SourcesHash)
+	require.Len(t, revision.Spec.SourceResources, 1)
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(source), source))
+	source.Data["resources.yaml"] = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: limits\ndata:\n  cpu: \"3\"\n"
+	require.NoError(t, c.Update(ctx, source))
+	require.NoError(t, c.Get(ctx, bindingReq.NamespacedName, binding))
+	binding.Spec.Revision = "test-class-1"
+	require.NoError(t, c.Update(ctx, binding))
+	_, err = bindingReconciler.Reconcile(ctx, bindingReq)
+	require.NoError(t, err)
+	assert.Equal(t, "2", cpu())
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// annotationSwitchImpact is set on a namespace to the impact of its pending class switch
+	annotationSwitchImpact = "namespaceclass.akuity.io/switch-impact"
+
+	// annotationConfirmSwitch is set on a namespace to the class it may switch to despite deletions
+	annotationConfirmSwitch = "namespaceclass.akuity.io/confirm-switch"
+
+	// reasonClassSwitchHeld marks a class switch waiting for confirmation
+	reasonClassSwitchHeld = "ClassSwitchHeld"
+
+	// reasonClassSwitchImpact reports what a class switch changes in the namespace
+	reasonClassSwitchImpact = "ClassSwitchImpact"
+
+	// switchRetryInterval is how often a switch held on unknown class content is evaluated again
+	switchRetryInterval = 30 * time.Second
+)
+
+// switchImpact is what switching a namespace from one class to another does to its resources
+type switchImpact struct {
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+	Delete []string `json:"delete,omitempty"`
+	Change []string `json:"change,omitempty"`
+	Add    []string `json:"add,omitempty"`
+
+	// Unknown is why the content of the new class can't be read, for instance because its sources
+	// haven't been fetched yet. The switch could then delete anything the binding applied.
+	Unknown string `json:"unknown,omitempty"`
+}
+
+// String summarizes the impact for events
+func (i *switchImpact) String() string {
+	if i.Unknown != "" {
+		return fmt.Sprintf("the impact of switching from class %s to %s is unknown: %s", i.From, i.To, i.Unknown)
+	}
+	return fmt.Sprintf("switching from class %s to %s deletes %d, changes %d and adds %d resources",
+		i.From, i.To, len(i.Delete), len(i.Change), len(i.Add))
+}
+
+// computeSwitchImpact compares what the binding applied with what the new class would apply. When
+// the new class content can't be read or parsed, the impact only records why.
+func (r *NamespaceReconciler) computeSwitchImpact(ctx context.Context, binding *akuityv1alpha1.NamespaceClassBinding,
+	className string) (*switchImpact, error) {
+	var to []parsedResource
+	class := &akuityv1alpha1.NamespaceClass{}
+	if err := r.Get(ctx, types.NamespacedName{Name: className}, class); err == nil {
+		resolved, err := resolveSources(ctx, sourceReader(r, r.SourceCache, r.SecretNamespace),
+			sourceFetchers{git: r.Git, oci: r.OCI, helm: r.Helm, kustomize: r.Kustomize}, class)
+		if err != nil {
+			if isPermanentFailure(err) {
+				return &switchImpact{From: binding.Spec.ClassName, To: className, Unknown: err.Error()}, nil
+			}
+			return nil, err
+		}
+		parsed := parseClass(ctx, r.Renderer, resolved)
+		if parsed.err != nil {
+			if isPermanentFailure(parsed.err) {
+				return &switchImpact{From: binding.Spec.ClassName, To: className, Unknown: parsed.err.Error()}, nil
+			}
+			return nil, parsed.err
+		}
+		to = parsed.resources
+	} else if !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("get NamespaceClass %s: %w", className, err)
+	}
+
+	from, fromKnown, err := observedResources(ctx, sourceReader(r, r.SourceCache, r.SecretNamespace),
+		sourceFetchers{git: r.Git, oci: r.OCI, helm: r.Helm, kustomize: r.Kustomize}, r.Renderer, binding)
+	if err != nil {
+		return nil, err
+	}
+
+	impact := &switchImpact{From: binding.Spec.ClassName, To: className}
+	for _, change := range planChanges(binding.Status.AppliedResources, renderResources(from, binding.Namespace),
+		renderResources(to, binding.Namespace), fromKnown) {
+		ref := changeRef(change)
+		switch change.Type {
+		case akuityv1alpha1.ResourceChangeRemoved:
+			impact.Delete = append(impact.Delete, ref)
+		case akuityv1alpha1.ResourceChangeChanged:
+			impact.Change = append(impact.Change, ref)
+		case akuityv1alpha1.ResourceChangeAdded:
+			impact.Add = append(impact.Add, ref)
+		}
+	}
+	return impact, nil
+}
+
+// changeRef formats the resource of a change as Kind/name or Kind/namespace/name
+func changeRef(change akuityv1alpha1.ResourceChange) string {
+	parts := []string{change.Kind}
+	if change.Namespace != "" {
+		parts = append(parts, change.Namespace)
+	}
+	return strings.Join(append(parts, change.Name), "/")
+}
+
+// switchHeld reports whether the switch must wait for confirmation: the namespace hasn't confirmed
+// the switch, and either the new class content is unknown or the switch deletes resources and the
+// class being left isn't safe to switch from
+func (r *NamespaceReconciler) switchHeld(ctx context.Context, namespace *corev1.Namespace,
+	impact *switchImpact) (bool, error) {
+	if impact == nil || namespace.Annotations[annotationConfirmSwitch] == impact.To {
+		return false, nil
+	}
+	if impact.Unknown != "" {
+		return true, nil
+	}
+	if len(impact.Delete) == 0 {
+		return false, nil
+	}
+
+	current := &akuityv1alpha1.NamespaceClass{}
+	if err := r.Get(ctx, types.NamespacedName{Name: impact.From}, current); err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("get NamespaceClass %s: %w", impact.From, err)
+	}
+	return !current.Spec.SafeToSwitch, nil
+}
+
+// publishHeldSwitch records the impact of a held switch on the namespace and reports it in an event,
+// once per distinct impact
+func (r *NamespaceReconciler) publishHeldSwitch(ctx context.Context, namespace *corev1.Namespace,
+	impact *switchImpact) error {
+	b, err := json.Marshal(impact)
+	if err != nil {
+		return err
+	}
+	if namespace.Annotations[annotationSwitchImpact] == string(b) {
+		return nil
+	}
+
+	base := namespace.DeepCopy()
+	if namespace.Annotations == nil {
+		namespace.Annotations = map[string]string{}
+	}
+	namespace.Annotations[annotationSwitchImpact] = string(b)
+	if err := r.Patch(ctx, namespace, client.MergeFrom(base)); err != nil {
+		return fmt.Errorf("annotate switch impact: %w", err)
+	}
+
+	message := impact.String()
+	if len(impact.Delete) > 0 {
+		message += " (" + strings.Join(impact.Delete, ", ") + ")"
+	}
+	r.Recorder.Event(namespace, corev1.EventTypeWarning, reasonClassSwitchHeld,
+		fmt.Sprintf("%s; set the %s annotation to %q to switch", message, annotationConfirmSwitch, impact.To))
+	return nil
+}
+
+// clearSwitchAnnotations removes the impact and confirmation of a switch once it went ahead, so a
+// stale confirmation can't approve a later switch
+func (r *NamespaceReconciler) clearSwitchAnnotations(ctx context.Context, namespace *corev1.Namespace) error {
+	_, hasImpact := namespace.Annotations[annotationSwitchImpact]
+	_, hasConfirm := namespace.Annotations[annotationConfirmSwitch]
+	if !hasImpact && !hasConfirm {
+		return nil
+	}
+
+	base := namespace.DeepCopy()
+	delete(namespace.Annotations, annotationSwitchImpact)
+	delete(namespace.Annotations, annotationConfirmSwitch)
+	return r.Patch(ctx, namespace, client.MergeFrom(base))
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// labelFieldSpec is a label map inside a resource that common labels are added to besides its own
+// labels. Selectors that the resource leaves empty are only created if create is set, since an
+// empty selector often means "select everything".
+type labelFieldSpec struct {
+	path   []string
+	create bool
+}
+
+var (
+	// workloadLabelFields are the selector and pod template of the apps workloads
+	workloadLabelFields = []labelFieldSpec{
+		{path: []string{"spec", "selector", "matchLabels"}, create: true},
+		{path: []string{"spec", "template", "metadata", "labels"}, create: true},
+	}
+
+	// commonLabelFields lists, per kind, where common labels go besides metadata.labels, following
+	// the defaults of kustomize
+	commonLabelFields = map[string][]labelFieldSpec{
+		"Deployment":  workloadLabelFields,
+		"ReplicaSet":  workloadLabelFields,
+		"DaemonSet":   workloadLabelFields,
+		"StatefulSet": workloadLabelFields,
+		"ReplicationController": {
+			{path: []string{"spec", "selector"}, create: true},
+			{path: []string{"spec", "template", "metadata", "labels"}, create: true},
+		},
+		"Service":             {{path: []string{"spec", "selector"}, create: true}},
+		"Job":                 {{path: []string{"spec", "template", "metadata", "labels"}, create: true}},
+		"CronJob":             {{path: []string{"spec", "jobTemplate", "spec", "template", "metadata", "labels"}, create: true}},
+		"PodDisruptionBudget": {{path: []string{"spec", "selector", "matchLabels"}}},
+		"NetworkPolicy":       {{path: []string{"spec", "podSelector", "matchLabels"}}},
+	}
+
+	// podTemplatePaths lists, per kind, the pod template whose annotations get common annotations
+	podTemplatePaths = map[string][]string{
+		"Deployment":            {"spec", "template"},
+		"ReplicaSet":            {"spec", "template"},
+		"DaemonSet":             {"spec", "template"},
+		"StatefulSet":           {"spec", "template"},
+		"ReplicationController": {"spec", "template"},
+		"Job":                   {"spec", "template"},
+		"CronJob":               {"spec", "jobTemplate", "spec", "template"},
+	}
+
+	// unprefixedKinds keep their names under namePrefix and nameSuffix, as in kustomize
+	unprefixedKinds = map[string]bool{
+		"Namespace":                true,
+		"CustomResourceDefinition": true,
+	}
+
+	// nameReferenceFields lists, per kind, the fields that refer to other resources by name, following
+	// the defaults of kustomize's nameReference
+	nameReferenceFields = newNameReferenceFields()
+)
+
+// newNameReferenceFields returns the name references of every kind that has them
+func newNameReferenceFields() map[string][]nameReferenceSpec {
+	bindingReferences := []nameReferenceSpec{
+		{kind: "Role", kindField: "kind", path: []string{"roleRef", "name"}},
+		{kind: "ClusterRole", kindField: "kind", path: []string{"roleRef", "name"}},
+		{kind: "ServiceAccount", kindField: "kind", path: []string{"subjects", "*", "name"}},
+	}
+	fields := map[string][]nameReferenceSpec{
+		"Pod": podSpecReferences("spec"),
+		"ServiceAccount": {
+			{kind: "Secret", path: []string{"secrets", "*", "name"}},
+			{kind: "Secret", path: []string{"imagePullSecrets", "*", "name"}},
+		},
+		"RoleBinding":        bindingReferences,
+		"ClusterRoleBinding": bindingReferences,
+		"Ingress": {
+			{kind: "Service", path: []string{"spec", "defaultBackend", "service", "name"}},
+			{kind: "Service", path: []string{"spec", "rules", "*", "http", "paths", "*", "backend", "service", "name"}},
+			{kind: "Secret", path: []string{"spec", "tls", "*", "secretName"}},
+		},
+	}
+	for kind, template := range podTemplatePaths {
+		fields[kind] = podSpecReferences(append(append([]string{}, template...), "spec")...)
+	}
+	fields["StatefulSet"] = append(fields["StatefulSet"],
+		nameReferenceSpec{kind: "Service", path: []string{"spec", "serviceName"}})
+	return fields
+}
+
+// nameReferenceSpec is a field holding the name of a resource of kind. A path step of "*" steps into
+// every item of a list. With kindField set, the field only refers to kind if its sibling kindField
+// names it, as in the roleRef and subjects of RBAC bindings.
+type nameReferenceSpec struct {
+	kind      string
+	kindField string
+	path      []string
+}
+
+// podSpecReferences returns the references of the pod spec at path
+func podSpecReferences(path ...string) []nameReferenceSpec {
+	at := func(kind string, rel ...string) nameReferenceSpec {
+		return nameReferenceSpec{kind: kind, path: append(append([]string{}, path...), rel...)}
+	}
+	refs := []nameReferenceSpec{
+		at("ServiceAccount", "serviceAccountName"),
+		at("Secret", "imagePullSecrets", "*", "name"),
+		at("ConfigMap", "volumes", "*", "configMap", "name"),
+		at("Secret", "volumes", "*", "secret", "secretName"),
+		at("ConfigMap", "volumes", "*", "projected", "sources", "*", "configMap", "name"),
+		at("Secret", "volumes", "*", "projected", "sources", "*", "secret", "name"),
+		at("PersistentVolumeClaim", "volumes", "*", "persistentVolumeClaim", "claimName"),
+	}
+	for _, containers := range []string{"containers", "initContainers"} {
+		refs = append(refs,
+			at("ConfigMap", containers, "*", "env", "*", "valueFrom", "configMapKeyRef", "name"),
+			at("Secret", containers, "*", "env", "*", "valueFrom", "secretKeyRef", "name"),
+			at("ConfigMap", containers, "*", "envFrom", "*", "configMapRef", "name"),
+			at("Secret", containers, "*", "envFrom", "*", "secretRef", "name"))
+	}
+	return refs
+}
+
+// validateCommonTransforms rejects common labels the operator reserves for tracking
+func validateCommonTransforms(spec *akuityv1alpha1.NamespaceClassSpec) error {
+	for key := range spec.CommonLabels {
+		if strings.HasPrefix(key, reservedKeyPrefix) {
+			return fmt.Errorf("commonLabels may not set %s; keys under %s are reserved", key, reservedKeyPrefix)
+		}
+	}
+	return nil
+}
+
+// applyCommonTransforms adds the class's common labels and annotations to the resource and renames
+// it with the name prefix and suffix. It runs when the class is parsed, so the rendered name is the
+// one tracked in binding status and pruning compares names with the same prefix applied. References
+// to renamed resources are rewritten by renameReferences once the whole class is renamed.
+func applyCommonTransforms(spec *akuityv1alpha1.NamespaceClassSpec, res *parsedResource) error {
+	u := res.Object
+	if !unprefixedKinds[res.Kind] {
+		res.Name = spec.NamePrefix + res.Name + spec.NameSuffix
+		u.SetName(res.Name)
+	}
+
+	if len(spec.CommonLabels) > 0 {
+		u.SetLabels(mergeStringMaps(u.GetLabels(), spec.CommonLabels))
+		for _, field := range commonLabelFields[res.Kind] {
+			if err := mergeNestedStringMap(u, spec.CommonLabels, field.create, field.path...); err != nil {
+				return fmt.Errorf("add common labels to %s: %w", strings.Join(field.path, "."), err)
+			}
+		}
+	}
+
+	if len(spec.CommonAnnotations) > 0 {
+		u.SetAnnotations(mergeStringMaps(u.GetAnnotations(), spec.CommonAnnotations))
+		if template, ok := podTemplatePaths[res.Kind]; ok {
+			path := append(append([]string{}, template...), "metadata", "annotations")
+			if err := mergeNestedStringMap(u, spec.CommonAnnotations, true, path...); err != nil {
+				return fmt.Errorf("add common annotations to %s: %w", strings.Join(path, "."), err)
+			}
+		}
+	}
+	return nil
+}
+
+// renameReferences points the name references of the resource at the new names of the class resources
+// they refer to. renamed maps kind and old name to the new name; references to anything outside the
+// class keep their names.
+func renameReferences(res *parsedResource, renamed map[string]map[string]string) {
+	for _, ref := range nameReferenceFields[res.Kind] {
+		renameReference(res.Object.Object, ref, ref.path, renamed)
+	}
+}
+
+// renameReference renames the reference at path below node
+func renameReference(node interface{}, ref nameReferenceSpec, path []string, renamed map[string]map[string]string) {
+	switch node := node.(type) {
+	case []interface{}:
+		if path[0] != "*" {
+			return
+		}
+		for _, item := range node {
+			renameReference(item, ref, path[1:], renamed)
+		}
+	case map[string]interface{}:
+		if path[0] == "*" {
+			return
+		}
+		if len(path) > 1 {
+			renameReference(node[path[0]], ref, path[1:], renamed)
+			return
+		}
+		if ref.kindField != "" && node[ref.kindField] != ref.kind {
+			return
+		}
+		if name, ok := node[path[0]].(string); ok {
+			if newName, ok := renamed[ref.kind][name]; ok {
+				node[path[0]] = newName
+			}
+		}
+	}
+}
+
+// mergeNestedStringMap adds values to the string map at path, creating it only if create is set
+func mergeNestedStringMap(u *unstructured.Unstructured, values map[string]string, create bool,
+	path ...string) error {
+	existing, found, err := unstructured.NestedStringMap(u.Object, path...)
+	if err != nil {
+		return err
+	}
+	if !found && !create {
+		return nil
+	}
+	return unstructured.SetNestedStringMap(u.Object, mergeStringMaps(existing, values), path...)
+}
+
+// mergeStringMaps returns base with values added, values taking precedence
+func mergeStringMaps(base, values map[string]string) map[string]string {
+	out := make(map[string]string, len(base)+len(values))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range values {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+func TestApplyCommonTransforms(t *testing.T) {
+	class := &akuityv1alpha1.NamespaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-class"},
+		Spec: akuityv1alpha1.NamespaceClassSpec{
+			CommonLabels:      map[string]string{"team": "platform"},
+			CommonAnnotations: map[string]string{"owner": "platform@example.com"},
+			NamePrefix:        "pf-",
+			NameSuffix:        "-v1",
+			Resources: []runtime.RawExtension{
+				{Raw: []byte(`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web",
+					"labels": {"app": "web"}}, "spec": {"selector": {"matchLabels": {"app": "web"}},
+					"template": {"metadata": {"labels": {"app": "web"}}}}}`)},
+				{Raw: []byte(`{"apiVersion": "networking.k8s.io/v1", "kind": "NetworkPolicy",
+					"metadata": {"name": "deny-all"}, "spec": {"podSelector": {}}}`)},
+				{Raw: []byte(`{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "$(NAMESPACE)-sidecar"}}`)},
+			},
+		},
+	}
+
+	parsed := parseClass(context.Background(), nil, class)
+	require.NoError(t, parsed.err)
+	require.Len(t, parsed.resources, 3)
+
+	// Workloads get the labels on their selector and pod template too
+	deployment := parsed.resources[0]
+	assert.Equal(t, "pf-web-v1", deployment.Name)
+	assert.Equal(t, "pf-web-v1", deployment.Object.GetName())
+	assert.Equal(t, map[string]string{"app": "web", "team": "platform"}, deployment.Object.GetLabels())
+	for _, path := range [][]string{
+		{"spec", "selector", "matchLabels"},
+		{"spec", "template", "metadata", "labels"},
+	} {
+		labels, _, err := unstructured.NestedStringMap(deployment.Object.Object, path...)
+		require.NoError(t, err)
+		assert.Equal(t, "platform", labels["team"], path)
+	}
+	annotations, _, err := unstructured.NestedStringMap(deployment.Object.Object,
+		"spec", "template", "metadata", "annotations")
+	require.NoError(t, err)
+	assert.Equal(t, "platform@example.com", annotations["owner"])
+
+	// An empty pod selector selects every pod and stays that way
+	policy := parsed.resources[1]
+	_, found, err := unstructured.NestedStringMap(policy.Object.Object, "spec", "podSelector", "matchLabels")
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, "platform", policy.Object.GetLabels()["team"])
+
+	// Namespaces keep their names
+	assert.Equal(t, "$(NAMESPACE)-sidecar", parsed.resources[2].Name)
+
+	// Tracking labels can't be overridden
+	class.Spec.CommonLabels[labelBindingNamespace] = "other"
+	parsed = parseClass(context.Background(), nil, class)
+	require.Error(t, parsed.err)
+	assert.True(t, isPermanentFailure(parsed.err))
+}
+
+func TestRenameReferences(t *testing.T) {
+	class := &akuityv1alpha1.NamespaceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-class"},
+		Spec: akuityv1alpha1.NamespaceClassSpec{
+			NamePrefix: "pf-",
+			Resources: []runtime.RawExtension{
+				{Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings"}}`)},
+				{Raw: []byte(`{"apiVersion": "v1", "kind": "ServiceAccount", "metadata": {"name": "runner"}}`)},
+				{Raw: []byte(`{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "Role",
+					"metadata": {"name": "reader"}}`)},
+				{Raw: []byte(`{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "RoleBinding",
+					"metadata": {"name": "reader"},
+					"roleRef": {"apiGroup": "rbac.authorization.k8s.io", "kind": "Role", "name": "reader"},
+					"subjects": [{"kind": "ServiceAccount", "name": "runner"},
+						{"kind": "ServiceAccount", "name": "default"},
+						{"kind": "User", "name": "runner"}]}`)},
+				{Raw: []byte(`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web"},
+					"spec": {"template": {"spec": {"serviceAccountName": "runner",
+						"volumes": [{"name": "settings", "configMap": {"name": "settings"}}],
+						"containers": [{"name": "web", "envFrom": [{"configMapRef": {"name": "settings"}},
+							{"secretRef": {"name": "external"}}]}]}}}}`)},
+			},
+		},
+	}
+
+	parsed := parseClass(context.Background(), nil, class)
+	require.NoError(t, parsed.err)
+	require.Len(t, parsed.resources, 5)
+
+	// References to class resources follow their new names, references to anything else don't
+	binding := parsed.resources[3].Object.Object
+	roleName, _, err := unstructured.NestedString(binding, "roleRef", "name")
+	require.NoError(t, err)
+	assert.Equal(t, "pf-reader", roleName)
+	subjects, _, err := unstructured.NestedSlice(binding, "subjects")
+	require.NoError(t, err)
+	var subjectNames []string
+	for _, subject := range subjects {
+		subjectNames = append(subjectNames, subject.(map[string]interface{})["name"].(string))
+	}
+	assert.Equal(t, []string{"pf-runner", "default", "runner"}, subjectNames)
+
+	podSpec, _, err := unstructured.NestedMap(parsed.resources[4].Object.Object, "spec", "template", "spec")
+	require.NoError(t, err)
+	assert.Equal(t, "pf-runner", podSpec["serviceAccountName"])
+	volumes := podSpec["volumes"].([]interface{})
+	assert.Equal(t, "pf-settings", volumes[0].(map[string]interface{})["configMap"].(map[string]interface{})["name"])
+	envFrom := podSpec["containers"].([]interface{})[0].(map[string]interface{})["envFrom"].([]interface{})
+	assert.Equal(t, "pf-settings", envFrom[0].(map[string]interface{})["configMapRef"].(map[string]interface{})["name"])
+	assert.Equal(t, "external", envFrom[1].(map[string]interface{})["secretRef"].(map[string]interface{})["name"])
+}
+
+func TestNamespaceClassBindingReconciler_NamePrefix(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-ns", Namespace: "test-ns"}}
+
+	class := settingsClass(1, "1")
+	class.Spec.NamePrefix = "team-"
+	class.Spec.CommonLabels = map[string]string{"managed-by": "namespaceclass"}
+	reconciler, c := newBindingTestReconciler(scheme,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-ns"}},
+		&akuityv1alpha1.NamespaceClassBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-ns", Namespace: "test-ns"},
+			Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+		},
+		class,
+	)
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "team-settings", Namespace: "test-ns"}, cm))
+	assert.Equal(t, "namespaceclass", cm.Labels["managed-by"])
+
+	// Changing the prefix creates the renamed object and prunes the old one
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(class), class))
+	class.Generation = 2
+	class.Spec.NamePrefix = "platform-"
+	require.NoError(t, c.Update(ctx, class))
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "platform-settings", Namespace: "test-ns"}, cm))
+	err = c.Get(ctx, client.ObjectKey{Name: "team-settings", Namespace: "test-ns"}, cm)
+	assert.True(t, errors.IsNotFound(err))
+
+	binding := &akuityv1alpha1.NamespaceClassBinding{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, binding))
+	require.Len(t, binding.Status.AppliedResources, 1)
+	assert.Equal(t, "platform-settings", binding.Status.AppliedResources[0].Name)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+const (
+	// reasonDryRunSucceeded marks a generation the API server accepted in every canary namespace
+	reasonDryRunSucceeded = "DryRunSucceeded"
+
+	// reasonDryRunFailed marks a generation the API server rejected in a canary namespace
+	reasonDryRunFailed = "DryRunFailed"
+
+	// reasonDryRunError marks a generation whose dry run couldn't complete and is retried
+	reasonDryRunError = "DryRunError"
+
+	// reasonCanaryNamespaceMissing marks a generation that can't be validated because a canary
+	// namespace doesn't exist. It is validated once the namespace is created.
+	reasonCanaryNamespaceMissing = "CanaryNamespaceMissing"
+
+	// reasonValidationFailed marks a rollout blocked because the generation failed validation
+	reasonValidationFailed = "ValidationFailed"
+)
+
+// validateClass dry-runs the current content of the resolved class into its canary namespaces and
+// returns the Validated condition, or nil when the class has no canary namespaces. Content is only
+// validated once per generation and source content. Errors that may pass on retry are returned as is.
+func (r *NamespaceClassReconciler) validateClass(ctx context.Context,
+	class *akuityv1alpha1.NamespaceClass) (*metav1.Condition, error) {
+	if len(class.Spec.CanaryNamespaces) == 0 {
+		return nil, nil
+	}
+	// The condition is written along with the rollout status, which names the source content
+	if cond := meta.FindStatusCondition(class.Status.Conditions,
+		akuityv1alpha1.ClassConditionValidated); cond != nil && cond.ObservedGeneration == class.Generation &&
+		cond.Status != metav1.ConditionUnknown &&
+		class.Status.Rollout != nil && class.Status.Rollout.SourcesHash == sourcesHash(class) {
+		validated := *cond
+		return &validated, nil
+	}
+
+	cond := &metav1.Condition{
+		Type:               akuityv1alpha1.ClassConditionValidated,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonDryRunSucceeded,
+		Message:            fmt.Sprintf("dry run succeeded in namespaces %v", class.Spec.CanaryNamespaces),
+		ObservedGeneration: class.Generation,
+	}
+	rejected := func(err error) *metav1.Condition {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = reasonDryRunFailed
+		cond.Message = err.Error()
+		return cond
+	}
+
+	parsed := parseClass(ctx, r.Renderer, class)
+	if parsed.err != nil {
+		if isPermanentFailure(parsed.err) {
+			return rejected(parsed.err), nil
+		}
+		return nil, parsed.err
+	}
+	for _, namespace := range class.Spec.CanaryNamespaces {
+		// A missing canary namespace is a mistake in the class, not in its content
+		if err := r.Get(ctx, types.NamespacedName{Name: namespace}, &corev1.Namespace{}); err != nil {
+			if !errors.IsNotFound(err) {
+				return nil, fmt.Errorf("get canary namespace %s: %w", namespace, err)
+			}
+			cond.Status = metav1.ConditionUnknown
+			cond.Reason = reasonCanaryNamespaceMissing
+			cond.Message = fmt.Sprintf("canary namespace %s doesn't exist", namespace)
+			return cond, nil
+		}
+		for _, res := range renderResources(parsed.resources, namespace) {
+			err := r.dryRunResource(ctx, res, namespace)
+			switch {
+			case err == nil:
+			case errors.IsInvalid(err) || errors.IsBadRequest(err) || errors.IsNotFound(err):
+				return rejected(fmt.Errorf("%s %s in canary namespace %s: %w", res.Kind, res.Name, namespace, err)), nil
+			default:
+				return nil, fmt.Errorf("dry run %s %s in namespace %s: %w", res.Kind, res.Name, namespace, err)
+			}
+		}
+	}
+	return cond, nil
+}
+
+// validationPending returns the Validated condition of a class whose dry run failed with err and is
+// retried
+func validationPending(class *akuityv1alpha1.NamespaceClass, err error) metav1.Condition {
+	return metav1.Condition{
+		Type:               akuityv1alpha1.ClassConditionValidated,
+		Status:             metav1.ConditionUnknown,
+		Reason:             reasonDryRunError,
+		Message:            fmt.Sprintf("dry run in the canary namespaces is retried: %v", err),
+		ObservedGeneration: class.Generation,
+	}
+}
+
+// dryRunResource applies a rendered class resource to namespace as a server-side dry run. Kinds that
+// aren't installed are skipped; bindings leave them pending as well.
+func (r *NamespaceClassReconciler) dryRunResource(ctx context.Context, res parsedResource, namespace string) error {
+	u := res.Object.DeepCopy()
+	u.SetAPIVersion(res.APIVersion)
+	u.SetKind(res.Kind)
+	u.SetName(res.Name)
+
+	gvk := u.GroupVersionKind()
+	mapping, err := r.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameRoot && u.GetNamespace() == "" {
+		u.SetNamespace(namespace)
+	}
+
+	return r.Patch(ctx, u, client.Apply, client.DryRunAll,
+		client.FieldOwner(bindingControllerName), client.ForceOwnership)
+}
+
+// findClassesForCanary returns reconcile requests for every class that names the namespace as canary
+// namespace, so a generation held on the missing namespace is validated once it is created
+func (r *NamespaceClassReconciler) findClassesForCanary(ctx context.Context, obj client.Object) []reconcile.Request {
+	var classes akuityv1alpha1.NamespaceClassList
+	if err := r.List(ctx, &classes); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, class := range classes.Items {
+		if slices.Contains(class.Spec.CanaryNamespaces, obj.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: class.Name}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+func TestNamespaceClassReconciler_CanaryValidation(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+
+	class := settingsClass(1, "1")
+	class.Spec.CanaryNamespaces = []string{"canary"}
+	objs := []client.Object{class, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "canary"}}}
+	for _, name := range []string{"team-a", "team-b"} {
+		objs = append(objs,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: name},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			},
+		)
+	}
+
+	// The API server rejects the ConfigMap the second generation adds; count the dry runs
+	dryRuns := 0
+	c := newRolloutTestClientBuilder(scheme).
+		WithObjects(objs...).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+				opts ...client.PatchOption) error {
+				patchOpts := &client.PatchOptions{}
+				patchOpts.ApplyOptions(opts)
+				if len(patchOpts.DryRun) > 0 {
+					dryRuns++
+					assert.Equal(t, "canary", obj.GetNamespace())
+				}
+				if patch == client.Apply && obj.GetName() == "broken" {
+					return errors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "broken",
+						field.ErrorList{field.Invalid(field.NewPath("data"), "x", "rejected")})
+				}
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	recorder := record.NewFakeRecorder(10)
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: recorder}
+	bindingReconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(30)}
+	version := func(namespace string) string {
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: namespace}, cm))
+		return cm.Data["version"]
+	}
+
+	// A valid generation passes the dry run once and rolls out
+	current := reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a", "team-b")
+	assert.True(t, meta.IsStatusConditionTrue(current.Status.Conditions, akuityv1alpha1.ClassConditionValidated))
+	assert.Equal(t, 1, dryRuns)
+	assert.Equal(t, "1", version("team-a"))
+	for len(recorder.Events) > 0 {
+		<-recorder.Events
+	}
+
+	// The dry run itself writes nothing
+	cm := &corev1.ConfigMap{}
+	err := c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: "canary"}, cm)
+	assert.True(t, errors.IsNotFound(err))
+
+	// A rejected generation stays out of every bound namespace
+	current.Generation = 2
+	current.Spec.Resources = append(settingsClass(2, "2").Spec.Resources, runtime.RawExtension{
+		Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "broken"}}`),
+	})
+	require.NoError(t, c.Update(ctx, current))
+	current = reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a", "team-b")
+	cond := meta.FindStatusCondition(current.Status.Conditions, akuityv1alpha1.ClassConditionValidated)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, reasonDryRunFailed, cond.Reason)
+	assert.Contains(t, cond.Message, "broken")
+	progressing := meta.FindStatusCondition(current.Status.Conditions, akuityv1alpha1.ClassConditionRolloutProgressing)
+	require.NotNil(t, progressing)
+	assert.Equal(t, reasonValidationFailed, progressing.Reason)
+	assert.Equal(t, "1", version("team-a"))
+	assert.Equal(t, "1", version("team-b"))
+	select {
+	case event := <-recorder.Events:
+		assert.Contains(t, event, reasonValidationFailed)
+	default:
+		t.Error("expected a ValidationFailed event")
+	}
+
+	// A fixed generation is validated again and rolls out
+	current.Generation = 3
+	current.Spec.Resources = current.Spec.Resources[:1]
+	require.NoError(t, c.Update(ctx, current))
+	current = reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a", "team-b")
+	assert.True(t, meta.IsStatusConditionTrue(current.Status.Conditions, akuityv1alpha1.ClassConditionValidated))
+	assert.Equal(t, "2", version("team-a"))
+	assert.Equal(t, "2", version("team-b"))
+}
+
+func TestNamespaceClassReconciler_CanaryValidationRetried(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+
+	class := settingsClass(1, "1")
+	class.Spec.CanaryNamespaces = []string{"canary"}
+
+	// The API server can't be reached for the first dry run
+	unavailable := true
+	c := newRolloutTestClientBuilder(scheme).
+		WithObjects(class, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "canary"}}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+				opts ...client.PatchOption) error {
+				if patch == client.Apply && unavailable {
+					return errors.NewServiceUnavailable("try again")
+				}
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+	classReq := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-class"}}
+
+	// The retry is reported on the class
+	_, err := classReconciler.Reconcile(ctx, classReq)
+	require.Error(t, err)
+	current := &akuityv1alpha1.NamespaceClass{}
+	require.NoError(t, c.Get(ctx, classReq.NamespacedName, current))
+	cond := meta.FindStatusCondition(current.Status.Conditions, akuityv1alpha1.ClassConditionValidated)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionUnknown, cond.Status)
+	assert.Equal(t, reasonDryRunError, cond.Reason)
+	assert.Contains(t, cond.Message, "try again")
+
+	// The next attempt validates the generation instead of keeping the pending result
+	unavailable = false
+	_, err = classReconciler.Reconcile(ctx, classReq)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, classReq.NamespacedName, current))
+	assert.True(t, meta.IsStatusConditionTrue(current.Status.Conditions, akuityv1alpha1.ClassConditionValidated))
+}
+
+func TestNamespaceClassReconciler_CanaryValidationSourceChange(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "resources", Namespace: "platform"},
+		Data:       map[string]string{"resources.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: limits\n"},
+	}
+	class := settingsClass(1, "1")
+	class.Spec.CanaryNamespaces = []string{"canary"}
+	class.Spec.Sources = []akuityv1alpha1.ResourceSource{
+		{ConfigMap: &akuityv1alpha1.ConfigMapSource{Name: "resources", Namespace: "platform"}},
+	}
+
+	// The API server rejects the ConfigMap named broken
+	c := newRolloutTestClientBuilder(scheme).
+		WithObjects(class, source, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "canary"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+				opts ...client.PatchOption) error {
+				if patch == client.Apply && obj.GetName() == "broken" {
+					return errors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "broken",
+						field.ErrorList{field.Invalid(field.NewPath("data"), "x", "rejected")})
+				}
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+	bindingReconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(30)}
+
+	current := reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a")
+	assert.True(t, meta.IsStatusConditionTrue(current.Status.Conditions, akuityv1alpha1.ClassConditionValidated))
+
+	// Rejected source content of a validated generation is validated again and held
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(source), source))
+	source.Data["resources.yaml"] = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: broken\n"
+	require.NoError(t, c.Update(ctx, source))
+	current = reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a")
+	cond := meta.FindStatusCondition(current.Status.Conditions, akuityv1alpha1.ClassConditionValidated)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Contains(t, cond.Message, "broken")
+	err := c.Get(ctx, client.ObjectKey{Name: "limits", Namespace: "team-a"}, &corev1.ConfigMap{})
+	assert.NoError(t, err, "the binding keeps the content it applied")
+}
+
+func TestNamespaceClassReconciler_CanaryNamespaceMissing(t *testing.T) {
+	scheme := newBindingTestScheme(t)
+	ctx := context.Background()
+
+	class := settingsClass(1, "1")
+	class.Spec.CanaryNamespaces = []string{"canary"}
+	canary := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "canary"}}
+	c := newRolloutTestClientBuilder(scheme).
+		WithObjects(class, canary, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&akuityv1alpha1.NamespaceClassBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: "team-a"},
+				Spec:       akuityv1alpha1.NamespaceClassBindingSpec{ClassName: "test-class"},
+			}).
+		Build()
+	classReconciler := &NamespaceClassReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+	bindingReconciler := &NamespaceClassBindingReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(30)}
+	version := func() string {
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "settings", Namespace: "team-a"}, cm))
+		return cm.Data["version"]
+	}
+	current := reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a")
+	assert.Equal(t, "1", version())
+
+	// Without the canary namespace the next generation is held without being rejected
+	require.NoError(t, c.Delete(ctx, canary))
+	current.Generation = 2
+	current.Spec.Resources = settingsClass(2, "2").Spec.Resources
+	require.NoError(t, c.Update(ctx, current))
+	current = reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a")
+	cond := meta.FindStatusCondition(current.Status.Conditions, akuityv1alpha1.ClassConditionValidated)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionUnknown, cond.Status)
+	assert.Equal(t, reasonCanaryNamespaceMissing, cond.Reason)
+	assert.Contains(t, cond.Message, "canary")
+	assert.Equal(t, "1", version())
+
+	// Creating the namespace brings the class back, and the generation is validated and rolled out
+	canary = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "canary"}}
+	require.NoError(t, c.Create(ctx, canary))
+	assert.Equal(t, []ctrl.Request{{NamespacedName: types.NamespacedName{Name: "test-class"}}},
+		classReconciler.findClassesForCanary(ctx, canary))
+	current = reconcileRollout(t, c, classReconciler, bindingReconciler, "team-a")
+	assert.True(t, meta.IsStatusConditionTrue(current.Status.Conditions, akuityv1alpha1.ClassConditionValidated))
+	assert.Equal(t, "2", version())
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// labelNamespaceClass is the Namespace label naming the class to bind
+const labelNamespaceClass = "namespaceclass.akuity.io/name"
+
+// namespacelog is for logging in this package.
+var namespacelog = logf.Log.WithName("namespace-resource")
+
+// SetupNamespaceWebhookWithManager registers the webhook for Namespace in the manager.
+func SetupNamespaceWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&corev1.Namespace{}).
+		WithValidator(&NamespaceCustomValidator{Reader: mgr.GetAPIReader()}).
+		Complete()
+}
+
+// The webhook fails open so namespaces can still be created and labelled while the operator is down.
+// config/webhook narrows it to namespaces with the class label.
+// +kubebuilder:webhook:path=/validate--v1-namespace,mutating=false,failurePolicy=ignore,sideEffects=None,groups=core,resources=namespaces,verbs=create;update,versions=v1,name=vnamespace-v1.kb.io,admissionReviewVersions=v1
+
+// NamespaceCustomValidator rejects class labels naming a NamespaceClass that doesn't exist. Without it
+// the binding created for such a label is deleted again right away and the typo goes unnoticed.
+type NamespaceCustomValidator struct {
+	// Reader looks up classes. It should read from the API server so a class created just before the
+	// namespace is found.
+	Reader client.Reader
+}
+
+var _ webhook.CustomValidator = &NamespaceCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Namespace.
+func (v *NamespaceCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	namespace, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return nil, fmt.Errorf("expected a Namespace object but got %T", obj)
+	}
+	namespacelog.V(1).Info("validation for Namespace upon creation", "name", namespace.GetName())
+
+	return nil, v.validateClassLabel(ctx, namespace)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Namespace.
+// Only a changed label is checked, so namespaces whose class was deleted can still be updated.
+func (v *NamespaceCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	namespace, ok := newObj.(*corev1.Namespace)
+	if !ok {
+		return nil, fmt.Errorf("expected a Namespace object for the newObj but got %T", newObj)
+	}
+	old, ok := oldObj.(*corev1.Namespace)
+	if !ok {
+		return nil, fmt.Errorf("expected a Namespace object for the oldObj but got %T", oldObj)
+	}
+	namespacelog.V(1).Info("validation for Namespace upon update", "name", namespace.GetName())
+
+	if old.Labels[labelNamespaceClass] == namespace.Labels[labelNamespaceClass] {
+		return nil, nil
+	}
+	return nil, v.validateClassLabel(ctx, namespace)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Namespace.
+func (v *NamespaceCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateClassLabel checks that the class named by the namespace's class label exists, suggesting the
+// closest class name when it doesn't
+func (v *NamespaceCustomValidator) validateClassLabel(ctx context.Context, namespace *corev1.Namespace) error {
+	className, ok := namespace.Labels[labelNamespaceClass]
+	if !ok || className == "" {
+		return nil
+	}
+
+	err := v.Reader.Get(ctx, types.NamespacedName{Name: className}, &akuityv1alpha1.NamespaceClass{})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return apierrors.NewInternalError(fmt.Errorf("get NamespaceClass %s: %w", className, err))
+	}
+
+	var classes akuityv1alpha1.NamespaceClassList
+	if err := v.Reader.List(ctx, &classes); err != nil {
+		return apierrors.NewInternalError(fmt.Errorf("list NamespaceClasses: %w", err))
+	}
+	names := make([]string, 0, len(classes.Items))
+	for _, class := range classes.Items {
+		names = append(names, class.Name)
+	}
+
+	message := fmt.Sprintf("NamespaceClass %q does not exist", className)
+	if suggestion := closestName(className, names); suggestion != "" {
+		message += fmt.Sprintf("; did you mean %q?", suggestion)
+	} else {
+		message += "; list the available classes with 'kubectl get namespaceclasses'"
+	}
+	fldPath := field.NewPath("metadata", "labels").Key(labelNamespaceClass)
+	return apierrors.NewInvalid(corev1.SchemeGroupVersion.WithKind("Namespace").GroupKind(), namespace.Name,
+		field.ErrorList{field.Invalid(fldPath, className, message)})
+}
+
+// closestName returns the name closest to value by edit distance, or "" if none is close enough to be
+// a likely typo. Ties go to the name listed first.
+func closestName(value string, names []string) string {
+	best, bestDistance := "", 0
+	for _, name := range names {
+		distance := editDistance(strings.ToLower(value), strings.ToLower(name))
+		if best == "" || distance < bestDistance {
+			best, bestDistance = name, distance
+		}
+	}
+	if best == "" || bestDistance > max(2, len(value)/3) {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// labelledNamespace returns the team-a namespace with the class label set to className
+func labelledNamespace(className string) *corev1.Namespace {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}
+	if className != "" {
+		namespace.Labels = map[string]string{labelNamespaceClass: className}
+	}
+	return namespace
+}
+
+func TestNamespaceCustomValidator(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, akuityv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&akuityv1alpha1.NamespaceClass{ObjectMeta: metav1.ObjectMeta{Name: "standard"}},
+		&akuityv1alpha1.NamespaceClass{ObjectMeta: metav1.ObjectMeta{Name: "restricted"}},
+	).Build()
+	validator := &NamespaceCustomValidator{Reader: c}
+
+	// Namespaces without the label or with an existing class are allowed
+	_, err := validator.ValidateCreate(ctx, labelledNamespace(""))
+	require.NoError(t, err)
+	_, err = validator.ValidateCreate(ctx, labelledNamespace("standard"))
+	require.NoError(t, err)
+
+	// A typo is rejected with the closest class suggested
+	_, err = validator.ValidateCreate(ctx, labelledNamespace("standrad"))
+	require.Error(t, err)
+	assert.True(t, apierrors.IsInvalid(err))
+	assert.Contains(t, err.Error(), `metadata.labels[namespaceclass.akuity.io/name]: Invalid value: "standrad"`)
+	assert.Contains(t, err.Error(), `NamespaceClass "standrad" does not exist; did you mean "standard"?`)
+
+	// Names that aren't close to any class get no suggestion
+	_, err = validator.ValidateCreate(ctx, labelledNamespace("gpu-workloads"))
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "did you mean")
+	assert.Contains(t, err.Error(), "kubectl get namespaceclasses")
+
+	// Updates are only checked when the label changes, so namespaces of deleted classes can be edited
+	_, err = validator.ValidateUpdate(ctx, labelledNamespace("deleted"), labelledNamespace("deleted"))
+	require.NoError(t, err)
+	_, err = validator.ValidateUpdate(ctx, labelledNamespace("standard"), labelledNamespace("Restricted"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `did you mean "restricted"?`)
+}
+
+func TestClosestName(t *testing.T) {
+	names := []string{"restricted", "standard", "standard-gpu"}
+	assert.Equal(t, "standard", closestName("standart", names))
+	assert.Equal(t, "standard-gpu", closestName("standard-gp", names))
+	assert.Equal(t, "restricted", closestName("RESTRICTED", names))
+	assert.Empty(t, closestName("batch", names))
+	assert.Empty(t, closestName("standard", nil))
+}
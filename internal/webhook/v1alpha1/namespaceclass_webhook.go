@@ -0,0 +1,235 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// placeholderNamespace stands for the bound namespace in class resources
+const placeholderNamespace = "$(NAMESPACE)"
+
+// namespaceclasslog is for logging in this package.
+var namespaceclasslog = logf.Log.WithName("namespaceclass-resource")
+
+// SetupNamespaceClassWebhookWithManager registers the webhook for NamespaceClass in the manager.
+// Classes may not contain the forbidden kinds, given as Kind or Kind.group.
+func SetupNamespaceClassWebhookWithManager(mgr ctrl.Manager, forbiddenKinds []string) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&akuityv1alpha1.NamespaceClass{}).
+		WithValidator(&NamespaceClassCustomValidator{
+			RESTMapper:     mgr.GetRESTMapper(),
+			ForbiddenKinds: forbiddenKinds,
+		}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-akuity-io-v1alpha1-namespaceclass,mutating=false,failurePolicy=fail,sideEffects=None,groups=akuity.io,resources=namespaceclasses,verbs=create;update,versions=v1alpha1,name=vnamespaceclass-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// NamespaceClassCustomValidator rejects classes whose inline resources can't be applied, so broken
+// classes are caught when they are written instead of when bindings reconcile them. Resources read
+// from sources aren't known at admission time and are only checked by the bindings.
+type NamespaceClassCustomValidator struct {
+	// RESTMapper resolves whether kinds are cluster-scoped. Kinds it doesn't know yet are allowed,
+	// as bindings leave them pending until their CRDs are installed.
+	RESTMapper meta.RESTMapper
+
+	// ForbiddenKinds lists the kinds classes may not contain, as Kind for every group or Kind.group
+	// for one group, e.g. "ClusterRoleBinding.rbac.authorization.k8s.io"
+	ForbiddenKinds []string
+}
+
+var _ webhook.CustomValidator = &NamespaceClassCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type NamespaceClass.
+func (v *NamespaceClassCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	class, ok := obj.(*akuityv1alpha1.NamespaceClass)
+	if !ok {
+		return nil, fmt.Errorf("expected a NamespaceClass object but got %T", obj)
+	}
+	namespaceclasslog.V(1).Info("validation for NamespaceClass upon creation", "name", class.GetName())
+
+	return nil, v.validateClass(class)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type NamespaceClass.
+// Only a changed spec is checked, so classes that no longer pass, say after a kind was forbidden, can still
+// have their labels, annotations and finalizers updated.
+func (v *NamespaceClassCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	class, ok := newObj.(*akuityv1alpha1.NamespaceClass)
+	if !ok {
+		return nil, fmt.Errorf("expected a NamespaceClass object for the newObj but got %T", newObj)
+	}
+	old, ok := oldObj.(*akuityv1alpha1.NamespaceClass)
+	if !ok {
+		return nil, fmt.Errorf("expected a NamespaceClass object for the oldObj but got %T", oldObj)
+	}
+	namespaceclasslog.V(1).Info("validation for NamespaceClass upon update", "name", class.GetName())
+
+	if equality.Semantic.DeepEqual(old.Spec, class.Spec) {
+		return nil, nil
+	}
+	return nil, v.validateClass(class)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type NamespaceClass.
+func (v *NamespaceClassCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// classResource holds the fields of a class resource the webhook checks
+type classResource struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+// validateClass checks every inline resource of the class and returns an Invalid error listing all
+// problems found, or an error when the RESTMapper can't be consulted
+func (v *NamespaceClassCustomValidator) validateClass(class *akuityv1alpha1.NamespaceClass) error {
+	var errs field.ErrorList
+	seen := map[string]int{}
+	resourcesPath := field.NewPath("spec", "resources")
+	for i, raw := range class.Spec.Resources {
+		fldPath := resourcesPath.Index(i)
+
+		var res classResource
+		if err := json.Unmarshal(raw.Raw, &res); err != nil {
+			errs = append(errs, field.Invalid(fldPath, string(raw.Raw), fmt.Sprintf("not a Kubernetes object: %v", err)))
+			continue
+		}
+		if res.APIVersion == "" {
+			errs = append(errs, field.Required(fldPath.Child("apiVersion"), ""))
+		}
+		if res.Kind == "" {
+			errs = append(errs, field.Required(fldPath.Child("kind"), ""))
+		}
+		if res.Metadata.Name == "" {
+			errs = append(errs, field.Required(fldPath.Child("metadata", "name"), ""))
+		}
+		if res.APIVersion == "" || res.Kind == "" || res.Metadata.Name == "" {
+			continue
+		}
+
+		gv, err := schema.ParseGroupVersion(res.APIVersion)
+		if err != nil {
+			errs = append(errs, field.Invalid(fldPath.Child("apiVersion"), res.APIVersion, err.Error()))
+			continue
+		}
+		gvk := gv.WithKind(res.Kind)
+
+		// Resources without a namespace are created in the bound namespace too
+		namespace := res.Metadata.Namespace
+		if namespace == placeholderNamespace {
+			namespace = ""
+		}
+		key := strings.Join([]string{gvk.Group, gvk.Kind, namespace, res.Metadata.Name}, "/")
+		if first, ok := seen[key]; ok {
+			errs = append(errs, field.Duplicate(fldPath, fmt.Sprintf("%s %s, first defined in %s",
+				res.Kind, res.Metadata.Name, resourcesPath.Index(first))))
+			continue
+		}
+		seen[key] = i
+
+		if v.forbidden(gvk.GroupKind()) {
+			errs = append(errs, field.Forbidden(fldPath.Child("kind"),
+				fmt.Sprintf("%s is not allowed in NamespaceClasses", gvk.GroupKind())))
+			continue
+		}
+
+		clusterScoped, err := v.clusterScoped(gvk)
+		if err != nil {
+			return apierrors.NewInternalError(fmt.Errorf("resolve scope of %s: %w", gvk.GroupKind(), err))
+		}
+		switch {
+		case clusterScoped && !class.Spec.AllowClusterScoped:
+			errs = append(errs, field.Forbidden(fldPath.Child("kind"),
+				fmt.Sprintf("%s is cluster-scoped; set spec.allowClusterScoped to opt in", res.Kind)))
+		case clusterScoped && res.Metadata.Namespace != "":
+			errs = append(errs, field.Forbidden(fldPath.Child("metadata", "namespace"),
+				fmt.Sprintf("%s is cluster-scoped and must not set a namespace", res.Kind)))
+		case !clusterScoped && !namespaceAllowed(class, res.Metadata.Namespace):
+			errs = append(errs, field.Forbidden(fldPath.Child("metadata", "namespace"),
+				"resources are created in the bound namespace; other namespaces must be listed in "+
+					"spec.allowedTargetNamespaces"))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(akuityv1alpha1.GroupVersion.WithKind("NamespaceClass").GroupKind(), class.Name, errs)
+}
+
+// forbidden reports whether gk is on the forbidden list
+func (v *NamespaceClassCustomValidator) forbidden(gk schema.GroupKind) bool {
+	for _, kind := range v.ForbiddenKinds {
+		if kind == gk.Kind || kind == gk.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterScoped reports whether the kind is cluster-scoped. Unknown kinds are treated as namespaced.
+func (v *NamespaceClassCustomValidator) clusterScoped(gvk schema.GroupVersionKind) (bool, error) {
+	if v.RESTMapper == nil {
+		return false, nil
+	}
+	mapping, err := v.RESTMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return mapping.Scope.Name() == meta.RESTScopeNameRoot, nil
+}
+
+// namespaceAllowed reports whether a namespaced resource may set metadata.namespace to namespace. The
+// bound namespace is always allowed; other namespaces must match spec.allowedTargetNamespaces as
+// written, placeholders included.
+func namespaceAllowed(class *akuityv1alpha1.NamespaceClass, namespace string) bool {
+	if namespace == "" || namespace == placeholderNamespace {
+		return true
+	}
+	for _, pattern := range class.Spec.AllowedTargetNamespaces {
+		if ok, err := path.Match(pattern, namespace); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	akuityv1alpha1 "github.com/jacobboykin/namespaceclass-operator/api/v1alpha1"
+)
+
+// newTestRESTMapper knows ConfigMaps and Roles as namespaced and ClusterRoles as cluster-scoped
+func newTestRESTMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"},
+		meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+		meta.RESTScopeRoot)
+	return mapper
+}
+
+// testClass returns a class with the given raw resources
+func testClass(resources ...string) *akuityv1alpha1.NamespaceClass {
+	class := &akuityv1alpha1.NamespaceClass{ObjectMeta: metav1.ObjectMeta{Name: "test-class"}}
+	for _, res := range resources {
+		class.Spec.Resources = append(class.Spec.Resources, runtime.RawExtension{Raw: []byte(res)})
+	}
+	return class
+}
+
+func TestNamespaceClassCustomValidator(t *testing.T) {
+	ctx := context.Background()
+	validator := &NamespaceClassCustomValidator{
+		RESTMapper:     newTestRESTMapper(),
+		ForbiddenKinds: []string{"Role.rbac.authorization.k8s.io", "Secret"},
+	}
+
+	tests := []struct {
+		name     string
+		class    *akuityv1alpha1.NamespaceClass
+		messages []string
+	}{
+		{
+			name: "accepts namespaced resources and kinds that aren't installed yet",
+			class: testClass(
+				`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings"}}`,
+				`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "other", "namespace": "$(NAMESPACE)"}}`,
+				`{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "settings"}}`,
+			),
+		},
+		{
+			name: "requires apiVersion, kind and name",
+			class: testClass(
+				`{"kind": "ConfigMap", "metadata": {"name": "settings"}}`,
+				`{"apiVersion": "v1", "metadata": {}}`,
+			),
+			messages: []string{
+				"spec.resources[0].apiVersion: Required value",
+				"spec.resources[1].kind: Required value",
+				"spec.resources[1].metadata.name: Required value",
+			},
+		},
+		{
+			name: "rejects duplicates",
+			class: testClass(
+				`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings"}}`,
+				`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings", "namespace": "$(NAMESPACE)"}}`,
+			),
+			messages: []string{"spec.resources[1]: Duplicate value: \"ConfigMap settings, first defined in spec.resources[0]\""},
+		},
+		{
+			name: "rejects namespaces that aren't allowed targets",
+			class: testClass(
+				`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings", "namespace": "kube-system"}}`,
+			),
+			messages: []string{"spec.resources[0].metadata.namespace: Forbidden"},
+		},
+		{
+			name: "rejects cluster-scoped kinds",
+			class: testClass(
+				`{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "ClusterRole", "metadata": {"name": "reader"}}`,
+			),
+			messages: []string{"ClusterRole is cluster-scoped; set spec.allowClusterScoped to opt in"},
+		},
+		{
+			name: "rejects forbidden kinds",
+			class: testClass(
+				`{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "Role", "metadata": {"name": "admin"}}`,
+				`{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "token"}}`,
+			),
+			messages: []string{
+				"spec.resources[0].kind: Forbidden: Role.rbac.authorization.k8s.io is not allowed",
+				"spec.resources[1].kind: Forbidden: Secret is not allowed",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validator.ValidateCreate(ctx, tt.class)
+			if len(tt.messages) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, apierrors.IsInvalid(err))
+			for _, message := range tt.messages {
+				assert.Contains(t, err.Error(), message)
+			}
+		})
+	}
+
+	t.Run("allows what the class opts in to", func(t *testing.T) {
+		class := testClass(
+			`{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "ClusterRole", "metadata": {"name": "$(NAMESPACE)-reader"}}`,
+			`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings", "namespace": "$(NAMESPACE)-monitoring"}}`,
+		)
+		class.Spec.AllowClusterScoped = true
+		class.Spec.AllowedTargetNamespaces = []string{"$(NAMESPACE)-*"}
+		_, err := validator.ValidateUpdate(ctx, testClass(), class)
+		require.NoError(t, err)
+	})
+	t.Run("skips updates that leave the spec alone", func(t *testing.T) {
+		old := testClass(`{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "token"}}`)
+		class := old.DeepCopy()
+		class.Labels = map[string]string{"team": "platform"}
+		_, err := validator.ValidateUpdate(ctx, old, class)
+		require.NoError(t, err)
+
+		class.Spec.Resources = append(class.Spec.Resources, runtime.RawExtension{
+			Raw: []byte(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings"}}`),
+		})
+		_, err = validator.ValidateUpdate(ctx, old, class)
+		assert.ErrorContains(t, err, "Secret is not allowed")
+	})
+}